@@ -0,0 +1,838 @@
+// Package api provides a client for the bit.io developer API. Unlike the
+// top-level bitdotio package, api does not depend on pgx, so programs that only
+// need HTTP access to bit.io (e.g. serverless functions or WASM builds) can
+// import it without pulling in the Postgres stack.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// apiVersion is the currently supported API version.
+	apiVersion string = "v2beta"
+
+	// apiURL is the URL of the bit.io developer API service.
+	apiURL string = "https://api.bit.io"
+
+	// AppName identifies the client to bit.io.
+	AppName string = "go-bitdotio-sdk"
+
+	// ClientVersion is the version of the go-bitdotio library being used.
+	ClientVersion string = "0.0.0b"
+
+	// UserAgent identifies the client to bit.io during HTTP requests and direct
+	// Postgres connections.
+	UserAgent string = AppName + ClientVersion
+
+	// wakeRetryInterval is the interval between retries of a query against a
+	// database that is waking from hibernation.
+	wakeRetryInterval time.Duration = time.Second
+)
+
+// Client implements methods for the bit.io developer API.
+//
+// Client's methods are safe for use across multiple goroutines. Some user-only
+// API methods may receive 403 Forbidden responses if called using a service
+// account token. See docs.bit.io for the latest API reference and further
+// information about service accounts.
+type Client struct {
+	accessToken string
+	apiClient   APIClient
+	// tokenLock guards the per-database token registry, which is shared with
+	// Clients derived with WithConfig.
+	tokenLock  *sync.RWMutex
+	dbTokens   map[string]string
+	apiClients map[string]APIClient
+	// wakeTimeout holds the time.Duration set by SetWakeTimeout.
+	wakeTimeout atomic.Int64
+	statsHook   atomic.Pointer[StatsHook]
+	auditHook   atomic.Pointer[AuditHook]
+	lastCall    atomic.Pointer[lastCall]
+	// deprecationHook holds the hook set by SetDeprecationHook, and
+	// deprecations the endpoints whose deprecation has been logged.
+	deprecationHook atomic.Pointer[DeprecationHook]
+	deprecations    *deprecationLog
+	cache           *metadataCache
+	config          ClientConfig
+}
+
+// NewClient constructs a new Client for a provided API key.
+func NewClient(accessToken string) *Client {
+	return NewClientWithConfig(accessToken, &ClientConfig{})
+}
+
+// NewClientWithConfig constructs a new Client for a provided API key with the
+// options in config.
+func NewClientWithConfig(accessToken string, config *ClientConfig) *Client {
+	c := &Client{
+		accessToken:  accessToken,
+		tokenLock:    &sync.RWMutex{},
+		dbTokens:     make(map[string]string),
+		apiClients:   make(map[string]APIClient),
+		cache:        newMetadataCache(config.MetadataCacheTTL),
+		deprecations: &deprecationLog{},
+		config:       *config,
+	}
+	apiClient := NewDefaultAPIClient(accessToken)
+	if httpClient := newHTTPClient(config); httpClient != nil {
+		apiClient.HTTPClient = httpClient
+	}
+	apiClient.BaseURL = config.BaseURL
+	apiClient.UserAgent = userAgent(config.UserAgentSuffix)
+	apiClient.Middleware = config.Middleware
+	apiClient.versions = newVersionNegotiator(config.APIVersions)
+	c.apiClient = apiClient
+	return c
+}
+
+// WithConfig constructs a derived Client that shares c's access token,
+// per-database tokens, and HTTP client, and so its connections, but uses the
+// options in config, such as a different Logger or Timeout. If config sets an
+// HTTPClient or Transport, the derived Client uses it instead. The wake timeout
+// and stats hook are copied from c, and the metadata cache is shared with c,
+// so that config's MetadataCacheTTL is ignored.
+func (c *Client) WithConfig(config *ClientConfig) *Client {
+	derived := &Client{
+		accessToken:  c.accessToken,
+		tokenLock:    c.tokenLock,
+		dbTokens:     c.dbTokens,
+		apiClients:   make(map[string]APIClient),
+		cache:        c.cache,
+		deprecations: c.deprecations,
+		config:       *config,
+	}
+	derived.wakeTimeout.Store(c.wakeTimeout.Load())
+	derived.statsHook.Store(c.statsHook.Load())
+	derived.auditHook.Store(c.auditHook.Load())
+	derived.deprecationHook.Store(c.deprecationHook.Load())
+	defaultClient, ok := c.apiClient.(*DefaultAPIClient)
+	if !ok {
+		derived.apiClient = c.apiClient
+		return derived
+	}
+	apiClient := c.newDefaultAPIClient(c.accessToken)
+	if httpClient := newHTTPClient(config); httpClient != nil {
+		apiClient.HTTPClient = httpClient
+	}
+	apiClient.BaseURL = defaultClient.BaseURL
+	if config.BaseURL != "" {
+		apiClient.BaseURL = config.BaseURL
+	}
+	apiClient.UserAgent = userAgent(config.UserAgentSuffix)
+	if config.Middleware != nil {
+		apiClient.Middleware = config.Middleware
+	}
+	derived.apiClient = apiClient
+	return derived
+}
+
+// userAgent returns the User-Agent header for API requests, with an optional
+// suffix identifying the application.
+func userAgent(suffix string) string {
+	if suffix == "" {
+		return UserAgent
+	}
+	return UserAgent + " " + suffix
+}
+
+// AsServiceAccount constructs a child Client that authenticates with a service
+// account key, such as one returned by CreateServiceAccountKey. The child
+// reuses the parent's HTTP client and settings but has its own per-database
+// tokens.
+func (c *Client) AsServiceAccount(credentials *Credentials) *Client {
+	child := NewClientWithConfig(credentials.APIKEY, &c.config)
+	child.wakeTimeout.Store(c.wakeTimeout.Load())
+	child.statsHook.Store(c.statsHook.Load())
+	child.auditHook.Store(c.auditHook.Load())
+	child.deprecationHook.Store(c.deprecationHook.Load())
+	if _, ok := c.apiClient.(*DefaultAPIClient); ok {
+		child.apiClient = c.newDefaultAPIClient(credentials.APIKEY)
+	}
+	return child
+}
+
+// SetWakeTimeout sets how long Query retries a database that is waking from
+// hibernation before returning an error. bit.io databases sleep after a period
+// of inactivity, and the first query then fails while the database wakes. The
+// default of 0 disables retries.
+func (c *Client) SetWakeTimeout(timeout time.Duration) {
+	c.wakeTimeout.Store(int64(timeout))
+}
+
+//
+// Credential Methods
+//
+
+// SetDatabaseToken associates an access token with a bit.io database. dbName
+// must be a full, user-qualified database name (e.g. `username/dbname`). API
+// calls scoped to the database authenticate with accessToken instead of the
+// token used to construct the Client. This allows a single Client to span
+// personal and service account credentials. Pools are looked up by token, so
+// for a Client that is part of a BitDotIO, use BitDotIO.SetDatabaseToken,
+// which also moves an open pool for the database to the new token.
+func (c *Client) SetDatabaseToken(dbName, accessToken string) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	c.dbTokens[dbName] = accessToken
+}
+
+// RemoveDatabaseToken removes a database-specific access token, reverting the
+// database to the token used to construct the Client.
+func (c *Client) RemoveDatabaseToken(dbName string) {
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	delete(c.dbTokens, dbName)
+}
+
+// TokenFor returns the access token to use for a bit.io database.
+func (c *Client) TokenFor(dbName string) string {
+	c.tokenLock.RLock()
+	defer c.tokenLock.RUnlock()
+	if token, ok := c.dbTokens[dbName]; ok {
+		return token
+	}
+	return c.accessToken
+}
+
+// apiClientFor returns the API client to use for requests scoped to a bit.io
+// database, creating and caching a client if the database has its own token.
+func (c *Client) apiClientFor(dbName string) APIClient {
+	token := c.TokenFor(dbName)
+	if token == c.accessToken {
+		return c.apiClient
+	}
+	c.tokenLock.Lock()
+	defer c.tokenLock.Unlock()
+	if apiClient, ok := c.apiClients[token]; ok {
+		return apiClient
+	}
+	apiClient := c.newDefaultAPIClient(token)
+	c.apiClients[token] = apiClient
+	return apiClient
+}
+
+// newDefaultAPIClient constructs a DefaultAPIClient for token that shares the
+// settings and underlying HTTP client, and its connections, of c's API client
+// when possible.
+func (c *Client) newDefaultAPIClient(token string) *DefaultAPIClient {
+	apiClient := NewDefaultAPIClient(token)
+	if defaultClient, ok := c.apiClient.(*DefaultAPIClient); ok {
+		apiClient.HTTPClient = defaultClient.HTTPClient
+		apiClient.BaseURL = defaultClient.BaseURL
+		apiClient.UserAgent = defaultClient.UserAgent
+		apiClient.RequestID = defaultClient.RequestID
+		apiClient.Middleware = defaultClient.Middleware
+		apiClient.APIVersion = defaultClient.APIVersion
+		apiClient.versions = defaultClient.versions
+	}
+	return apiClient
+}
+
+// logf logs a diagnostic message if the Client has a Logger.
+func (c *Client) logf(format string, v ...interface{}) {
+	c.logfCtx(context.Background(), format, v...)
+}
+
+// logfCtx logs a diagnostic message about an operation with ctx if the Client
+// has a Logger, passing ctx to a ContextLogger.
+func (c *Client) logfCtx(ctx context.Context, format string, v ...interface{}) {
+	switch logger := c.config.Logger.(type) {
+	case nil:
+	case ContextLogger:
+		logger.LogfCtx(ctx, format, v...)
+	default:
+		logger.Printf(format, v...)
+	}
+}
+
+//
+// API Methods
+//
+
+// ListDatabases lists metadata for all databases that you own or are a collaborator on.
+func (c *Client) ListDatabases(opts ...CallOption) ([]*Database, error) {
+	if databases, ok := c.cache.getList(); ok {
+		return databases, nil
+	}
+	data, err := c.call(c.apiClient, "GET", "db/", nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get list of databases: %w", err)
+		return nil, err
+	}
+	var databaseList DatabaseList
+	if err = json.Unmarshal(data, &databaseList); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+		return databaseList.Databases, err
+	}
+	c.cache.putList(databaseList.Databases)
+	return databaseList.Databases, nil
+}
+
+// CreateDatabase creates a new database.
+func (c *Client) CreateDatabase(databaseConfig *DatabaseConfig, opts ...CallOption) (*Database, error) {
+	if err := ValidateName("database", databaseConfig.Name); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(databaseConfig)
+	if err != nil {
+		err = fmt.Errorf("failed to serialize new database params: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClient, "POST", "db/", body, opts...)
+	c.cache.invalidate()
+	if err != nil {
+		err = fmt.Errorf("failed to create database: %w", err)
+		return nil, err
+	}
+	var database Database
+	if err = json.Unmarshal(data, &database); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &database, err
+}
+
+// EnsureDatabase returns an existing database with the name given by
+// databaseConfig, or creates it if no such database exists, so that scripts
+// can be safely rerun. The name may be either a bare database name or a full,
+// user-qualified name (e.g. `username/dbname`). Configuration of an existing
+// database is not updated.
+func (c *Client) EnsureDatabase(databaseConfig *DatabaseConfig, opts ...CallOption) (*Database, error) {
+	database, err := c.findDatabase(databaseConfig.Name, opts...)
+	if err != nil || database != nil {
+		return database, err
+	}
+
+	createConfig := *databaseConfig
+	if _, dbName, ok := strings.Cut(createConfig.Name, "/"); ok {
+		createConfig.Name = dbName
+	}
+	database, createErr := c.CreateDatabase(&createConfig, opts...)
+	if createErr == nil {
+		return database, nil
+	}
+	// The database may have been created concurrently, so check again before
+	// reporting the error.
+	if database, err = c.findDatabase(databaseConfig.Name, opts...); err == nil && database != nil {
+		return database, nil
+	}
+	return nil, createErr
+}
+
+// findDatabase looks up a database by bare or user-qualified name, returning
+// nil if no such database exists.
+func (c *Client) findDatabase(name string, opts ...CallOption) (*Database, error) {
+	databases, err := c.ListDatabases(opts...)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Database
+	for _, database := range databases {
+		if database.Name == name {
+			return database, nil
+		}
+		if _, dbName, ok := strings.Cut(database.Name, "/"); ok && dbName == name {
+			matches = append(matches, database)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("database name %s is ambiguous, use a full user-qualified name", name)
+	}
+}
+
+// GetDatabase gets metadata about a single database.
+func (c *Client) GetDatabase(username, dbName string, opts ...CallOption) (*Database, error) {
+	if err := ValidateFullDatabaseName(username + "/" + dbName); err != nil {
+		return nil, err
+	}
+	path, err := url.JoinPath("db/", username, dbName)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	if database, ok := c.cache.getDatabase(username + "/" + dbName); ok {
+		return database, nil
+	}
+	data, err := c.call(c.apiClientFor(username+"/"+dbName), "GET", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get database: %w", err)
+		return nil, err
+	}
+	var database Database
+	if err = json.Unmarshal(data, &database); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+		return &database, err
+	}
+	c.cache.putDatabase(username+"/"+dbName, &database)
+	return &database, nil
+}
+
+// DeleteDatabase deletes a single database. In protected mode, it requires
+// WithConfirm with the full database name, e.g. `username/dbname`.
+func (c *Client) DeleteDatabase(username, dbName string, opts ...CallOption) error {
+	if err := ValidateFullDatabaseName(username + "/" + dbName); err != nil {
+		return err
+	}
+	if err := c.confirm(opts, username+"/"+dbName); err != nil {
+		return err
+	}
+	path, err := url.JoinPath("db/", username, dbName)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return err
+	}
+
+	_, err = c.call(c.apiClientFor(username+"/"+dbName), "DELETE", path, nil, opts...)
+	c.cache.invalidate(username + "/" + dbName)
+	if err != nil {
+		err = fmt.Errorf("failed to delete database: %w", err)
+		return err
+	}
+	return err
+}
+
+// UpdateDatabase updates the configuration of a database. Only fields set in
+// databaseUpdate are changed.
+func (c *Client) UpdateDatabase(username, dbName string, databaseUpdate *DatabaseUpdate, opts ...CallOption) (*Database, error) {
+	if err := ValidateFullDatabaseName(username + "/" + dbName); err != nil {
+		return nil, err
+	}
+	if databaseUpdate.Name != nil {
+		if err := ValidateName("database", *databaseUpdate.Name); err != nil {
+			return nil, err
+		}
+	}
+	path, err := url.JoinPath("db/", username, dbName)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	body, err := json.Marshal(databaseUpdate)
+	if err != nil {
+		err = fmt.Errorf("failed to serialize database update params: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClientFor(username+"/"+dbName), "PATCH", path, body, opts...)
+	// The update may rename the database, so invalidate the new name as well.
+	if databaseUpdate.Name != nil {
+		c.cache.invalidate(username+"/"+dbName, username+"/"+*databaseUpdate.Name)
+	} else {
+		c.cache.invalidate(username + "/" + dbName)
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to update database: %w", err)
+		return nil, err
+	}
+	var database Database
+	if err = json.Unmarshal(data, &database); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &database, err
+}
+
+// CreateKey creates a new API key/database password with the same permissions as the requester.
+func (c *Client) CreateKey(opts ...CallOption) (*Credentials, error) {
+	path := "api-key/"
+
+	data, err := c.call(c.apiClient, "POST", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to create a new key: %w", err)
+		return nil, err
+	}
+	var credentials Credentials
+	if err = json.Unmarshal(data, &credentials); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &credentials, err
+}
+
+// ListServiceAccounts lists metadata pertaining to service accounts the requester has created.
+func (c *Client) ListServiceAccounts(opts ...CallOption) ([]*ServiceAccount, error) {
+	data, err := c.call(c.apiClient, "GET", "service-account/", nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get a list of service accounts: %w", err)
+		return nil, err
+	}
+	var serviceAccountList ServiceAccountList
+	if err = json.Unmarshal(data, &serviceAccountList); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return serviceAccountList.ServiceAccounts, err
+}
+
+// GetServiceAccount gets metadata about a single service account.
+func (c *Client) GetServiceAccount(serviceAccountID string, opts ...CallOption) (*ServiceAccount, error) {
+	path, err := url.JoinPath("service-account", serviceAccountID)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClient, "GET", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get service account: %w", err)
+		return nil, err
+	}
+	var serviceAccount ServiceAccount
+	if err = json.Unmarshal(data, &serviceAccount); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &serviceAccount, err
+}
+
+// CreateServiceAccountKey creates a new key for a service account.
+func (c *Client) CreateServiceAccountKey(serviceAccountID string, opts ...CallOption) (*Credentials, error) {
+	path, err := url.JoinPath("service-account", serviceAccountID, "api-key/")
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClient, "POST", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to create new service account key: %w", err)
+		return nil, err
+	}
+	var credentials Credentials
+	if err = json.Unmarshal(data, &credentials); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &credentials, err
+}
+
+// RevokeServiceAccountKeys revokes all keys for a service account. In
+// protected mode, it requires WithConfirm with the service account ID.
+func (c *Client) RevokeServiceAccountKeys(serviceAccountID string, opts ...CallOption) error {
+	if err := c.confirm(opts, serviceAccountID); err != nil {
+		return err
+	}
+	path, err := url.JoinPath("service-account", serviceAccountID, "api-key/")
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return err
+	}
+
+	_, err = c.call(c.apiClient, "DELETE", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to revoke service account keys: %w", err)
+		return err
+	}
+	return err
+}
+
+// CreateImportJob creates a new import job. Client is responsible for closing
+// any closable readers passed in as the File field of an *ImportJobConfig.
+//
+// If the upload fails and the RetryPolicy allows a retry, the file is rewound
+// and sent again, provided that File implements io.Seeker or the file is given
+// by FilePath. Other readers cannot be rewound, so their uploads are not
+// retried.
+func (c *Client) CreateImportJob(fullDBName string, tableName string, config *ImportJobConfig, opts ...CallOption) (*ImportJob, error) {
+	sources := 0
+	for _, set := range []bool{config.FileURL != "", config.File != nil, config.FilePath != "", config.GoogleSheet != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, fmt.Errorf("Must provide exactly one of File, FilePath, FileURL, or GoogleSheet")
+	}
+	if err := ValidateFullDatabaseName(fullDBName); err != nil {
+		return nil, err
+	}
+	if err := ValidateName("table", tableName); err != nil {
+		return nil, err
+	}
+	if config.SchemaName != "" {
+		if err := ValidateName("schema", config.SchemaName); err != nil {
+			return nil, err
+		}
+	}
+
+	path, err := url.JoinPath("db", fullDBName, "import/")
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	// Add non-file request parts
+	fields := fieldParts{
+		"table_name": strings.NewReader(tableName),
+	}
+	if v := config.SchemaName; v != "" {
+		fields["schema_name"] = strings.NewReader(v)
+	}
+	if v := config.InferHeader; v != "" {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+		fields["infer_header"] = strings.NewReader(string(v))
+	}
+	if v := config.FileURL; v != "" {
+		fields["file_url"] = strings.NewReader(v)
+	}
+	if sheet := config.GoogleSheet; sheet != nil {
+		if config.Format != "" && config.Format != ImportFormatCSV {
+			return nil, fmt.Errorf("Google Sheets are imported as %q, got Format %q", ImportFormatCSV, config.Format)
+		}
+		exportURL, err := sheet.ExportURL()
+		if err != nil {
+			return nil, err
+		}
+		fields["file_url"] = strings.NewReader(exportURL)
+	}
+
+	if v := config.Format; v != "" {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+		fields["format"] = strings.NewReader(string(v))
+	}
+
+	extraFields, err := extraFieldParts(config.Extra)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range extraFields {
+		fields[key] = value
+	}
+
+	// Add file request parts
+	var files fileParts
+	f := config.File
+	if config.FilePath != "" {
+		file, err := os.Open(config.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer file.Close()
+		f = file
+	}
+	if f != nil {
+		filename := tableName
+		if config.Format != "" {
+			filename += "." + string(config.Format)
+		}
+		// NDJSON is validated as it streams so that malformed input fails the
+		// upload rather than a job later on.
+		if config.Format == ImportFormatNDJSON {
+			f = newNDJSONValidator(f)
+		}
+		files = fileParts{"file": &formFile{filename, f}}
+	}
+
+	data, err := c.callMultipart(c.apiClientFor(fullDBName), "POST", path, fields, files, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to create import job: %w", err)
+		return nil, err
+	}
+
+	var importJob ImportJob
+	if err = json.Unmarshal(data, &importJob); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+		return &importJob, err
+	}
+	c.config.Events.Publish(&Event{Type: EventJobSubmitted, DBName: fullDBName, JobID: importJob.ID})
+	return &importJob, nil
+}
+
+// GetImportJob gets the status for an import job.
+func (c *Client) GetImportJob(importID string, opts ...CallOption) (*ImportJob, error) {
+	path, err := url.JoinPath("import", importID)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClient, "GET", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get import job status: %w", err)
+		return nil, err
+	}
+
+	var importJob ImportJob
+	if err = json.Unmarshal(data, &importJob); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &importJob, err
+}
+
+// CreateExportJob creates a new export job.
+func (c *Client) CreateExportJob(fullDBName string, config *ExportJobConfig, opts ...CallOption) (*ExportJob, error) {
+	if (config.QueryString == "") == (config.TableName == "") {
+		return nil, fmt.Errorf("Must provide QueryString XOR TableName")
+	}
+	if err := ValidateFullDatabaseName(fullDBName); err != nil {
+		return nil, err
+	}
+	if config.TableName != "" {
+		if err := ValidateName("table", config.TableName); err != nil {
+			return nil, err
+		}
+	}
+	if config.SchemaName != "" {
+		if err := ValidateName("schema", config.SchemaName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Explicit schema name is required by the API, but we can default to "public"
+	// here if table_name is given.
+	if config.TableName != "" && config.SchemaName == "" {
+		config.SchemaName = "public"
+	}
+
+	path, err := url.JoinPath("db", fullDBName, "export/")
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	body, err := json.Marshal(config)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal export job config: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClientFor(fullDBName), "POST", path, body, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to create export job: %w", err)
+		return nil, err
+	}
+
+	var exportJob ExportJob
+	if err = json.Unmarshal(data, &exportJob); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+		return &exportJob, err
+	}
+	c.config.Events.Publish(&Event{Type: EventJobSubmitted, DBName: fullDBName, JobID: exportJob.ID})
+	return &exportJob, nil
+}
+
+// GetExportJob gets the status for an export job.
+func (c *Client) GetExportJob(exportID string, opts ...CallOption) (*ExportJob, error) {
+	path, err := url.JoinPath("export", exportID)
+	if err != nil {
+		err = fmt.Errorf("failed to construct request path: %v", err)
+		return nil, err
+	}
+
+	data, err := c.call(c.apiClient, "GET", path, nil, opts...)
+	if err != nil {
+		err = fmt.Errorf("failed to get export job status: %w", err)
+		return nil, err
+	}
+
+	var exportJob ExportJob
+	if err = json.Unmarshal(data, &exportJob); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+	}
+	return &exportJob, err
+}
+
+// Query executes a query using the HTTP API and returns the reponse as JSON-serialized bytes.
+func (c *Client) Query(fullDBName string, queryString string, opts ...CallOption) (*QueryResult, error) {
+	// Decode the response as it is received, see decodeQueryResultStream.
+	var queryResult QueryResult
+	decoded := false
+	decode := func(body io.Reader) error {
+		queryResult, decoded = QueryResult{}, true
+		return decodeQueryResultStream(body, &queryResult)
+	}
+	data, options, err := c.query(fullDBName, queryString, append(opts[:len(opts):len(opts)], withDecoder(decode)))
+	if err != nil {
+		return nil, err
+	}
+
+	// APIClients other than DefaultAPIClient return the body instead.
+	if !decoded {
+		if err = decodeQueryResult(data, &queryResult); err != nil {
+			err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+			return &queryResult, err
+		}
+	}
+	queryResult.QueryString = queryString
+	if err = options.checkRows(len(queryResult.Data)); err != nil {
+		return nil, err
+	}
+	if err = queryResult.ConvertTimes(c.config.TimeMode, nil); err != nil {
+		return nil, err
+	}
+	return &queryResult, nil
+}
+
+// QueryColumnar is like Query, but returns the result stored column-wise,
+// decoding the response directly into typed column slices without
+// materializing rows.
+func (c *Client) QueryColumnar(fullDBName string, queryString string, opts ...CallOption) (*ColumnarResult, error) {
+	data, options, err := c.query(fullDBName, queryString, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ColumnarResult
+	if err = decodeColumnarResult(data, &result); err != nil {
+		err = fmt.Errorf("JSON unmarshaling failed: %s", err)
+		return nil, err
+	}
+	result.QueryString = queryString
+	if err = options.checkRows(result.NumRows); err != nil {
+		return nil, err
+	}
+	if err = result.ConvertTimes(c.config.TimeMode, nil); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// query executes a query using the HTTP API and returns the response body.
+func (c *Client) query(fullDBName string, queryString string, opts []CallOption) ([]byte, *callOptions, error) {
+	path := "query"
+	if err := ValidateFullDatabaseName(fullDBName); err != nil {
+		return nil, nil, err
+	}
+
+	options := c.newCallOptions(opts)
+	query := &Query{DatabaseName: fullDBName, QueryString: options.limitQuery(queryString)}
+	body, err := json.Marshal(query)
+	if err != nil {
+		err = fmt.Errorf("failed to serialize query: %v", err)
+		return nil, nil, err
+	}
+
+	apiClient := c.apiClientFor(fullDBName)
+	data, err := c.retry(apiClient, "POST", path, body, opts...)
+	// Retry while the database wakes from hibernation, if enabled.
+	deadline := time.Now().Add(time.Duration(c.wakeTimeout.Load()))
+	for retries := 1; err != nil && isWaking(err) && time.Now().Add(wakeRetryInterval).Before(deadline); retries++ {
+		c.logfCtx(options.context(), "bitdotio: database %s is waking, retrying query", fullDBName)
+		if !sleepContext(options.context(), wakeRetryInterval) {
+			break
+		}
+		data, err = c.attempt(apiClient, retries, "POST", path, body, opts...)
+	}
+	c.audit("POST", path, func() string { return summarizeBody(body) }, err)
+	if err != nil {
+		err = fmt.Errorf("query request failed: %w", err)
+		return nil, nil, err
+	}
+	return data, options, nil
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/arrow"
+)
+
+// ArrowSchema returns the Arrow schema of r's columns, which map to Arrow
+// types by kind:
+//
+//   - ColumnInt64, ColumnFloat64, and ColumnBool: Int64, Float64, and Bool
+//   - ColumnString: Utf8
+//   - ColumnTime: Date32 for dates, Timestamp in UTC for timestamptz, and
+//     Timestamp without a time zone, of the wall clock, for timestamp
+//   - ColumnAny: Binary for bytea, and Utf8 otherwise, of strings as they are
+//     and of other values formatted as JSON
+//
+// All fields are nullable.
+func (r *ColumnarResult) ArrowSchema() *arrow.Schema {
+	schema := &arrow.Schema{Fields: make([]arrow.Field, len(r.Columns))}
+	for i, column := range r.Columns {
+		field := arrow.Field{Name: column.Name, Nullable: true}
+		switch column.Kind {
+		case ColumnInt64:
+			field.Type = arrow.Int64
+		case ColumnFloat64:
+			field.Type = arrow.Float64
+		case ColumnBool:
+			field.Type = arrow.Bool
+		case ColumnString:
+			field.Type = arrow.Utf8
+		case ColumnTime:
+			switch normalizeTypeName(column.Type) {
+			case "date":
+				field.Type = arrow.Date32
+			case "timestamptz", "timestamp with time zone":
+				field.Type, field.TimeZone = arrow.Timestamp, "UTC"
+			default:
+				field.Type = arrow.Timestamp
+			}
+		default:
+			field.Type = arrow.Utf8
+			if normalizeTypeName(column.Type) == "bytea" {
+				field.Type = arrow.Binary
+			}
+		}
+		schema.Fields[i] = field
+	}
+	return schema
+}
+
+// ArrowRecords returns the rows of r as Arrow record batches of at most
+// batchSize rows each, or of all rows if batchSize is not positive, with the
+// schema of ArrowSchema. The records can be handed off to Arrow-based compute
+// without conversion, or written as an IPC stream, see WriteArrow.
+func (r *ColumnarResult) ArrowRecords(batchSize int) (*arrow.Schema, []*arrow.Record, error) {
+	schema := r.ArrowSchema()
+	if batchSize <= 0 {
+		batchSize = r.NumRows
+	}
+	var records []*arrow.Record
+	for start := 0; start < r.NumRows; start += batchSize {
+		end := start + batchSize
+		if end > r.NumRows {
+			end = r.NumRows
+		}
+		columns := make([]*arrow.Array, len(r.Columns))
+		for i, column := range r.Columns {
+			var err error
+			if columns[i], err = column.arrowArray(schema.Fields[i], start, end); err != nil {
+				return nil, nil, fmt.Errorf("unable to convert column %s: %w", column.Name, err)
+			}
+		}
+		record, err := arrow.NewRecord(schema, columns)
+		if err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+	return schema, records, nil
+}
+
+// WriteArrow writes the rows of r to w as an Arrow IPC stream of record
+// batches of at most batchSize rows each, see ArrowRecords, e.g. for reading
+// in Python with pyarrow.ipc.open_stream.
+func (r *ColumnarResult) WriteArrow(w io.Writer, batchSize int) error {
+	schema, records, err := r.ArrowRecords(batchSize)
+	if err != nil {
+		return err
+	}
+	writer, err := arrow.NewWriter(w, schema)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err = writer.Write(record); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// arrowArray returns rows start to end of c as an array of field's type.
+func (c *Column) arrowArray(field arrow.Field, start, end int) (*arrow.Array, error) {
+	var nulls []bool
+	if c.Nulls != nil {
+		nulls = c.Nulls[start:end]
+	}
+	switch c.Kind {
+	case ColumnInt64:
+		return arrow.NewInt64Array(c.Int64[start:end], nulls), nil
+	case ColumnFloat64:
+		return arrow.NewFloat64Array(c.Float64[start:end], nulls), nil
+	case ColumnBool:
+		return arrow.NewBoolArray(c.Bool[start:end], nulls), nil
+	case ColumnString:
+		return arrow.NewStringArray(c.String[start:end], nulls)
+	case ColumnTime:
+		times := c.Time[start:end]
+		switch {
+		case field.Type == arrow.Date32:
+			return arrow.NewDate32Array(times, nulls), nil
+		case field.TimeZone == "":
+			// Timestamps without a time zone are stored as their wall clock
+			// in UTC.
+			wall := make([]time.Time, len(times))
+			for i, t := range times {
+				wall[i] = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			}
+			times = wall
+		}
+		return arrow.NewTimestampArray(times, nulls), nil
+	}
+	values := c.Values[start:end]
+	if field.Type == arrow.Binary {
+		b := make([][]byte, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case []byte:
+				b[i] = v
+			default:
+				return nil, fmt.Errorf("row %d: %T is not bytea", start+i, v)
+			}
+		}
+		return arrow.NewBinaryArray(b, nulls)
+	}
+	s := make([]string, len(values))
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		var err error
+		if s[i], err = arrowText(v); err != nil {
+			return nil, fmt.Errorf("row %d: %w", start+i, err)
+		}
+	}
+	return arrow.NewStringArray(s, nulls)
+}
+
+// arrowText formats a value of a ColumnAny column for a Utf8 array: strings
+// as they are, the values of driver.Valuer types such as pgtype.Numeric, and
+// other values as JSON.
+func arrowText(v interface{}) (string, error) {
+	if valuer, ok := v.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return "", err
+		}
+		if value != nil {
+			v = value
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
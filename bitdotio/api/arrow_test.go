@@ -0,0 +1,89 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/arrow"
+)
+
+func TestColumnarResultArrow(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := time.Date(2023, 1, 2, 3, 4, 5, 678901000, newYork)
+	result := NewColumnarResult("SELECT ...", []string{"id", "score", "active", "name", "day", "at", "local", "doc", "raw"}, map[string]string{
+		"id":     "int8",
+		"score":  "float8",
+		"active": "bool",
+		"name":   "text",
+		"day":    "date",
+		"at":     "timestamptz",
+		"local":  "timestamp",
+		"doc":    "jsonb",
+		"raw":    "bytea",
+	})
+	for _, row := range [][]interface{}{
+		{float64(1), 1.5, true, "alpha", "2023-01-02", at, at, map[string]interface{}{"a": []interface{}{1.0}}, `\x0001`},
+		{float64(2), nil, nil, nil, nil, nil, nil, nil, nil},
+		{float64(3), -2.25, false, "beta", "1969-12-31", at, at, "text", `\x`},
+	} {
+		if err = result.AppendRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	schema, records, err := result.ArrowRecords(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSchema := &arrow.Schema{Fields: []arrow.Field{
+		{Name: "id", Type: arrow.Int64, Nullable: true},
+		{Name: "score", Type: arrow.Float64, Nullable: true},
+		{Name: "active", Type: arrow.Bool, Nullable: true},
+		{Name: "name", Type: arrow.Utf8, Nullable: true},
+		{Name: "day", Type: arrow.Date32, Nullable: true},
+		{Name: "at", Type: arrow.Timestamp, TimeZone: "UTC", Nullable: true},
+		{Name: "local", Type: arrow.Timestamp, Nullable: true},
+		{Name: "doc", Type: arrow.Utf8, Nullable: true},
+		{Name: "raw", Type: arrow.Binary, Nullable: true},
+	}}
+	if !reflect.DeepEqual(schema, wantSchema) {
+		t.Errorf("schema = %+v, want %+v", schema, wantSchema)
+	}
+	if len(records) != 2 || records[0].NumRows != 2 || records[1].NumRows != 1 {
+		t.Fatalf("got %d records, want records of 2 and 1 rows", len(records))
+	}
+
+	// The records read back from an IPC stream have the values of the result.
+	var b bytes.Buffer
+	if err = result.WriteArrow(&b, 2); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := arrow.NewReader(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got [][]interface{}
+	for reader.Next() {
+		record := reader.Record()
+		for i := 0; i < record.NumRows; i++ {
+			got = append(got, record.Row(i))
+		}
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	local := time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)
+	want := [][]interface{}{
+		{int64(1), 1.5, true, "alpha", time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), at.UTC(), local, `{"a":[1]}`, []byte{0, 1}},
+		{int64(2), nil, nil, nil, nil, nil, nil, nil, nil},
+		{int64(3), -2.25, false, "beta", time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), at.UTC(), local, "text", []byte{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// auditSummaryLimit is the maximum length of AuditEvent.Request.
+const auditSummaryLimit = 1024
+
+// AuditEvent describes a completed mutating API call.
+type AuditEvent struct {
+	Method string
+	// Resource is the API path of the call, e.g. `db/username/dbname`.
+	Resource string
+	// Request summarizes the request: its JSON body, truncated to 1KiB, or the
+	// field and file names of a multipart upload.
+	Request string
+	// Status is the HTTP status code of an error response, or 0 if the call
+	// succeeded or no response was received.
+	Status int
+	Err    error
+}
+
+// AuditHook receives an event for every mutating API call made by a Client.
+// Hooks are called synchronously after each call completes, including any
+// retries, so they should return quickly.
+type AuditHook func(event *AuditEvent)
+
+// SetAuditHook sets a hook that is called after every mutating API call, i.e.
+// every call other than GET, so that an audit trail of changes made through
+// the SDK can be shipped to a logging system. Queries are included, since they
+// may modify data. A nil hook disables auditing.
+func (c *Client) SetAuditHook(hook AuditHook) {
+	if hook == nil {
+		c.auditHook.Store(nil)
+		return
+	}
+	c.auditHook.Store(&hook)
+}
+
+// audit reports a completed call to the audit hook, if any and the call is
+// mutating. summary is called only if the event is reported.
+func (c *Client) audit(method, path string, summary func() string, err error) {
+	hook := c.auditHook.Load()
+	if hook == nil || method == http.MethodGet || method == http.MethodHead {
+		return
+	}
+	event := &AuditEvent{Method: method, Resource: path, Request: summary(), Err: err}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		event.Status = apiErr.Status
+	}
+	(*hook)(event)
+}
+
+// summarizeBody summarizes a JSON request or response body, truncated to 1KiB.
+func summarizeBody(body []byte) string {
+	if len(body) > auditSummaryLimit {
+		return string(body[:auditSummaryLimit]) + "..."
+	}
+	return string(body)
+}
+
+// summarizeMultipart summarizes a multipart request for an AuditEvent.
+func summarizeMultipart(fields map[string]io.Reader, files fileParts) string {
+	var parts []string
+	for key := range fields {
+		parts = append(parts, key)
+	}
+	for key, formFile := range files {
+		parts = append(parts, key+"="+formFile.filename)
+	}
+	sort.Strings(parts)
+	return "multipart: " + strings.Join(parts, ", ")
+}
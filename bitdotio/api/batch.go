@@ -0,0 +1,168 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// QueryBatch executes statements in order using the HTTP API in a single
+// request, amortizing the HTTP overhead of migration-style scripts, and
+// returns the result of the last statement. Use SplitStatements to split a
+// script into statements.
+//
+// Per-statement results and errors are not possible over the query endpoint,
+// which returns only the result of the last statement of a request, or a
+// single error that does not say which statement failed. Whether statements
+// before a failed one remain applied is up to the endpoint; to know which
+// statement failed, run the statements with Query one at a time.
+func (c *Client) QueryBatch(fullDBName string, statements []string, opts ...CallOption) (*QueryResult, error) {
+	var script strings.Builder
+	for _, statement := range statements {
+		statement = strings.TrimRight(strings.TrimSpace(statement), "; \t\r\n")
+		if statement == "" {
+			continue
+		}
+		// Put each semicolon on its own line, so that a trailing -- comment
+		// does not comment it out.
+		script.WriteString(statement)
+		script.WriteString("\n;\n")
+	}
+	if script.Len() == 0 {
+		return nil, errors.New("no statements to execute")
+	}
+	result, err := c.Query(fullDBName, script.String(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("batch of %d statements failed: %w", len(statements), err)
+	}
+	return result, nil
+}
+
+// SplitStatements splits a SQL script into statements at semicolons, ignoring
+// semicolons in quoted strings, quoted identifiers, dollar-quoted strings, and
+// comments. Statements are trimmed of surrounding whitespace, and empty
+// statements are omitted.
+func SplitStatements(script string) []string {
+	var statements []string
+	add := func(statement string) {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	start := 0
+	for i := 0; i < len(script); i++ {
+		switch c := script[i]; {
+		case c == ';':
+			add(script[start:i])
+			start = i + 1
+		case c == '\'' && isEscapeString(script, i):
+			i = skipEscapeString(script, i)
+		case c == '\'' || c == '"':
+			i = skipQuoted(script, i, c)
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = skipBlockComment(script, i)
+		case c == '$':
+			if tag, ok := dollarQuoteTag(script[i:]); ok {
+				if end := strings.Index(script[i+len(tag):], tag); end >= 0 {
+					i += len(tag) + end + len(tag) - 1
+				} else {
+					i = len(script)
+				}
+			}
+		}
+	}
+	if start < len(script) {
+		add(script[start:])
+	}
+	return statements
+}
+
+// skipQuoted returns the index of the quote closing the string or identifier
+// opened by quote at script[i], where doubled quotes are escapes.
+func skipQuoted(script string, i int, quote byte) int {
+	for i++; i < len(script); i++ {
+		if script[i] == quote {
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(script)
+}
+
+// isEscapeString reports whether the quote at script[i] opens an escape
+// string constant, e.g. `E'it\'s'`, whose E prefix is not the end of an
+// identifier.
+func isEscapeString(script string, i int) bool {
+	if i == 0 || script[i-1] != 'E' && script[i-1] != 'e' {
+		return false
+	}
+	if i == 1 {
+		return true
+	}
+	c := script[i-2]
+	return !(c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80)
+}
+
+// skipEscapeString returns the index of the quote closing the escape string
+// opened at script[i], where backslashes escape the next character and
+// doubled quotes are escapes.
+func skipEscapeString(script string, i int) int {
+	for i++; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			i++
+		case '\'':
+			if i+1 < len(script) && script[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return len(script)
+}
+
+// skipBlockComment returns the index of the last character of the block
+// comment opened at script[i]. Postgres block comments nest.
+func skipBlockComment(script string, i int) int {
+	depth := 0
+	for ; i < len(script); i++ {
+		switch {
+		case strings.HasPrefix(script[i:], "/*"):
+			depth++
+			i++
+		case strings.HasPrefix(script[i:], "*/"):
+			depth--
+			i++
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(script)
+}
+
+// dollarQuoteTag returns the dollar quote tag, e.g. `$$` or `$body$`, that s
+// starts with, if any.
+func dollarQuoteTag(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '$':
+			return s[:i+1], true
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80:
+		case c >= '0' && c <= '9' && i > 1:
+		default:
+			return "", false
+		}
+	}
+	return "", false
+}
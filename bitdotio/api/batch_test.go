@@ -0,0 +1,25 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		want   []string
+	}{
+		{"SELECT 1; SELECT 2;", []string{"SELECT 1", "SELECT 2"}},
+		{`SELECT 'a;b', "c;d"; SELECT 'it''s;'`, []string{`SELECT 'a;b', "c;d"`, `SELECT 'it''s;'`}},
+		{`SELECT E'it\'s;'; SELECT e'\\'; SELECT 2`, []string{`SELECT E'it\'s;'`, `SELECT e'\\'`, "SELECT 2"}},
+		// A backslash is not an escape in a standard string, even after an
+		// identifier ending in E.
+		{`SELECT name'\'; SELECT 2`, []string{`SELECT name'\'`, "SELECT 2"}},
+		{"SELECT $$a;b$$; -- c;\nSELECT /* d; /* e; */ */ 2", []string{"SELECT $$a;b$$", "-- c;\nSELECT /* d; /* e; */ */ 2"}},
+	} {
+		if got := SplitStatements(test.script); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("SplitStatements(%q) = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// metadataCache caches database metadata for ClientConfig.MetadataCacheTTL. A
+// nil *metadataCache caches nothing.
+type metadataCache struct {
+	ttl time.Duration
+
+	lock      sync.Mutex
+	databases map[string]cachedDatabase
+	list      []*Database
+	listUntil time.Time
+}
+
+type cachedDatabase struct {
+	database *Database
+	until    time.Time
+}
+
+// newMetadataCache constructs a cache with ttl, or returns nil if ttl is not
+// positive.
+func newMetadataCache(ttl time.Duration) *metadataCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &metadataCache{ttl: ttl, databases: make(map[string]cachedDatabase)}
+}
+
+// getDatabase returns a copy of the cached metadata of a database by full
+// name, if any.
+func (mc *metadataCache) getDatabase(fullDBName string) (*Database, bool) {
+	if mc == nil {
+		return nil, false
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	cached, ok := mc.databases[fullDBName]
+	if !ok || time.Now().After(cached.until) {
+		return nil, false
+	}
+	database := *cached.database
+	return &database, true
+}
+
+// putDatabase caches the metadata of a database by full name.
+func (mc *metadataCache) putDatabase(fullDBName string, database *Database) {
+	if mc == nil {
+		return
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	cached := *database
+	mc.databases[fullDBName] = cachedDatabase{database: &cached, until: time.Now().Add(mc.ttl)}
+}
+
+// getList returns copies of the cached database list, if any.
+func (mc *metadataCache) getList() ([]*Database, bool) {
+	if mc == nil {
+		return nil, false
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	if mc.list == nil || time.Now().After(mc.listUntil) {
+		return nil, false
+	}
+	return copyDatabases(mc.list), true
+}
+
+// putList caches the database list.
+func (mc *metadataCache) putList(databases []*Database) {
+	if mc == nil {
+		return
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	mc.list = copyDatabases(databases)
+	mc.listUntil = time.Now().Add(mc.ttl)
+}
+
+// invalidate removes the databases with the given full names, and the
+// database list, from the cache.
+func (mc *metadataCache) invalidate(fullDBNames ...string) {
+	if mc == nil {
+		return
+	}
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+	for _, fullDBName := range fullDBNames {
+		delete(mc.databases, fullDBName)
+	}
+	mc.list = nil
+}
+
+// copyDatabases returns shallow copies of databases, so that callers cannot
+// modify cached metadata.
+func copyDatabases(databases []*Database) []*Database {
+	copies := make([]*Database, len(databases))
+	for i, database := range databases {
+		copied := *database
+		copies[i] = &copied
+	}
+	return copies
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+const (
+	// defaultChunkSize is the default size in bytes of the chunks uploaded by
+	// ImportChunked.
+	defaultChunkSize = 100 << 20
+
+	// importIfExistsField is the form field that sets what an import does if
+	// the table exists. The API does not document it yet, and appends by
+	// default, so ImportChunked sends it through ImportJobConfig.Extra to
+	// request appending explicitly.
+	importIfExistsField = "if_exists"
+)
+
+// ChunkedImportOptions configures ImportChunked.
+type ChunkedImportOptions struct {
+	ImportOptions
+	// ChunkSize is the approximate size in bytes of each chunk. Chunks end at
+	// the first row boundary after ChunkSize bytes. Defaults to 100 MiB.
+	ChunkSize int64
+	// Chunks, if set, splits the file into this many chunks of about equal
+	// size instead, overriding ChunkSize.
+	Chunks int
+	// Concurrency is the maximum number of chunks imported at once.
+	Concurrency int
+}
+
+// ChunkImportResult contains the outcome of importing one chunk of a file.
+type ChunkImportResult struct {
+	// Offset and Size locate the chunk's rows in the file, excluding the
+	// header.
+	Offset int64
+	Size   int64
+	// Rows is the number of data rows in the chunk.
+	Rows int64
+	Job  *ImportJob
+	Err  error
+}
+
+// ChunkedImportResult aggregates the outcomes of the chunks of a file imported
+// by ImportChunked.
+type ChunkedImportResult struct {
+	// Chunks are the chunks in file order, including any that were not
+	// imported, whose Err is set.
+	Chunks []*ChunkImportResult
+	// Rows is the number of data rows imported by successful chunks.
+	Rows int64
+	// Failed is the number of chunks that failed or were not imported.
+	Failed int
+}
+
+// ImportChunked imports a large local CSV file, given by config.File or
+// config.FilePath, as several import jobs of one chunk of rows each, working
+// around the size and time limits of a single job. Each chunk is uploaded
+// with the file's header row, if any, and imported as with ImportAndWait.
+//
+// The first chunk is imported alone, so that it creates the table with the
+// schema inferred by bit.io, and then the others are imported concurrently,
+// appending to it, with an if_exists form field of "append" unless
+// config.Extra sets one. If the first chunk fails, no others are imported. Rows of
+// chunks are appended in no particular order.
+//
+// Chunks are split at row boundaries found by parsing the file, so quoted
+// fields may span lines. For InferHeaderAuto, or no InferHeader, the header is
+// inferred once, as by ValidateImport, and every chunk is imported with the
+// same explicit InferHeader, so that bit.io does not infer it differently per
+// chunk. File must implement io.ReaderAt and io.Seeker, as *os.File does.
+//
+// If any chunk fails, the result is returned along with an error wrapping the
+// first failure. Rows of successful chunks remain imported, and the chunks
+// that failed can be identified by their Err.
+func (c *Client) ImportChunked(ctx context.Context, fullDBName, tableName string, config *ImportJobConfig, options *ChunkedImportOptions) (*ChunkedImportResult, error) {
+	if options == nil {
+		options = &ChunkedImportOptions{}
+	}
+	if config.Format != "" && config.Format != ImportFormatCSV {
+		return nil, fmt.Errorf("only CSV files can be imported in chunks, got Format %q", config.Format)
+	}
+	if err := config.InferHeader.Validate(); err != nil {
+		return nil, err
+	}
+	if config.FileURL != "" || config.GoogleSheet != nil {
+		return nil, errors.New("only local files can be imported in chunks")
+	}
+	var file interface {
+		io.ReaderAt
+		io.Seeker
+	}
+	if config.FilePath != "" {
+		f, err := os.Open(config.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer f.Close()
+		file = f
+	} else if f, ok := config.File.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		file = f
+	} else {
+		return nil, errors.New("Must provide FilePath, or a File that implements io.ReaderAt and io.Seeker")
+	}
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to size import file: %v", err)
+	}
+
+	chunkSize := options.ChunkSize
+	if options.Chunks > 0 {
+		chunkSize = size / int64(options.Chunks)
+	}
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	header, chunks, inferHeader, err := splitCSV(io.NewSectionReader(file, 0, size), config.InferHeader, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ChunkedImportResult{Chunks: chunks}
+	importChunk := func(chunk *ChunkImportResult) {
+		body := io.NewSectionReader(file, chunk.Offset, chunk.Size)
+		chunkConfig := *config
+		if chunk != chunks[0] {
+			if _, ok := config.Extra[importIfExistsField]; !ok {
+				chunkConfig.Extra = make(map[string]interface{}, len(config.Extra)+1)
+				for name, value := range config.Extra {
+					chunkConfig.Extra[name] = value
+				}
+				chunkConfig.Extra[importIfExistsField] = "append"
+			}
+		}
+		chunkConfig.Format = ImportFormatCSV
+		chunkConfig.InferHeader = inferHeader
+		chunkConfig.FilePath = ""
+		chunkConfig.File = newSectionsReader(io.NewSectionReader(file, 0, header), body)
+		chunk.Job, chunk.Err = c.ImportAndWait(ctx, fullDBName, tableName, &chunkConfig, &options.ImportOptions)
+	}
+
+	importChunk(chunks[0])
+	if chunks[0].Err == nil {
+		concurrency := options.Concurrency
+		if concurrency <= 0 {
+			concurrency = defaultImportConcurrency
+		}
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for _, chunk := range chunks[1:] {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(chunk *ChunkImportResult) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if chunk.Err = ctx.Err(); chunk.Err == nil {
+					importChunk(chunk)
+				}
+			}(chunk)
+		}
+		wg.Wait()
+	} else {
+		for _, chunk := range chunks[1:] {
+			chunk.Err = errors.New("not imported because the first chunk failed")
+		}
+	}
+
+	var firstErr error
+	for i, chunk := range chunks {
+		if chunk.Err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("chunk %d: %w", i+1, chunk.Err)
+			}
+			result.Failed++
+			continue
+		}
+		result.Rows += chunk.Rows
+	}
+	if firstErr != nil {
+		return result, fmt.Errorf("failed to import %d of %d chunks: %w", result.Failed, len(chunks), firstErr)
+	}
+	return result, nil
+}
+
+// splitCSV parses a CSV file and splits its rows into chunks of at least
+// chunkSize bytes, except the last. It returns the size of the header row,
+// which is 0 without one, the chunks, and the header inference mode to import
+// each chunk with.
+func splitCSV(r io.Reader, inferHeader InferHeader, chunkSize int64) (int64, []*ChunkImportResult, InferHeader, error) {
+	reader := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	first, err := reader.Read()
+	if err == io.EOF {
+		return 0, nil, "", errors.New("import file is empty")
+	}
+	if err != nil {
+		return 0, nil, "", fmt.Errorf("invalid CSV: %w", err)
+	}
+	firstEnd := reader.InputOffset()
+
+	var sample [][]string
+	var chunks []*ChunkImportResult
+	chunk := &ChunkImportResult{Offset: firstEnd}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, nil, "", fmt.Errorf("invalid CSV: %w", err)
+		}
+		if len(sample) < headerSampleRows {
+			sample = append(sample, record)
+		}
+		chunk.Rows++
+		if offset := reader.InputOffset(); offset-chunk.Offset >= chunkSize {
+			chunk.Size = offset - chunk.Offset
+			chunks = append(chunks, chunk)
+			chunk = &ChunkImportResult{Offset: offset}
+		}
+	}
+	if end := reader.InputOffset(); end > chunk.Offset || len(chunks) == 0 {
+		chunk.Size = end - chunk.Offset
+		chunks = append(chunks, chunk)
+	}
+
+	if inferHeader != InferHeaderFirstRow && inferHeader != InferHeaderNone {
+		inferHeader = InferHeaderNone
+		if looksLikeHeader(first, sample) {
+			inferHeader = InferHeaderFirstRow
+		}
+	}
+	if inferHeader == InferHeaderFirstRow {
+		return firstEnd, chunks, inferHeader, nil
+	}
+	// Without a header, the first row is data of the first chunk.
+	chunks[0].Offset, chunks[0].Size, chunks[0].Rows = 0, chunks[0].Size+firstEnd, chunks[0].Rows+1
+	return 0, chunks, inferHeader, nil
+}
+
+// sectionsReader reads sections of a file one after another, such as a header
+// row followed by a chunk of rows, and can seek so that uploads can be
+// retried.
+type sectionsReader struct {
+	sections []*io.SectionReader
+	size     int64
+	offset   int64
+}
+
+func newSectionsReader(sections ...*io.SectionReader) *sectionsReader {
+	r := &sectionsReader{sections: sections}
+	for _, section := range sections {
+		r.size += section.Size()
+	}
+	return r
+}
+
+func (r *sectionsReader) Read(p []byte) (int, error) {
+	start := int64(0)
+	for _, section := range r.sections {
+		end := start + section.Size()
+		if r.offset < end {
+			if remaining := end - r.offset; int64(len(p)) > remaining {
+				p = p[:remaining]
+			}
+			n, err := section.ReadAt(p, r.offset-start)
+			r.offset += int64(n)
+			if err == io.EOF && n > 0 {
+				err = nil
+			}
+			return n, err
+		}
+		start = end
+	}
+	return 0, io.EOF
+}
+
+func (r *sectionsReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.offset = offset
+	return offset, nil
+}
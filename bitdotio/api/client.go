@@ -0,0 +1,268 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// APIClient provides an interface for potential mocking of an actual HTTP client.
+type APIClient interface {
+	Call(method, path string, body []byte) ([]byte, error)
+	CallMultipart(method, path string, fields map[string]io.Reader, files fileParts) ([]byte, error)
+}
+
+// RequestHandler sends an API request and returns its response, like
+// http.Client.Do.
+type RequestHandler func(req *http.Request) (*http.Response, error)
+
+// Middleware wraps the RequestHandler that sends API requests, e.g. to add
+// headers, replace authentication, log or sign requests, or inspect responses.
+// Middleware applies to every API request, whether JSON or multipart, but not
+// to export downloads from pre-signed URLs.
+type Middleware func(next RequestHandler) RequestHandler
+
+// requestIDHeader is the header used to correlate a request with bit.io logs.
+const requestIDHeader = "X-Request-ID"
+
+// DefaultAPIClient implements APIClient using http.Client.
+type DefaultAPIClient struct {
+	accessToken string
+	HTTPClient  *http.Client
+	// BaseURL is the URL of the bit.io developer API service. Defaults to
+	// https://api.bit.io.
+	BaseURL string
+	// UserAgent is the User-Agent header sent with each request. Defaults to
+	// the SDK's UserAgent.
+	UserAgent string
+	// RequestID, if set, returns the X-Request-ID sent with each request. By
+	// default a random ID is generated per request.
+	RequestID func() string
+	// Middleware wraps the sending of each request, with the first Middleware
+	// outermost. Requests reach Middleware fully built and authenticated, and
+	// responses are read after it returns.
+	Middleware []Middleware
+	// APIVersion is the API version in request paths. Defaults to v2beta.
+	// It is ignored if versions are negotiated, see ClientConfig.APIVersions.
+	APIVersion string
+	// versions negotiates the API version, if enabled.
+	versions *versionNegotiator
+}
+
+// NewDefaultAPIClient constructs a default client for making API HTTP requests.
+func NewDefaultAPIClient(accessToken string) *DefaultAPIClient {
+	return &DefaultAPIClient{
+		accessToken: accessToken,
+		HTTPClient:  &http.Client{},
+	}
+}
+
+// Call creates and executes an authenticated HTTP request against bit.io APIs.
+func (c *DefaultAPIClient) Call(method, path string, data []byte) ([]byte, error) {
+	return c.CallContext(context.Background(), method, path, data)
+}
+
+// CallContext is like Call, but the request is bounded by ctx.
+func (c *DefaultAPIClient) CallContext(ctx context.Context, method, path string, data []byte) ([]byte, error) {
+	return c.send(ctx, method, path, data, http.Header{"Accept": {"application/json"}})
+}
+
+// send builds a request for the client's API version, adds header, sends it
+// through the client's Middleware with HTTPClient, and reads the response,
+// converting an error response with HandleErrorResponse. All requests share
+// this path, so that request building, authentication, and response handling
+// are defined in one place. If versions are negotiated and the request is
+// rejected with 404 Not Found or 410 Gone because its version is no longer
+// supported, the request is resent once with a newly negotiated version.
+func (c *DefaultAPIClient) send(ctx context.Context, method, path string, body []byte, header http.Header) ([]byte, error) {
+	version := c.version(ctx)
+	resBody, status, err := c.sendVersion(ctx, version, method, path, body, header)
+	if (status == http.StatusNotFound || status == http.StatusGone) && c.versions.renegotiate(ctx, c, version) {
+		resBody, _, err = c.sendVersion(ctx, c.version(ctx), method, path, body, header)
+	}
+	return resBody, err
+}
+
+// sendVersion sends a request for API version, returning the response body
+// and status code, if a response was received.
+func (c *DefaultAPIClient) sendVersion(ctx context.Context, version, method, path string, body []byte, header http.Header) ([]byte, int, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := c.newRequest(version, method, path, bodyReader)
+	if err != nil {
+		err = fmt.Errorf("failed to create a new request: %v", err)
+		return nil, 0, err
+	}
+	req = req.WithContext(ctx)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	res, err := c.handler()(req)
+
+	var resBody []byte
+	var status int
+	if err == nil {
+		status = res.StatusCode
+		recordResponse(ctx, res)
+		if decode, ok := ctx.Value(decoderKey{}).(func(io.Reader) error); ok && status < 400 {
+			// Decode the body as it is received, see withDecoder.
+			if err = decode(res.Body); err != nil {
+				err = fmt.Errorf("failed to decode response: %w", err)
+			}
+		} else {
+			resBody, err = io.ReadAll(res.Body)
+		}
+		res.Body.Close()
+	}
+
+	if err != nil {
+		err = fmt.Errorf("request failed with error: %w", err)
+	} else if res.StatusCode >= 400 {
+		err = c.HandleErrorResponse(res, resBody)
+	}
+
+	return resBody, status, err
+}
+
+// handler returns the RequestHandler that sends requests with HTTPClient,
+// wrapped in the client's Middleware.
+func (c *DefaultAPIClient) handler() RequestHandler {
+	handler := RequestHandler(c.HTTPClient.Do)
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		handler = c.Middleware[i](handler)
+	}
+	return handler
+}
+
+// HandleErrorResponse converts an Error API response to an Error.
+func (s *DefaultAPIClient) HandleErrorResponse(res *http.Response, resBody []byte) error {
+	apiErr := &APIError{
+		Status:          res.StatusCode,
+		Body:            string(resBody),
+		ServerRequestID: res.Header.Get(requestIDHeader),
+	}
+	if res.Request != nil {
+		apiErr.RequestID = res.Request.Header.Get(requestIDHeader)
+		apiErr.Method = res.Request.Method
+		apiErr.Path = res.Request.URL.Path
+	}
+	return apiErr
+}
+
+// NewRequest constructs requests for bit.io APIs, using the client's
+// APIVersion.
+func (c *DefaultAPIClient) NewRequest(method, path string, body io.Reader) (*http.Request, error) {
+	return c.newRequest(c.APIVersion, method, path, body)
+}
+
+// newRequest constructs a request for API version, or the default version if
+// version is empty.
+func (c *DefaultAPIClient) newRequest(version, method, path string, body io.Reader) (*http.Request, error) {
+	baseURL := c.BaseURL
+	if baseURL == "" {
+		baseURL = apiURL
+	}
+	if version == "" {
+		version = apiVersion
+	}
+	path, err := url.JoinPath(baseURL, version, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct request path: %v", err)
+	}
+	// This method is shared with requests with no body, so need to handle nil.
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Authorization", "Bearer "+c.accessToken)
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = UserAgent
+	}
+	req.Header.Add("User-Agent", userAgent)
+	req.Header.Set(requestIDHeader, c.newRequestID())
+
+	return req, nil
+}
+
+// newRequestID returns the X-Request-ID for a new request.
+func (c *DefaultAPIClient) newRequestID() string {
+	if c.RequestID != nil {
+		if id := c.RequestID(); id != "" {
+			return id
+		}
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// formFile defines a file part for a multipart/form-data body
+type formFile struct {
+	filename string
+	file     io.Reader
+}
+
+// fieldParts contains field value parts for a multipart/form-data body
+type fieldParts map[string]io.Reader
+
+// fileParts contains file parts for a multipart/form-data body
+type fileParts map[string]*formFile
+
+// CallMultipart creates and executes an authenticated multipart/form-data HTTP
+// request against bit.io APIs.
+func (c *DefaultAPIClient) CallMultipart(method, path string, fields map[string]io.Reader, files fileParts) ([]byte, error) {
+	return c.CallMultipartContext(context.Background(), method, path, fields, files)
+}
+
+// CallMultipartContext is like CallMultipart, but the request is bounded by ctx.
+func (c *DefaultAPIClient) CallMultipartContext(ctx context.Context, method, path string, fields map[string]io.Reader, files fileParts) ([]byte, error) {
+	var reqBody bytes.Buffer
+	mpWriter := multipart.NewWriter(&reqBody)
+	var err error
+	// Write field value parts
+	for key, fieldReader := range fields {
+		var fieldWriter io.Writer
+		if fieldWriter, err = mpWriter.CreateFormField(key); err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(fieldWriter, fieldReader); err != nil {
+			return nil, err
+		}
+	}
+	// Write file parts
+	for key, formFile := range files {
+		var fileWriter io.Writer
+		if fileWriter, err = mpWriter.CreateFormFile(key, formFile.filename); err != nil {
+			return nil, err
+		}
+		// TODO: See if mpWriter materializes entire file in memory/ if so is
+		// there a streaming way to handle the file
+		if _, err := io.Copy(fileWriter, formFile.file); err != nil {
+			return nil, err
+		}
+	}
+	mpWriter.Close()
+
+	// Content-MD5 (RFC 1864) lets the server and intermediaries reject a body
+	// corrupted or truncated in transit.
+	sum := md5.Sum(reqBody.Bytes())
+	return c.send(ctx, method, path, reqBody.Bytes(), http.Header{
+		"Content-Type": {mpWriter.FormDataContentType()},
+		"Content-Md5":  {base64.StdEncoding.EncodeToString(sum[:])},
+	})
+}
@@ -0,0 +1,572 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ColumnKind is the Go type in which a Column stores its values.
+type ColumnKind int
+
+const (
+	// ColumnAny stores values in Values, as in QueryResult.TypedData.
+	ColumnAny ColumnKind = iota
+	// ColumnInt64 stores integer types in Int64.
+	ColumnInt64
+	// ColumnFloat64 stores real, double precision, and numeric in Float64.
+	ColumnFloat64
+	// ColumnBool stores booleans in Bool.
+	ColumnBool
+	// ColumnString stores text types and UUIDs in String.
+	ColumnString
+	// ColumnTime stores dates and timestamps in Time.
+	ColumnTime
+)
+
+func (k ColumnKind) String() string {
+	switch k {
+	case ColumnAny:
+		return "any"
+	case ColumnInt64:
+		return "int64"
+	case ColumnFloat64:
+		return "float64"
+	case ColumnBool:
+		return "bool"
+	case ColumnString:
+		return "string"
+	case ColumnTime:
+		return "time"
+	}
+	return fmt.Sprintf("ColumnKind(%d)", int(k))
+}
+
+// columnKind returns the kind for a Postgres type name.
+func columnKind(typeName string) ColumnKind {
+	switch typeName = normalizeTypeName(typeName); typeName {
+	case "int2", "int4", "int8", "smallint", "integer", "bigint", "smallserial", "serial", "bigserial", "oid":
+		return ColumnInt64
+	case "float4", "float8", "real", "double precision", "numeric", "decimal":
+		return ColumnFloat64
+	case "bool", "boolean":
+		return ColumnBool
+	case "text", "varchar", "character varying", "char", "character", "bpchar", "name", "citext", "uuid":
+		return ColumnString
+	}
+	if isTimeType(typeName) {
+		return ColumnTime
+	}
+	return ColumnAny
+}
+
+// ColumnarResult is a query result stored column-wise, with each column's
+// values in a slice of its Go type, rather than row-wise in [][]interface{}.
+// Wide numeric results take a fraction of the memory of a QueryResult, since
+// values are not boxed in interfaces, and columns convert directly to columnar
+// formats such as Apache Arrow.
+type ColumnarResult struct {
+	QueryString string
+	Columns     []*Column
+	// NumRows is the number of rows, which is the length of each column.
+	NumRows int
+}
+
+// Column is a column of a ColumnarResult. Its values are in the slice for its
+// Kind, with the zero value for NULLs, and the other slices are nil. Values
+// convert as with ConvertValue, so a column is demoted to ColumnAny if the
+// values of its Postgres type do not convert to the Go type of its kind, e.g.
+// a numeric column with values that pgx decodes as pgtype.Numeric.
+type Column struct {
+	Name string
+	// Type is the Postgres type name of the column.
+	Type string
+	Kind ColumnKind
+
+	Int64   []int64
+	Float64 []float64
+	Bool    []bool
+	String  []string
+	Time    []time.Time
+	Values  []interface{}
+
+	// Nulls reports which rows are NULL, or is nil if none are.
+	Nulls []bool
+}
+
+// NewColumnarResult constructs an empty ColumnarResult for a query with
+// columns, in order, and metadata mapping column names to Postgres type names,
+// for filling with AppendRow.
+func NewColumnarResult(queryString string, columns []string, metadata map[string]string) *ColumnarResult {
+	r := &ColumnarResult{QueryString: queryString, Columns: make([]*Column, len(columns))}
+	for i, name := range columns {
+		typeName := metadata[name]
+		r.Columns[i] = &Column{Name: name, Type: typeName, Kind: columnKind(typeName)}
+	}
+	return r
+}
+
+// Column returns the column with name, or nil if there is none.
+func (r *ColumnarResult) Column(name string) *Column {
+	for _, column := range r.Columns {
+		if column.Name == name {
+			return column
+		}
+	}
+	return nil
+}
+
+// Row returns the values of row i, as in QueryResult.TypedData.
+func (r *ColumnarResult) Row(i int) []interface{} {
+	row := make([]interface{}, len(r.Columns))
+	for j, column := range r.Columns {
+		row[j] = column.Value(i)
+	}
+	return row
+}
+
+// AppendRow appends a row of values, such as from the Data of a QueryResult or
+// from pgx.Rows.Values, converting each with ConvertValue.
+func (r *ColumnarResult) AppendRow(values []interface{}) error {
+	if len(values) != len(r.Columns) {
+		return fmt.Errorf("row %d has %d values, but result has %d columns", r.NumRows, len(values), len(r.Columns))
+	}
+	for j, column := range r.Columns {
+		if err := column.append(r.NumRows, values[j]); err != nil {
+			return fmt.Errorf("unable to convert row %d column %s: %w", r.NumRows, column.Name, err)
+		}
+	}
+	r.NumRows++
+	return nil
+}
+
+// ConvertTimes converts the time columns of r according to mode, as with
+// QueryResult.ConvertTimes. With TimeModeString, time columns become string
+// columns.
+func (r *ColumnarResult) ConvertTimes(mode TimeMode, session *time.Location) error {
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	if mode == TimeModeDefault {
+		return nil
+	}
+	for _, column := range r.Columns {
+		if column.Kind != ColumnTime {
+			continue
+		}
+		typeName := normalizeTypeName(column.Type)
+		var formatted []string
+		if mode == TimeModeString {
+			formatted = make([]string, len(column.Time))
+		}
+		for i, t := range column.Time {
+			if column.IsNull(i) {
+				continue
+			}
+			value, _ := convertTime(typeName, t, mode, session)
+			switch value := value.(type) {
+			case time.Time:
+				column.Time[i] = value
+			case string:
+				formatted[i] = value
+			}
+		}
+		if mode == TimeModeString {
+			column.Kind, column.String, column.Time = ColumnString, formatted, nil
+		}
+	}
+	return nil
+}
+
+// Columnar returns the result stored column-wise. It requires r.Columns to
+// name the columns of r.Data in order.
+func (r *QueryResult) Columnar() (*ColumnarResult, error) {
+	result := NewColumnarResult(r.QueryString, r.Columns, r.Metadata)
+	result.grow(len(r.Data))
+	for _, row := range r.Data {
+		if err := result.AppendRow(row); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// grow reserves space for n more rows in each column.
+func (r *ColumnarResult) grow(n int) {
+	for _, column := range r.Columns {
+		switch column.Kind {
+		case ColumnInt64:
+			column.Int64 = append(make([]int64, 0, len(column.Int64)+n), column.Int64...)
+		case ColumnFloat64:
+			column.Float64 = append(make([]float64, 0, len(column.Float64)+n), column.Float64...)
+		case ColumnBool:
+			column.Bool = append(make([]bool, 0, len(column.Bool)+n), column.Bool...)
+		case ColumnString:
+			column.String = append(make([]string, 0, len(column.String)+n), column.String...)
+		case ColumnTime:
+			column.Time = append(make([]time.Time, 0, len(column.Time)+n), column.Time...)
+		default:
+			column.Values = append(make([]interface{}, 0, len(column.Values)+n), column.Values...)
+		}
+	}
+}
+
+// Len returns the number of values in c.
+func (c *Column) Len() int {
+	switch c.Kind {
+	case ColumnInt64:
+		return len(c.Int64)
+	case ColumnFloat64:
+		return len(c.Float64)
+	case ColumnBool:
+		return len(c.Bool)
+	case ColumnString:
+		return len(c.String)
+	case ColumnTime:
+		return len(c.Time)
+	}
+	return len(c.Values)
+}
+
+// IsNull reports whether the value in row i is NULL.
+func (c *Column) IsNull(i int) bool {
+	return i < len(c.Nulls) && c.Nulls[i]
+}
+
+// Value returns the value in row i, or nil if it is NULL.
+func (c *Column) Value(i int) interface{} {
+	if c.IsNull(i) {
+		return nil
+	}
+	switch c.Kind {
+	case ColumnInt64:
+		return c.Int64[i]
+	case ColumnFloat64:
+		return c.Float64[i]
+	case ColumnBool:
+		return c.Bool[i]
+	case ColumnString:
+		return c.String[i]
+	case ColumnTime:
+		return c.Time[i]
+	}
+	return c.Values[i]
+}
+
+// append appends v, converted with ConvertValue, as row i.
+func (c *Column) append(i int, v interface{}) error {
+	v, err := ConvertValue(c.Type, v)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		c.appendNull(i)
+		return nil
+	}
+	appended := false
+	switch c.Kind {
+	case ColumnInt64:
+		var n int64
+		if n, appended = toInt64(v); appended {
+			c.Int64 = append(c.Int64, n)
+		}
+	case ColumnFloat64:
+		var f float64
+		if f, appended = toFloat64(v); appended {
+			c.Float64 = append(c.Float64, f)
+		}
+	case ColumnBool:
+		var b bool
+		if b, appended = v.(bool); appended {
+			c.Bool = append(c.Bool, b)
+		}
+	case ColumnString:
+		var s string
+		if s, appended = v.(string); appended {
+			c.String = append(c.String, s)
+		}
+	case ColumnTime:
+		var t time.Time
+		if t, appended = v.(time.Time); appended {
+			c.Time = append(c.Time, t)
+		}
+	}
+	if !appended {
+		c.demote()
+		c.Values = append(c.Values, v)
+	}
+	c.appendValid()
+	return nil
+}
+
+// appendNull appends a NULL as row i.
+func (c *Column) appendNull(i int) {
+	if c.Nulls == nil {
+		c.Nulls = make([]bool, i, i+1)
+	}
+	c.Nulls = append(c.Nulls, true)
+	switch c.Kind {
+	case ColumnInt64:
+		c.Int64 = append(c.Int64, 0)
+	case ColumnFloat64:
+		c.Float64 = append(c.Float64, 0)
+	case ColumnBool:
+		c.Bool = append(c.Bool, false)
+	case ColumnString:
+		c.String = append(c.String, "")
+	case ColumnTime:
+		c.Time = append(c.Time, time.Time{})
+	default:
+		c.Values = append(c.Values, nil)
+	}
+}
+
+// appendValid records that the last value appended is not NULL, if c has NULLs.
+func (c *Column) appendValid() {
+	if c.Nulls != nil {
+		c.Nulls = append(c.Nulls, false)
+	}
+}
+
+// demote moves the values of c to Values, for a value that does not have the
+// Go type of c's kind.
+func (c *Column) demote() {
+	if c.Kind == ColumnAny {
+		return
+	}
+	n := c.Len()
+	values := make([]interface{}, n, n+1)
+	for i := range values {
+		values[i] = c.Value(i)
+	}
+	c.Kind, c.Values = ColumnAny, values
+	c.Int64, c.Float64, c.Bool, c.String, c.Time = nil, nil, nil, nil, nil
+}
+
+// toInt64 converts integer values, such as the int32 values pgx returns for
+// int4, to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// toFloat64 converts float values, such as the float32 values pgx returns for
+// real, to float64.
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// appendJSON appends the JSON cell value at the current position of s as row
+// i. Strings, numbers, and booleans are parsed straight into the column's
+// slice, without boxing, and a string equal to the one above reuses it.
+func (c *Column) appendJSON(s *resultScanner, i int) error {
+	start := s.pos
+	if s.data[s.pos] == 'n' {
+		s.pos += len("null")
+		c.appendNull(i)
+		return nil
+	}
+	text, quoted, err := s.scalar()
+	if err != nil {
+		return err
+	}
+	appended := true
+	switch c.Kind {
+	case ColumnInt64:
+		switch {
+		case quoted:
+			var n int64
+			if n, err = strconv.ParseInt(string(text), 10, 64); err == nil {
+				c.Int64 = append(c.Int64, n)
+			}
+		case isJSONNumber(text):
+			var n int64
+			n, err = parseJSONInt(text)
+			if err == nil {
+				c.Int64 = append(c.Int64, n)
+			}
+		default:
+			appended = false
+		}
+	case ColumnFloat64:
+		if quoted || isJSONNumber(text) {
+			var f float64
+			if f, err = strconv.ParseFloat(string(text), 64); err == nil {
+				c.Float64 = append(c.Float64, f)
+			}
+		} else {
+			appended = false
+		}
+	case ColumnBool:
+		switch {
+		case quoted:
+			var b bool
+			if b, err = strconv.ParseBool(string(text)); err == nil {
+				c.Bool = append(c.Bool, b)
+			}
+		case string(text) == "true" || string(text) == "false":
+			c.Bool = append(c.Bool, text[0] == 't')
+		default:
+			appended = false
+		}
+	case ColumnString:
+		if !quoted {
+			appended = false
+		} else if n := len(c.String); n > 0 && c.String[n-1] == string(text) {
+			c.String = append(c.String, c.String[n-1])
+		} else {
+			c.String = append(c.String, string(text))
+		}
+	case ColumnTime:
+		if quoted {
+			var t time.Time
+			if t, err = parseTime(string(text)); err == nil {
+				c.Time = append(c.Time, t)
+			}
+		} else {
+			appended = false
+		}
+	default:
+		appended = false
+	}
+	if err != nil {
+		return err
+	}
+	if appended {
+		c.appendValid()
+		return nil
+	}
+	// Values of other JSON types are decoded as in a QueryResult.
+	s.pos = start
+	v, err := s.cell(nil)
+	if err != nil {
+		return err
+	}
+	return c.append(i, v)
+}
+
+// isJSONNumber reports whether text, a JSON scalar, is a number.
+func isJSONNumber(text []byte) bool {
+	return len(text) > 0 && (text[0] == '-' || text[0] >= '0' && text[0] <= '9')
+}
+
+// parseJSONInt parses a JSON number as an integer. Unlike decoding into
+// float64, integers beyond ±2^53 keep their precision.
+func parseJSONInt(text []byte) (int64, error) {
+	if n, err := strconv.ParseInt(string(text), 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(string(text), 64)
+	if err != nil {
+		return 0, err
+	}
+	if f != math.Trunc(f) {
+		return 0, fmt.Errorf("%v is not an integer", f)
+	}
+	return int64(f), nil
+}
+
+// decodeColumnarResult decodes the JSON of a query result into r column-wise,
+// without materializing rows. See decodeQueryResult.
+func decodeColumnarResult(data []byte, r *ColumnarResult) error {
+	fields, err := scanQueryResult(data)
+	if err != nil || fields == nil {
+		return err
+	}
+	var result QueryResult
+	if raw, ok := fields["query_string"]; ok {
+		if err = json.Unmarshal(raw, &result.QueryString); err != nil {
+			return err
+		}
+	}
+	if raw, ok := fields["metadata"]; ok {
+		if result.Columns, err = decodeMetadata(json.NewDecoder(bytes.NewReader(raw)), &result); err != nil {
+			return err
+		}
+	}
+	if raw, ok := fields["columns"]; ok {
+		result.Columns = nil
+		if err = json.Unmarshal(raw, &result.Columns); err != nil {
+			return err
+		}
+	}
+	*r = *NewColumnarResult(result.QueryString, result.Columns, result.Metadata)
+	raw, ok := fields["data"]
+	if !ok || raw[0] == 'n' {
+		return nil
+	}
+	if raw[0] != '[' {
+		return fmt.Errorf("query result data is not an array")
+	}
+	s := &resultScanner{data: raw}
+	r.grow(s.count())
+	s.pos++
+	for {
+		s.skipSpace()
+		if s.data[s.pos] == ']' {
+			return nil
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			s.skipSpace()
+		}
+		if err = s.columnarRow(r); err != nil {
+			return fmt.Errorf("query result data row %d: %w", r.NumRows, err)
+		}
+	}
+}
+
+// columnarRow decodes a row of a query result into the columns of r.
+func (s *resultScanner) columnarRow(r *ColumnarResult) error {
+	if s.data[s.pos] != '[' {
+		return fmt.Errorf("row is not an array")
+	}
+	s.pos++
+	for j := 0; ; j++ {
+		s.skipSpace()
+		if s.data[s.pos] == ']' {
+			s.pos++
+			if j != len(r.Columns) {
+				return fmt.Errorf("row has %d values, but result has %d columns", j, len(r.Columns))
+			}
+			r.NumRows++
+			return nil
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			s.skipSpace()
+		}
+		if j >= len(r.Columns) {
+			return fmt.Errorf("row has more values than the result's %d columns", len(r.Columns))
+		}
+		if err := r.Columns[j].appendJSON(s, r.NumRows); err != nil {
+			return fmt.Errorf("unable to convert column %s: %w", r.Columns[j].Name, err)
+		}
+	}
+}
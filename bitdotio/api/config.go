@@ -0,0 +1,157 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultRetryMinBackoff is the default delay before the first retry of a
+	// failed API request.
+	defaultRetryMinBackoff = 250 * time.Millisecond
+
+	// defaultRetryMaxBackoff is the default maximum delay between retries of a
+	// failed API request.
+	defaultRetryMaxBackoff = 5 * time.Second
+)
+
+// Logger receives diagnostic messages from the SDK, such as retries. It is
+// satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// ContextLogger is a Logger that also receives the context of the operation
+// being logged, e.g. to correlate SDK log lines with the trace or request IDs
+// that the application carries in its contexts. Messages logged with a context
+// are passed to LogfCtx instead of Printf. API calls carry a context if made
+// with WithContext.
+type ContextLogger interface {
+	Logger
+	LogfCtx(ctx context.Context, format string, v ...interface{})
+}
+
+// RetryPolicy controls retries of failed API requests. Multipart uploads, such
+// as import jobs with a local file, are retried only if the file can be
+// rewound, see CreateImportJob. Note that DefaultRetryable does not retry POST
+// requests, including uploads, since they are not idempotent.
+type RetryPolicy struct {
+	// MaxRetries is the maximum number of retries of a request.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled after each retry.
+	// Defaults to 250ms.
+	MinBackoff time.Duration
+	// MaxBackoff is the maximum delay between retries. Defaults to 5s.
+	MaxBackoff time.Duration
+	// Retryable reports whether a request with method that failed with err
+	// should be retried. Defaults to DefaultRetryable. Requests that exceeded
+	// their own deadline, see WithTimeout, or whose context is done are never
+	// retried.
+	Retryable func(method string, err error) bool
+}
+
+// DefaultRetryable reports whether a request may be safely retried: the
+// method is idempotent, and the request failed with a temporary API error or
+// a network error.
+func DefaultRetryable(method string, err error) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+	default:
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// backoff returns the delay before retry number retry, counting from 1.
+func (p *RetryPolicy) backoff(retry int) time.Duration {
+	minBackoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = defaultRetryMinBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	backoff := minBackoff
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
+
+// retryable reports whether a request should be retried under p.
+func (p *RetryPolicy) retryable(method string, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(method, err)
+	}
+	return DefaultRetryable(method, err)
+}
+
+// ClientConfig contains configuration options for a new Client. The zero value
+// uses the default for every option.
+type ClientConfig struct {
+	// HTTPClient is the HTTP client used for API requests and export
+	// downloads. Defaults to a new http.Client.
+	HTTPClient *http.Client
+	// Transport tunes the transport of a new http.Client, such as its
+	// connection pool size and HTTP/2 support, see TransportConfig. It is
+	// ignored if HTTPClient is set.
+	Transport *TransportConfig
+	// BaseURL is the URL of the bit.io developer API service, e.g. for a proxy.
+	// Defaults to https://api.bit.io.
+	BaseURL string
+	// Logger receives diagnostic messages. Defaults to no logging.
+	Logger Logger
+	// RetryPolicy controls retries of failed API requests. Defaults to no
+	// retries.
+	RetryPolicy *RetryPolicy
+	// Timeout is the default deadline for each API request, which can be
+	// overridden per call with WithTimeout. Defaults to no deadline.
+	Timeout time.Duration
+	// UserAgentSuffix is appended to the SDK's User-Agent header, e.g. to
+	// identify the application or subsystem making requests.
+	UserAgentSuffix string
+	// ProtectedMode requires destructive calls, such as DeleteDatabase, to be
+	// confirmed with WithConfirm naming the affected resource, preventing
+	// accidental deletions from scripts.
+	ProtectedMode bool
+	// MetadataCacheTTL enables caching of GetDatabase and ListDatabases results
+	// for the given duration. Cached entries are invalidated by CreateDatabase,
+	// UpdateDatabase, and DeleteDatabase calls through the same Client or its
+	// derived Clients, but not by changes made elsewhere. Defaults to no
+	// caching.
+	MetadataCacheTTL time.Duration
+	// Events receives events such as retried requests and submitted jobs.
+	// Defaults to no events.
+	Events *EventBus
+	// Middleware wraps the sending of every API request, see
+	// DefaultAPIClient.Middleware. Clients derived with WithConfig keep c's
+	// Middleware unless config sets its own.
+	Middleware []Middleware
+	// APIVersions enables API version negotiation: the client probes which of
+	// these versions, in order of preference, the service supports before its
+	// first request, and again when a request is rejected with 404 Not Found or
+	// 410 Gone, switching request paths transparently, e.g. from v2beta to its
+	// successor. Defaults to always using v2beta. Clients derived with
+	// WithConfig share c's negotiated version.
+	APIVersions []string
+	// MaxPollErrors is the number of consecutive failed job status requests
+	// tolerated by WaitForImportJob, WaitForExportJob, and ImportAndWait while
+	// polling, if the requests fail transiently, e.g. with 503 Service
+	// Unavailable or a timeout. Defaults to 5; a negative value fails on the
+	// first error.
+	MaxPollErrors int
+	// TimeMode controls how Query returns date and timestamp values, see
+	// TimeMode. Defaults to the strings returned by the API.
+	TimeMode TimeMode
+}
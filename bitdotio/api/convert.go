@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts tried, in order, when converting date and time
+// values, covering both ISO 8601 and Postgres text output.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// UnmarshalJSON decodes a query result, recording the order of the columns in
+// the metadata, which the API sends in column order, in Columns, unless the
+// JSON names the columns explicitly. See decodeQueryResult.
+func (r *QueryResult) UnmarshalJSON(data []byte) error {
+	return decodeQueryResult(data, r)
+}
+
+// TypedData returns a copy of r.Data with each cell converted to a Go type
+// according to its column's type in r.Metadata, see ConvertValue. It requires
+// r.Columns to name the columns of r.Data in order.
+func (r *QueryResult) TypedData() ([][]interface{}, error) {
+	typed := make([][]interface{}, len(r.Data))
+	for i, row := range r.Data {
+		if len(row) != len(r.Columns) {
+			return nil, fmt.Errorf("row %d has %d values, but result has %d columns", i, len(row), len(r.Columns))
+		}
+		typed[i] = make([]interface{}, len(row))
+		for j, v := range row {
+			value, err := ConvertValue(r.Metadata[r.Columns[j]], v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to convert row %d column %s: %w", i, r.Columns[j], err)
+			}
+			typed[i][j] = value
+		}
+	}
+	return typed, nil
+}
+
+// ConvertValue converts a query result cell decoded from JSON to the Go type
+// for the Postgres type named typeName:
+//
+//   - integer types: int64
+//   - real, double precision, and numeric: float64
+//   - boolean: bool
+//   - date and timestamp types: time.Time
+//   - bytea: []byte, from hex (`\x...`) or base64 text
+//
+// NULLs convert to nil, and values of other types, or values that already
+// have the target type, are returned unchanged. Integers beyond ±2^53 have
+// already lost precision in JSON decoding.
+func ConvertValue(typeName string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	switch normalizeTypeName(typeName) {
+	case "int2", "int4", "int8", "smallint", "integer", "bigint", "smallserial", "serial", "bigserial", "oid":
+		switch v := v.(type) {
+		case float64:
+			if v != math.Trunc(v) {
+				return nil, fmt.Errorf("%v is not an integer", v)
+			}
+			return int64(v), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+	case "float4", "float8", "real", "double precision", "numeric", "decimal":
+		if s, ok := v.(string); ok {
+			return strconv.ParseFloat(s, 64)
+		}
+	case "bool", "boolean":
+		if s, ok := v.(string); ok {
+			return strconv.ParseBool(s)
+		}
+	case "date", "timestamp", "timestamptz", "timestamp without time zone", "timestamp with time zone":
+		if s, ok := v.(string); ok {
+			return parseTime(s)
+		}
+	case "bytea":
+		if s, ok := v.(string); ok {
+			if strings.HasPrefix(s, `\x`) {
+				return hex.DecodeString(s[2:])
+			}
+			return base64.StdEncoding.DecodeString(s)
+		}
+	}
+	return v, nil
+}
+
+// normalizeTypeName lowercases a Postgres type name and strips any type
+// modifier, e.g. `NUMERIC(10, 2)` becomes `numeric`.
+func normalizeTypeName(typeName string) string {
+	typeName = strings.ToLower(strings.TrimSpace(typeName))
+	if i := strings.IndexByte(typeName, '('); i >= 0 {
+		rest := ""
+		if j := strings.IndexByte(typeName, ')'); j > i {
+			rest = typeName[j+1:]
+		}
+		typeName = strings.TrimSpace(typeName[:i]) + rest
+	}
+	return typeName
+}
+
+// parseTime parses a date or timestamp in any of timeLayouts.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %q as a time", s)
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	mapType     = reflect.TypeOf(map[string]interface{}(nil))
+	jsonbType   = reflect.TypeOf(JSONB{})
+)
+
+// DecodeOptions configures QueryResult.DecodeWithOptions.
+type DecodeOptions struct {
+	// Strict fails decoding when a NULL would be decoded into a field that
+	// cannot represent NULL, such as an int, instead of leaving the field's
+	// zero value.
+	Strict bool
+}
+
+// Decode decodes the rows of r into dest, see DecodeWithOptions.
+func (r *QueryResult) Decode(dest interface{}) error {
+	return r.DecodeWithOptions(dest, &DecodeOptions{})
+}
+
+// DecodeWithOptions decodes the rows of r into dest, which must be a pointer
+// to a slice of structs, struct pointers, or map[string]interface{}, or a
+// pointer to a single struct or map for a result of exactly one row. Cells are
+// first converted to Go types with TypedData.
+//
+// Struct fields are matched to columns by the first element of their db tag,
+// or their lowercased name if there is no tag, as for pool.Repository. Columns
+// without a matching field are ignored. Fields may be pointers, which are nil
+// for NULL, or implement sql.Scanner, such as sql.NullString, which receive
+// NULL as nil. Numeric values convert to any numeric field type, and json and
+// jsonb values decode into fields of any type, as with DecodeJSON.
+func (r *QueryResult) DecodeWithOptions(dest interface{}, options *DecodeOptions) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("decode destination must be a non-nil pointer, got %T", dest)
+	}
+	rows, err := r.TypedData()
+	if err != nil {
+		return err
+	}
+	types := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		types[i] = r.Metadata[column]
+	}
+	d := &decoder{columns: r.Columns, types: types, strict: options.Strict}
+	v = v.Elem()
+	if v.Kind() != reflect.Slice {
+		if len(rows) != 1 {
+			return fmt.Errorf("unable to decode %d rows into %s, expected exactly 1", len(rows), v.Type())
+		}
+		return d.decodeRow(v, rows[0])
+	}
+	slice := reflect.MakeSlice(v.Type(), len(rows), len(rows))
+	for i, row := range rows {
+		if err := d.decodeRow(slice.Index(i), row); err != nil {
+			return fmt.Errorf("unable to decode row %d: %w", i, err)
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// decoder decodes typed rows of a query result.
+type decoder struct {
+	columns []string
+	types   []string
+	strict  bool
+}
+
+// decodeRow decodes row into v, a struct, struct pointer, or map.
+func (d *decoder) decodeRow(v reflect.Value, row []interface{}) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Type() == mapType:
+		m := make(map[string]interface{}, len(row))
+		for i, value := range row {
+			m[d.columns[i]] = value
+		}
+		v.Set(reflect.ValueOf(m))
+		return nil
+	case v.Kind() == reflect.Struct:
+		fields := fieldIndexes(v.Type())
+		for i, value := range row {
+			index, ok := fields[d.columns[i]]
+			if !ok {
+				continue
+			}
+			if err := d.assign(v.FieldByIndex(index), d.types[i], value); err != nil {
+				return fmt.Errorf("column %s: %w", d.columns[i], err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unable to decode a row into %s", v.Type())
+}
+
+// assign sets field to value of a column of type typeName, where value is nil
+// for NULL. Values of json and jsonb columns are decoded from JSON into fields
+// they cannot be assigned to directly.
+func (d *decoder) assign(field reflect.Value, typeName string, value interface{}) error {
+	if field.CanAddr() && field.Type() == jsonbType {
+		// Decode with the column's type, which Scan does not know. Decode
+		// allocates rows, so V is usually nil, and is set to the decoded
+		// value.
+		j := field.Addr().Interface().(*JSONB)
+		if value == nil {
+			return nil
+		}
+		if j.V == nil {
+			var v interface{}
+			if err := DecodeJSONColumn(value, typeName, &v); err != nil {
+				return err
+			}
+			j.V = v
+			return nil
+		}
+		return DecodeJSONColumn(value, typeName, j.V)
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(scannerType) {
+		return field.Addr().Interface().(sql.Scanner).Scan(value)
+	}
+	if value == nil {
+		switch field.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		if d.strict {
+			return fmt.Errorf("NULL cannot be decoded into non-nullable %s, use a pointer or sql.Null type", field.Type())
+		}
+		return nil
+	}
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := d.assign(elem.Elem(), typeName, value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+	case isJSONType(typeName):
+		return DecodeJSONColumn(value, typeName, field.Addr().Interface())
+	case isNumeric(rv.Kind()) && isNumeric(field.Kind()), rv.Kind() == field.Kind() && rv.Type().ConvertibleTo(field.Type()):
+		field.Set(rv.Convert(field.Type()))
+	default:
+		return fmt.Errorf("unable to decode %T into %s", value, field.Type())
+	}
+	return nil
+}
+
+// isNumeric reports whether kind is an integer or floating point kind.
+func isNumeric(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldIndexes maps column names to the indexes of the exported fields of
+// struct type t. Fields tagged `db:"-"` are skipped and embedded structs are
+// flattened.
+func fieldIndexes(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			for name, index := range fieldIndexes(sf.Type) {
+				fields[name] = append([]int{i}, index...)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("db"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = strings.ToLower(sf.Name)
+		}
+		fields[name] = sf.Index
+	}
+	return fields
+}
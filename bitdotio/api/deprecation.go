@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DeprecationNotice describes an API response that announced the deprecation
+// or removal of its endpoint, or carried a warning.
+type DeprecationNotice struct {
+	Method string
+	Path   string
+	// Deprecation is the Deprecation header, e.g. "true" or a date, if any.
+	Deprecation string
+	// Sunset is the time from the Sunset header (RFC 8594) after which the
+	// endpoint may stop responding, or zero if absent or invalid.
+	Sunset time.Time
+	// Link is the URL of the Link header with rel="deprecation" or
+	// rel="sunset", which documents the change, if any.
+	Link string
+	// Warnings are the values of Warning headers, if any.
+	Warnings []string
+}
+
+// DeprecationHook receives a notice for every API response announcing a
+// deprecation. Hooks are called synchronously after each request completes,
+// so they should return quickly.
+type DeprecationHook func(notice *DeprecationNotice)
+
+// SetDeprecationHook sets a hook that is called for every API response with a
+// Deprecation, Sunset, or Warning header, so that programs get advance warning
+// before v2beta endpoints are removed. Notices are also logged to the
+// ClientConfig's Logger, once per endpoint. A nil hook disables the hook.
+func (c *Client) SetDeprecationHook(hook DeprecationHook) {
+	if hook == nil {
+		c.deprecationHook.Store(nil)
+		return
+	}
+	c.deprecationHook.Store(&hook)
+}
+
+// deprecationLog records the endpoints whose deprecation has been logged.
+type deprecationLog struct {
+	lock   sync.Mutex
+	logged map[string]bool
+}
+
+// firstTime reports whether key is seen for the first time.
+func (l *deprecationLog) firstTime(key string) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.logged == nil {
+		l.logged = make(map[string]bool)
+	}
+	if l.logged[key] {
+		return false
+	}
+	l.logged[key] = true
+	return true
+}
+
+// checkDeprecation logs and reports a response to the deprecation hook if its
+// header announces a deprecation.
+func (c *Client) checkDeprecation(ctx context.Context, method, path string, header http.Header) {
+	notice := parseDeprecation(header)
+	if notice == nil {
+		return
+	}
+	notice.Method = method
+	notice.Path = path
+	if c.config.Logger != nil && c.deprecations.firstTime(method+" "+path) {
+		c.logfCtx(ctx, "bitdotio: deprecation notice for %s %s: %s", method, path, notice)
+	}
+	if hook := c.deprecationHook.Load(); hook != nil {
+		(*hook)(notice)
+	}
+}
+
+// parseDeprecation returns a notice for header, or nil if it announces no
+// deprecation.
+func parseDeprecation(header http.Header) *DeprecationNotice {
+	notice := &DeprecationNotice{
+		Deprecation: header.Get("Deprecation"),
+		Warnings:    header.Values("Warning"),
+	}
+	sunset := header.Get("Sunset")
+	if notice.Deprecation == "" && sunset == "" && len(notice.Warnings) == 0 {
+		return nil
+	}
+	if sunset != "" {
+		notice.Sunset, _ = http.ParseTime(sunset)
+	}
+	for _, link := range header.Values("Link") {
+		for _, part := range strings.Split(link, ",") {
+			if strings.Contains(part, `rel="deprecation"`) || strings.Contains(part, `rel="sunset"`) {
+				target, _, _ := strings.Cut(part, ";")
+				notice.Link = strings.Trim(strings.TrimSpace(target), "<>")
+			}
+		}
+	}
+	return notice
+}
+
+// String summarizes the notice for logging.
+func (n *DeprecationNotice) String() string {
+	var parts []string
+	if n.Deprecation != "" {
+		parts = append(parts, "deprecation="+n.Deprecation)
+	}
+	if !n.Sunset.IsZero() {
+		parts = append(parts, "sunset="+n.Sunset.Format(time.RFC3339))
+	}
+	if n.Link != "" {
+		parts = append(parts, "see "+n.Link)
+	}
+	for _, warning := range n.Warnings {
+		parts = append(parts, "warning: "+warning)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultImportConcurrency is the default number of concurrent imports run
+	// by ImportDirectory.
+	defaultImportConcurrency = 4
+)
+
+// importFileExtensions maps the file extensions discovered by ImportDirectory to
+// their import formats.
+var importFileExtensions = map[string]ImportFormat{
+	".csv":    ImportFormatCSV,
+	".json":   ImportFormatJSON,
+	".ndjson": ImportFormatNDJSON,
+	".jsonl":  ImportFormatNDJSON,
+}
+
+// DirectoryImportOptions configures ImportDirectory.
+type DirectoryImportOptions struct {
+	ImportOptions
+	// Concurrency is the maximum number of imports run at once.
+	Concurrency int
+	// SchemaName is the schema for all imported tables.
+	SchemaName string
+	// InferHeader is the header inference mode for all imported files.
+	InferHeader InferHeader
+	// TableName maps a file path to a table name. Defaults to DefaultTableName.
+	TableName func(path string) string
+}
+
+// FileImportResult contains the outcome of importing a single file.
+type FileImportResult struct {
+	Path      string
+	TableName string
+	Job       *ImportJob
+	Err       error
+}
+
+// DefaultTableName maps a file path to a table name by lowercasing its base
+// name without extension and replacing unsupported characters with underscores.
+func DefaultTableName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + ('a' - 'A')
+		}
+		return '_'
+	}, name)
+}
+
+// ImportDirectory imports every CSV, JSON, and NDJSON file in dir, without
+// recursing into subdirectories, into tables named by options.TableName. Up to
+// options.Concurrency imports run at once, and each is waited on as with
+// ImportAndWait. Results are returned for every file, sorted by path; per-file
+// failures are reported in FileImportResult.Err rather than as an error.
+func (c *Client) ImportDirectory(ctx context.Context, fullDBName string, dir string, options *DirectoryImportOptions) ([]*FileImportResult, error) {
+	if options == nil {
+		options = &DirectoryImportOptions{}
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	tableName := options.TableName
+	if tableName == nil {
+		tableName = DefaultTableName
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import directory: %v", err)
+	}
+	var results []*FileImportResult
+	for _, entry := range entries {
+		if _, ok := importFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))]; entry.IsDir() || !ok {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		results = append(results, &FileImportResult{Path: path, TableName: tableName(path)})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, result := range results {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *FileImportResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result.Job, result.Err = c.importFile(ctx, fullDBName, result.Path, result.TableName, options)
+		}(result)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// importFile imports a single local file and waits for the job to finish.
+func (c *Client) importFile(ctx context.Context, fullDBName, path, tableName string, options *DirectoryImportOptions) (*ImportJob, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	config := &ImportJobConfig{
+		SchemaName:  options.SchemaName,
+		InferHeader: options.InferHeader,
+		Format:      importFileExtensions[strings.ToLower(filepath.Ext(path))],
+		File:        f,
+	}
+	return c.ImportAndWait(ctx, fullDBName, tableName, config, &options.ImportOptions)
+}
@@ -0,0 +1,322 @@
+package api
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/parquet"
+)
+
+const (
+	// downloadMaxResumes is the maximum number of times DownloadExport resumes
+	// a broken download stream.
+	downloadMaxResumes = 5
+
+	// downloadResumeBackoff is the delay before resuming a broken download
+	// stream, doubled after each attempt.
+	downloadResumeBackoff = 500 * time.Millisecond
+)
+
+// md5ETag matches an ETag that is the plain MD5 digest of the content, as
+// used by S3 for objects not uploaded in parts.
+var md5ETag = regexp.MustCompile(`^"?([0-9a-fA-F]{32})"?$`)
+
+// httpClient returns the HTTP client used for requests outside of the bit.io
+// API, such as export downloads.
+func (c *Client) httpClient() *http.Client {
+	if defaultClient, ok := c.apiClient.(*DefaultAPIClient); ok {
+		return defaultClient.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// DownloadExport opens the file produced by a finished export job. The caller
+// is responsible for closing the returned reader.
+//
+// If the download stream breaks, it is resumed from the last byte received
+// with an HTTP Range request, up to downloadMaxResumes times. When the stream
+// ends, its length is checked against the Content-Length, and its MD5 digest
+// against the ETag when the ETag is a plain MD5 digest, so a truncated or
+// corrupted download is reported as an error from Read, wrapping an
+// *IntegrityError, rather than io.EOF.
+//
+// Download URLs expire, so if the download is rejected as forbidden, the URL
+// is refreshed by fetching the export job again before retrying.
+func (c *Client) DownloadExport(ctx context.Context, exportJob *ExportJob) (io.ReadCloser, error) {
+	if exportJob.DownloadURL == "" {
+		return nil, fmt.Errorf("export job %s has no download URL, state is %s", exportJob.ID, exportJob.State)
+	}
+	d := &download{
+		client: c,
+		ctx:    ctx,
+		jobID:  exportJob.ID,
+		url:    exportJob.DownloadURL,
+		size:   -1,
+		hash:   md5.New(),
+	}
+	res, err := d.open()
+	if err != nil {
+		return nil, err
+	}
+	d.body = res.Body
+	d.size = res.ContentLength
+	d.etag = res.Header.Get("ETag")
+	d.resumable = res.Header.Get("Accept-Ranges") == "bytes"
+	return d, nil
+}
+
+// ReadParquetExport downloads a finished parquet-format export job to a
+// temporary file, which parquet requires for random access, and calls read
+// with an iterator over its decoded rows:
+//
+//	err := c.ReadParquetExport(ctx, exportJob, func(rows *parquet.Rows) error {
+//		for rows.Next() {
+//			values := rows.Values()
+//			...
+//		}
+//		return rows.Err()
+//	})
+//
+// Values are of the Go types described by parquet.File.ReadRowGroup, and
+// rows.Columns describes the file's schema. The temporary file is removed
+// when read returns.
+func (c *Client) ReadParquetExport(ctx context.Context, exportJob *ExportJob, read func(rows *parquet.Rows) error) error {
+	if exportJob.ExportFormat != "parquet" {
+		return fmt.Errorf("export job %s has format %s, not parquet", exportJob.ID, exportJob.ExportFormat)
+	}
+	body, err := c.DownloadExport(ctx, exportJob)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	f, err := os.CreateTemp("", "bitdotio-export-*.parquet")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	size, err := io.Copy(f, body)
+	if err != nil {
+		return fmt.Errorf("export download failed with error: %w", err)
+	}
+	file, err := parquet.Open(f, size)
+	if err != nil {
+		return fmt.Errorf("failed to read parquet export: %w", err)
+	}
+	return read(file.Rows())
+}
+
+// DownloadOptions configures DownloadExportToFile.
+type DownloadOptions struct {
+	// SHA256 is the expected hex-encoded SHA-256 digest of the file. If set,
+	// the download is checked against it before the file is created.
+	SHA256 string
+	// Perm is the permission of the created file. Defaults to 0644.
+	Perm os.FileMode
+}
+
+// DownloadExportToFile downloads the file produced by a finished export job to
+// path, and returns its size. The download is written to a temporary file in
+// the same directory, which is renamed to path only once the download is
+// complete and has passed DownloadExport's integrity checks, and the optional
+// checksum in options, so that an interrupted or corrupted download never
+// leaves a truncated file at path. options may be nil. An existing file at
+// path is replaced.
+func (c *Client) DownloadExportToFile(ctx context.Context, exportJob *ExportJob, path string, options *DownloadOptions) (int64, error) {
+	if options == nil {
+		options = &DownloadOptions{}
+	}
+	perm := options.Perm
+	if perm == 0 {
+		perm = 0644
+	}
+	body, err := c.DownloadExport(ctx, exportJob)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := f.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			f.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	digest := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, digest), body)
+	if err != nil {
+		return 0, fmt.Errorf("export download failed with error: %w", err)
+	}
+	if options.SHA256 != "" {
+		if actual := hex.EncodeToString(digest.Sum(nil)); !strings.EqualFold(actual, options.SHA256) {
+			return 0, fmt.Errorf("export download failed with error: %w", &IntegrityError{Check: "sha256", Expected: options.SHA256, Actual: actual})
+		}
+	}
+	if err = f.Chmod(perm); err != nil {
+		return 0, err
+	}
+	if err = f.Sync(); err != nil {
+		return 0, err
+	}
+	if err = f.Close(); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return 0, err
+	}
+	committed = true
+	return size, nil
+}
+
+// download is a resumable export download stream.
+type download struct {
+	client    *Client
+	ctx       context.Context
+	jobID     string
+	url       string
+	body      io.ReadCloser
+	offset    int64
+	size      int64
+	etag      string
+	resumable bool
+	resumes   int
+	hash      hash.Hash
+}
+
+// open requests the download from d.offset onward. If the download URL has
+// expired, it is refreshed from the export job and the request is retried once.
+func (d *download) open() (*http.Response, error) {
+	res, err := d.get()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusForbidden || d.jobID == "" {
+		return res, err
+	}
+	// Download URLs are time-limited signed URLs, which fail with 403 Forbidden
+	// once expired, e.g. for downloads queued behind others.
+	exportJob, refreshErr := d.client.GetExportJob(d.jobID, WithContext(d.ctx))
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w, and refreshing the download URL failed: %v", err, refreshErr)
+	}
+	if exportJob.DownloadURL == "" || exportJob.DownloadURL == d.url {
+		return nil, err
+	}
+	d.url = exportJob.DownloadURL
+	return d.get()
+}
+
+// get requests the download from d.offset onward.
+func (d *download) get() (*http.Response, error) {
+	req, err := http.NewRequestWithContext(d.ctx, "GET", d.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a new request: %v", err)
+	}
+	if d.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", d.offset))
+		if d.etag != "" {
+			req.Header.Set("If-Range", d.etag)
+		}
+	}
+	res, err := d.client.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("export download failed with error: %w", err)
+	}
+	if res.StatusCode >= 400 {
+		resBody, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		return nil, &APIError{Status: res.StatusCode, Body: string(resBody), Method: "GET", Path: req.URL.Path}
+	}
+	if d.offset > 0 && res.StatusCode != http.StatusPartialContent {
+		res.Body.Close()
+		return nil, errors.New("export download cannot be resumed, the server did not return the requested range")
+	}
+	return res, nil
+}
+
+func (d *download) Read(p []byte) (int, error) {
+	for {
+		n, err := d.body.Read(p)
+		d.offset += int64(n)
+		d.hash.Write(p[:n])
+		if err == io.EOF && (d.size < 0 || d.offset >= d.size) {
+			return n, d.verify()
+		}
+		if err == nil || n > 0 {
+			return n, nil
+		}
+		if err = d.resume(err); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// resume reopens the download stream after it broke with err, returning err
+// if the stream cannot be resumed. A stream that ended before its
+// Content-Length and cannot be resumed is reported as an *IntegrityError.
+func (d *download) resume(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = &IntegrityError{Check: "length", Expected: strconv.FormatInt(d.size, 10), Actual: strconv.FormatInt(d.offset, 10)}
+	}
+	if !d.resumable || d.resumes == downloadMaxResumes || d.ctx.Err() != nil {
+		return fmt.Errorf("export download failed after %d bytes with error: %w", d.offset, err)
+	}
+	d.body.Close()
+	backoff := downloadResumeBackoff << d.resumes
+	d.resumes++
+	d.client.logfCtx(d.ctx, "bitdotio: resuming export download after %d bytes in %s after error: %v", d.offset, backoff, err)
+	select {
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	case <-time.After(backoff):
+	}
+	res, openErr := d.open()
+	if openErr != nil {
+		var integrityErr *IntegrityError
+		if errors.As(err, &integrityErr) {
+			return fmt.Errorf("export download failed after %d bytes with error: %w, resume failed: %v", d.offset, err, openErr)
+		}
+		return fmt.Errorf("export download failed after %d bytes with error: %v, resume failed: %w", d.offset, err, openErr)
+	}
+	d.body = res.Body
+	return nil
+}
+
+// verify checks the length and, when possible, the digest of a completed
+// download.
+func (d *download) verify() error {
+	if d.size >= 0 && d.offset != d.size {
+		err := &IntegrityError{Check: "length", Expected: strconv.FormatInt(d.size, 10), Actual: strconv.FormatInt(d.offset, 10)}
+		return fmt.Errorf("export download incomplete: %w", err)
+	}
+	if match := md5ETag.FindStringSubmatch(d.etag); match != nil {
+		if sum := hex.EncodeToString(d.hash.Sum(nil)); !strings.EqualFold(sum, match[1]) {
+			err := &IntegrityError{Check: "md5", Expected: strings.ToLower(match[1]), Actual: sum}
+			return fmt.Errorf("export download corrupted: %w", err)
+		}
+	}
+	return io.EOF
+}
+
+func (d *download) Close() error {
+	return d.body.Close()
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/parquet"
+)
+
+func TestReadParquetExport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "../parquet/testdata/export.parquet")
+	}))
+	defer server.Close()
+	c := NewClient("token")
+	exportJob := &ExportJob{TransferJob: TransferJob{ID: "export-1", State: "DONE"}, ExportFormat: "parquet", DownloadURL: server.URL + "/export.parquet"}
+
+	var names []string
+	var got [][]interface{}
+	err := c.ReadParquetExport(context.Background(), exportJob, func(rows *parquet.Rows) error {
+		for _, column := range rows.Columns() {
+			names = append(names, column.Name)
+		}
+		for rows.Next() {
+			values := rows.Values()
+			got = append(got, values[:5])
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"id", "name", "score", "active", "day", "created_at", "price", "uid", "note"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("columns = %v, want %v", names, want)
+	}
+	want := [][]interface{}{
+		{int64(1), "alpha", 1.5, true, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{int64(2), nil, nil, false, nil},
+		{int64(3), "beta", -2.25, true, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{int64(4), "alpha", 0.0, false, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{int64(5), "alpha", nil, true, nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+
+	exportJob.ExportFormat = "csv"
+	if err = c.ReadParquetExport(context.Background(), exportJob, func(*parquet.Rows) error { return nil }); err == nil {
+		t.Error("ReadParquetExport of a CSV export succeeded, want an error")
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError indicates a completed API response with an error status.
+type APIError struct {
+	Status int    `json:"status,omitempty"`
+	Body   string `body:"body,omitempty"`
+	// Method and Path identify the API endpoint that returned the error, and
+	// Retries is the number of earlier attempts of the same request.
+	Method  string `json:"method,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Retries int    `json:"retries,omitempty"`
+	// RequestID is the X-Request-ID sent with the request, and ServerRequestID
+	// is the request ID returned by bit.io, if any. Include both when
+	// contacting bit.io support about a failed request.
+	RequestID       string `json:"request_id,omitempty"`
+	ServerRequestID string `json:"server_request_id,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	ret, _ := json.Marshal(e)
+	return string(ret)
+}
+
+// Temporary reports whether the request may succeed if retried: the server
+// timed out, was rate limiting, or was temporarily unavailable.
+func (e *APIError) Temporary() bool {
+	switch e.Status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Timeout reports whether the request timed out on the server or a gateway.
+func (e *APIError) Timeout() bool {
+	return e.Status == http.StatusRequestTimeout || e.Status == http.StatusGatewayTimeout
+}
+
+// Retryable reports whether the request may succeed if retried. It is
+// equivalent to Temporary, and is provided for retry frameworks that look for
+// it by name.
+func (e *APIError) Retryable() bool {
+	return e.Temporary()
+}
+
+// isWaking reports whether err indicates that a database is waking from
+// hibernation and should be retried shortly: a 503 whose body reports that
+// the database is starting up. Other 503s, e.g. from maintenance or an
+// overloaded API, are left to the retry policy.
+func isWaking(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Status == http.StatusServiceUnavailable && strings.Contains(strings.ToLower(apiErr.Body), "starting up")
+}
+
+// JobError indicates that an import or export job finished in a failed state.
+type JobError struct {
+	Job *TransferJob
+}
+
+func (e *JobError) Error() string {
+	return fmt.Sprintf("job %s failed with error type %s (error ID %s)", e.Job.ID, e.Job.ErrorType, e.Job.ErrorID)
+}
+
+// IntegrityError indicates that transferred data failed an integrity check,
+// e.g. a download that was truncated or corrupted in transit.
+type IntegrityError struct {
+	// Check is the failed check, "length", "md5", or "sha256".
+	Check    string
+	Expected string
+	Actual   string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed: %s is %s, expected %s", e.Check, e.Actual, e.Expected)
+}
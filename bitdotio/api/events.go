@@ -0,0 +1,124 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType identifies a kind of SDK event.
+type EventType string
+
+// Events published to an EventBus.
+const (
+	// EventPoolCreated is published when a connection pool is created for
+	// Event.DBName.
+	EventPoolCreated EventType = "pool_created"
+	// EventPoolClosed is published when the connection pool for Event.DBName is
+	// closed with ClosePool.
+	EventPoolClosed EventType = "pool_closed"
+	// EventJobSubmitted is published when an import or export job, Event.JobID,
+	// is created.
+	EventJobSubmitted EventType = "job_submitted"
+	// EventJobFinished is published when WaitForImportJob or WaitForExportJob
+	// sees job Event.JobID finish, with Event.Err set if the job failed.
+	EventJobFinished EventType = "job_finished"
+	// EventRequestRetried is published before an API request, Event.Method and
+	// Event.Path, is retried after Event.Err.
+	EventRequestRetried EventType = "request_retried"
+	// EventRateLimited is published when an API request is rejected with
+	// 429 Too Many Requests.
+	EventRateLimited EventType = "rate_limited"
+)
+
+// Event is an SDK event. Fields that do not apply to an event's Type are
+// empty.
+type Event struct {
+	Type   EventType
+	Time   time.Time
+	DBName string
+	JobID  string
+	Method string
+	Path   string
+	Err    error
+}
+
+// EventBus delivers SDK events to subscribers, e.g. for dashboards and
+// alerting. A nil *EventBus discards events.
+type EventBus struct {
+	lock sync.RWMutex
+	subs map[int]*subscription
+	next int
+}
+
+type subscription struct {
+	fn    func(*Event)
+	types map[EventType]bool
+}
+
+// NewEventBus constructs an EventBus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[int]*subscription)}
+}
+
+// Subscribe calls fn with every published event of one of types, or of any
+// type if no types are given, until the returned function is called. fn is
+// called synchronously by the goroutine publishing the event, so it should
+// return quickly. fn may unsubscribe, and may be called once more with an
+// event published concurrently with unsubscribing.
+func (b *EventBus) Subscribe(fn func(event *Event), types ...EventType) (unsubscribe func()) {
+	sub := &subscription{fn: fn}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.lock.Lock()
+	id := b.next
+	b.next++
+	b.subs[id] = sub
+	b.lock.Unlock()
+	return func() {
+		b.lock.Lock()
+		delete(b.subs, id)
+		b.lock.Unlock()
+	}
+}
+
+// SubscribeChan delivers published events of one of types, or of any type if
+// no types are given, on a channel with the given buffer size, until the
+// returned function is called. Events are dropped rather than blocking the
+// publisher when the channel's buffer is full. The channel is never closed.
+func (b *EventBus) SubscribeChan(buffer int, types ...EventType) (<-chan *Event, func()) {
+	events := make(chan *Event, buffer)
+	unsubscribe := b.Subscribe(func(event *Event) {
+		select {
+		case events <- event:
+		default:
+		}
+	}, types...)
+	return events, unsubscribe
+}
+
+// Publish delivers event to subscribers, setting event.Time if it is zero.
+func (b *EventBus) Publish(event *Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	// Call subscribers without holding the lock, so that they may subscribe
+	// or unsubscribe.
+	b.lock.RLock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if sub.types == nil || sub.types[event.Type] {
+			subs = append(subs, sub)
+		}
+	}
+	b.lock.RUnlock()
+	for _, sub := range subs {
+		sub.fn(event)
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// mergeExtra adds the entries of extra to the JSON object body, overriding
+// fields of the same name, so that users can send API parameters that the
+// SDK's config types do not model yet.
+func mergeExtra(body []byte, extra map[string]interface{}) ([]byte, error) {
+	if len(extra) == 0 {
+		return body, nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range extra {
+		fields[key] = value
+	}
+	return json.Marshal(fields)
+}
+
+// extraFieldParts converts extra to multipart field values. Strings are sent
+// as is, and other values as JSON.
+func extraFieldParts(extra map[string]interface{}) (fieldParts, error) {
+	fields := make(fieldParts, len(extra))
+	for key, value := range extra {
+		if s, ok := value.(string); ok {
+			fields[key] = strings.NewReader(s)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize extra field %s: %v", key, err)
+		}
+		fields[key] = strings.NewReader(string(data))
+	}
+	return fields, nil
+}
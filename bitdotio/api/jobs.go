@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultPollInterval is the default interval between job status requests.
+	defaultPollInterval = 2 * time.Second
+
+	// defaultMaxPollErrors is the default number of consecutive failed job
+	// status requests tolerated while waiting for a job.
+	defaultMaxPollErrors = 5
+)
+
+// ImportOptions configures ImportAndWait. The zero value polls at a default
+// interval and does not resubmit failed jobs.
+type ImportOptions struct {
+	// PollInterval is the interval between job status requests.
+	PollInterval time.Duration
+	// MaxRetries is the maximum number of retries for a job that fails with a
+	// transient error. Retries already performed by bit.io, as reported in
+	// TransferJob.Retries, count towards this limit.
+	MaxRetries int
+	// IsTransient reports whether a failed job may succeed if resubmitted.
+	// Defaults to IsTransientJobError.
+	IsTransient func(job *TransferJob) bool
+}
+
+// transientErrorTypes are error_type substrings that indicate a job failed for
+// reasons unrelated to its input.
+var transientErrorTypes = []string{"timeout", "network", "unavailable", "internal"}
+
+// IsTransientJobError reports whether a failed job's error type indicates a
+// transient failure, such as a timeout or network error.
+func IsTransientJobError(job *TransferJob) bool {
+	errorType := strings.ToLower(job.ErrorType)
+	for _, t := range transientErrorTypes {
+		if strings.Contains(errorType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportAndWait creates an import job and polls it until it finishes. If the
+// job fails with a transient error and options.MaxRetries allows, the job is
+// resubmitted. Resubmitting a job created from config.File requires File to
+// implement io.Seeker so that it can be rewound to its offset when
+// ImportAndWait was called; otherwise only jobs created from config.FileURL are
+// resubmitted. A job that finishes in a failed state is
+// returned along with a *JobError.
+func (c *Client) ImportAndWait(ctx context.Context, fullDBName string, tableName string, config *ImportJobConfig, options *ImportOptions) (*ImportJob, error) {
+	if options == nil {
+		options = &ImportOptions{}
+	}
+	isTransient := options.IsTransient
+	if isTransient == nil {
+		isTransient = IsTransientJobError
+	}
+
+	// The file is rewound to where it was on entry, not to its start, as for
+	// multipart requests, see multipartRewinder.
+	var seeker io.Seeker
+	var offset int64
+	if config.File != nil {
+		if s, ok := config.File.(io.Seeker); ok {
+			if o, err := s.Seek(0, io.SeekCurrent); err == nil {
+				seeker, offset = s, o
+			}
+		}
+	}
+
+	var retries int
+	for {
+		importJob, err := c.CreateImportJob(fullDBName, tableName, config, WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		importJob, err = c.WaitForImportJob(ctx, importJob.ID, options.PollInterval)
+		var jobErr *JobError
+		if !errors.As(err, &jobErr) {
+			return importJob, err
+		}
+
+		retries += int(importJob.Retries) + 1
+		if retries > options.MaxRetries || !isTransient(&importJob.TransferJob) {
+			return importJob, err
+		}
+		if config.File != nil {
+			if seeker == nil {
+				return importJob, err
+			}
+			if _, seekErr := seeker.Seek(offset, io.SeekStart); seekErr != nil {
+				return importJob, fmt.Errorf("unable to rewind import file for retry: %v", seekErr)
+			}
+		}
+	}
+}
+
+// WaitForImportJob polls an import job every pollInterval until it finishes or
+// ctx is done. A pollInterval of 0 uses a default interval. Transient polling
+// errors are tolerated, see ClientConfig.MaxPollErrors. A job that finishes in
+// a failed state is returned along with a *JobError.
+func (c *Client) WaitForImportJob(ctx context.Context, importID string, pollInterval time.Duration) (*ImportJob, error) {
+	var importJob *ImportJob
+	err := c.waitForJob(ctx, pollInterval, func() (*TransferJob, error) {
+		var err error
+		importJob, err = c.GetImportJob(importID, WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &importJob.TransferJob, nil
+	})
+	return importJob, err
+}
+
+// WaitForExportJob polls an export job every pollInterval until it finishes or
+// ctx is done. A pollInterval of 0 uses a default interval. Transient polling
+// errors are tolerated, see ClientConfig.MaxPollErrors. A job that finishes in
+// a failed state is returned along with a *JobError.
+func (c *Client) WaitForExportJob(ctx context.Context, exportID string, pollInterval time.Duration) (*ExportJob, error) {
+	var exportJob *ExportJob
+	err := c.waitForJob(ctx, pollInterval, func() (*TransferJob, error) {
+		var err error
+		exportJob, err = c.GetExportJob(exportID, WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &exportJob.TransferJob, nil
+	})
+	return exportJob, err
+}
+
+// Job is an import or export job fetched by its status URL. Exactly one of
+// Import and Export is set.
+type Job struct {
+	Import *ImportJob
+	Export *ExportJob
+}
+
+// Transfer returns the job's common fields.
+func (j *Job) Transfer() *TransferJob {
+	if j.Import != nil {
+		return &j.Import.TransferJob
+	}
+	return &j.Export.TransferJob
+}
+
+// parseStatusURL returns whether statusURL, a TransferJob.StatusURL, is the
+// status of an import or an export job, and the job's ID.
+func parseStatusURL(statusURL string) (kind, id string, err error) {
+	u, err := url.Parse(statusURL)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid job status URL: %v", err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if n := len(segments); n >= 2 {
+		kind, id = segments[n-2], segments[n-1]
+		if (kind == "import" || kind == "export") && id != "" {
+			return kind, id, nil
+		}
+	}
+	return "", "", fmt.Errorf("%q is not an import or export job status URL", statusURL)
+}
+
+// GetJobByURL gets the status of an import or export job from its StatusURL,
+// e.g. one persisted by a previous run, so that callers can resume tracking it
+// without keeping the job's ID and kind. The job is fetched from the Client's
+// API, with the ID and kind from statusURL's path, so that its access token is
+// never sent to another host.
+func (c *Client) GetJobByURL(ctx context.Context, statusURL string) (*Job, error) {
+	kind, id, err := parseStatusURL(statusURL)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "import" {
+		importJob, err := c.GetImportJob(id, WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return &Job{Import: importJob}, nil
+	}
+	exportJob, err := c.GetExportJob(id, WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Job{Export: exportJob}, nil
+}
+
+// WaitForJobByURL polls an import or export job by its StatusURL, as for
+// WaitForImportJob and WaitForExportJob.
+func (c *Client) WaitForJobByURL(ctx context.Context, statusURL string, pollInterval time.Duration) (*Job, error) {
+	kind, id, err := parseStatusURL(statusURL)
+	if err != nil {
+		return nil, err
+	}
+	if kind == "import" {
+		importJob, err := c.WaitForImportJob(ctx, id, pollInterval)
+		if importJob == nil {
+			return nil, err
+		}
+		return &Job{Import: importJob}, err
+	}
+	exportJob, err := c.WaitForExportJob(ctx, id, pollInterval)
+	if exportJob == nil {
+		return nil, err
+	}
+	return &Job{Export: exportJob}, err
+}
+
+// waitForJob calls getJob every pollInterval until the job finishes, and
+// publishes an EventJobFinished event when it does. A status request that
+// fails transiently, see isTransientPollError, is retried at the next poll,
+// unless it is the last of more than MaxPollErrors consecutive failures.
+func (c *Client) waitForJob(ctx context.Context, pollInterval time.Duration, getJob func() (*TransferJob, error)) error {
+	if pollInterval == 0 {
+		pollInterval = defaultPollInterval
+	}
+	maxPollErrors := c.config.MaxPollErrors
+	if maxPollErrors == 0 {
+		maxPollErrors = defaultMaxPollErrors
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	var pollErrors int
+	for {
+		job, err := getJob()
+		switch {
+		case err == nil:
+			pollErrors = 0
+		case ctx.Err() != nil || !isTransientPollError(err):
+			return err
+		case pollErrors >= maxPollErrors:
+			return fmt.Errorf("job status failed %d consecutive times: %w", pollErrors+1, err)
+		default:
+			pollErrors++
+			c.logfCtx(ctx, "bitdotio: job status request failed, retrying at next poll: %v", err)
+		}
+		if job != nil {
+			switch job.State {
+			case JobStateDone:
+				c.config.Events.Publish(&Event{Type: EventJobFinished, JobID: job.ID})
+				return nil
+			case JobStateFailed:
+				err := &JobError{Job: job}
+				c.config.Events.Publish(&Event{Type: EventJobFinished, JobID: job.ID, Err: err})
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// isTransientPollError reports whether a failed job status request may succeed
+// at the next poll: the API returned a temporary error, such as 503 Service
+// Unavailable, or the request failed with a network error or timed out.
+func isTransientPollError(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSONB binds a Go value as a json or jsonb query parameter, or scans a json or
+// jsonb column into a Go value, on either transport. For example, with a pool:
+//
+//	_, err = pool.Exec(ctx, "INSERT INTO events (payload) VALUES ($1)", api.JSONB{V: payload})
+//	err = pool.QueryRow(ctx, "SELECT payload FROM events").Scan(&api.JSONB{V: &payload})
+//
+// and with a QueryResult, in a field of a struct decoded with Decode, or with
+// DecodeJSON. Struct fields decoded with Decode from json and jsonb columns are
+// also decoded from JSON without the wrapper.
+type JSONB struct {
+	V interface{}
+}
+
+// Value encodes j.V as JSON text.
+func (j JSONB) Value() (driver.Value, error) {
+	data, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// MarshalJSON encodes j.V, so that pgx, which encodes json and jsonb
+// parameters with encoding/json rather than Value, sends j.V.
+func (j JSONB) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.V)
+}
+
+// Scan decodes a JSON value into j.V, which must be a pointer. NULL leaves j.V
+// unchanged.
+func (j *JSONB) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	return DecodeJSON(src, j.V)
+}
+
+// UnmarshalJSON decodes data into j.V, which must be a pointer, so that pgx,
+// which scans json and jsonb columns with encoding/json rather than Scan,
+// fills j.V.
+func (j *JSONB) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, j.V)
+}
+
+// DecodeJSON decodes a json or jsonb cell into dest, which must be a pointer.
+// The cell may be JSON text, as returned by Postgres drivers, or an already
+// decoded JSON value, as in QueryResult.Data. For cells of columns of other
+// types, use DecodeJSONColumn.
+func DecodeJSON(cell interface{}, dest interface{}) error {
+	return DecodeJSONColumn(cell, "jsonb", dest)
+}
+
+// DecodeJSONColumn decodes a cell of a column of Postgres type typeName into
+// dest, which must be a pointer. Cells of json and jsonb columns are decoded
+// as for DecodeJSON. Cells of other columns are decoded as the values they
+// are, so that a text cell holding JSON text decodes as a string.
+func DecodeJSONColumn(cell interface{}, typeName string, dest interface{}) error {
+	if v := reflect.ValueOf(dest); v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("JSON decode destination must be a non-nil pointer, got %T", dest)
+	}
+	var data []byte
+	if isJSONType(typeName) {
+		switch cell := cell.(type) {
+		case []byte:
+			data = cell
+		case string:
+			// A string is JSON text from a driver, or else a decoded JSON string.
+			data = []byte(cell)
+		}
+	}
+	if data == nil || !json.Valid(data) {
+		var err error
+		if data, err = json.Marshal(cell); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// isJSONType reports whether typeName names a Postgres JSON type.
+func isJSONType(typeName string) bool {
+	switch normalizeTypeName(typeName) {
+	case "json", "jsonb":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// ResponseSummary describes the most recent API request made by a Client, for
+// debugging. Secrets, such as API keys in response bodies, are redacted, and
+// bodies are truncated to 1KiB.
+type ResponseSummary struct {
+	Time   time.Time
+	Method string
+	Path   string
+	// Request summarizes the request: its JSON body, or the field and file
+	// names of a multipart upload.
+	Request string
+	// Status is the HTTP status code of the response, or 0 if no response was
+	// received.
+	Status int
+	// Header contains the response headers, without cookies.
+	Header  http.Header
+	Body    string
+	Latency time.Duration
+	Err     error
+}
+
+// lastCall holds the unsanitized details of a Client's most recent request.
+// The summary is only built when requested by LastResponse, so that recording
+// a call is cheap.
+type lastCall struct {
+	time     time.Time
+	method   string
+	path     string
+	request  func() string
+	response APIResponse
+	body     []byte
+	latency  time.Duration
+	err      error
+}
+
+// secretPattern matches JSON string fields that may hold secrets, including a
+// value cut off by truncation.
+var secretPattern = regexp.MustCompile(`("(?i:api_key|access_token|token|password|secret)"\s*:\s*)"[^"]*"?`)
+
+// LastResponse returns a summary of the most recent API request made by c, or
+// nil if c has made none, e.g. to see why a call failed with 400 Bad Request
+// while exploring the API. Retried requests are summarized per attempt. Clients
+// derived with WithConfig or AsServiceAccount record their own requests.
+func (c *Client) LastResponse() *ResponseSummary {
+	last := c.lastCall.Load()
+	if last == nil {
+		return nil
+	}
+	summary := &ResponseSummary{
+		Time:    last.time,
+		Method:  last.method,
+		Path:    last.path,
+		Request: redactSecrets(last.request()),
+		Status:  last.response.Status,
+		Body:    redactSecrets(summarizeBody(last.body)),
+		Latency: last.latency,
+		Err:     last.err,
+	}
+	if last.response.Header != nil {
+		summary.Header = last.response.Header.Clone()
+		summary.Header.Del("Set-Cookie")
+	}
+	return summary
+}
+
+// recordLast calls do with the options in opts and records the request as c's
+// most recent. request is called only if the request is summarized.
+func (c *Client) recordLast(method, path string, request func() string, opts []CallOption, do func(options *callOptions) ([]byte, error)) ([]byte, error) {
+	options := c.newCallOptions(opts)
+	callerResponse := options.response
+	last := &lastCall{time: time.Now(), method: method, path: path, request: request}
+	options.response = &last.response
+	data, err := do(options)
+	last.latency = time.Since(last.time)
+	last.body = data
+	last.err = err
+	if last.response.Status != 0 {
+		c.checkDeprecation(options.context(), method, path, last.response.Header)
+		if callerResponse != nil {
+			*callerResponse = last.response
+		}
+	}
+	c.lastCall.Store(last)
+	return data, err
+}
+
+// redactSecrets replaces the values of secret fields in a JSON summary.
+func redactSecrets(s string) string {
+	return secretPattern.ReplaceAllString(s, `$1"REDACTED"`)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ndjsonValidator wraps a reader of newline-delimited JSON and returns an error
+// from Read as soon as a complete line is found that is not a valid JSON value.
+// Blank lines are allowed.
+type ndjsonValidator struct {
+	r       io.Reader
+	partial []byte
+	line    int
+}
+
+func newNDJSONValidator(r io.Reader) *ndjsonValidator {
+	return &ndjsonValidator{r: r}
+}
+
+func (v *ndjsonValidator) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	data := p[:n]
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			v.partial = append(v.partial, data...)
+			break
+		}
+		v.partial = append(v.partial, data[:i]...)
+		if lineErr := v.validateLine(); lineErr != nil {
+			return n, lineErr
+		}
+		data = data[i+1:]
+	}
+	if err == io.EOF && len(v.partial) > 0 {
+		if lineErr := v.validateLine(); lineErr != nil {
+			return n, lineErr
+		}
+	}
+	return n, err
+}
+
+// Seek seeks the underlying reader, if it is an io.Seeker, and resets
+// validation, so that the validator can be rewound with its reader.
+func (v *ndjsonValidator) Seek(offset int64, whence int) (int64, error) {
+	seeker, ok := v.r.(io.Seeker)
+	if !ok {
+		return 0, errors.New("NDJSON reader is not seekable")
+	}
+	n, err := seeker.Seek(offset, whence)
+	if err == nil && !(offset == 0 && whence == io.SeekCurrent) {
+		v.partial = v.partial[:0]
+		v.line = 0
+	}
+	return n, err
+}
+
+// validateLine checks and then clears the buffered line.
+func (v *ndjsonValidator) validateLine() error {
+	v.line++
+	line := bytes.TrimSpace(v.partial)
+	v.partial = v.partial[:0]
+	if len(line) > 0 && !json.Valid(line) {
+		return fmt.Errorf("invalid NDJSON on line %d", v.line)
+	}
+	return nil
+}
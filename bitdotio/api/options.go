@@ -0,0 +1,193 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotConfirmed indicates that a destructive call in protected mode was not
+// confirmed with WithConfirm.
+var ErrNotConfirmed = errors.New("destructive call not confirmed")
+
+// ErrResultTooLarge indicates that a query returned more rows than allowed by
+// WithMaxRows.
+var ErrResultTooLarge = errors.New("result too large")
+
+// CallOption configures a single API request.
+type CallOption func(*callOptions)
+
+type callOptions struct {
+	timeout  time.Duration
+	rowLimit int
+	maxRows  int
+	confirm  string
+	response *APIResponse
+	ctx      context.Context
+	// decode, if set by withDecoder, decodes a successful response body as
+	// it is received.
+	decode func(body io.Reader) error
+}
+
+// context returns the context set by WithContext, or context.Background.
+func (o *callOptions) context() context.Context {
+	if o.ctx == nil {
+		return context.Background()
+	}
+	return o.ctx
+}
+
+// WithContext makes a single API request with ctx, so that it is canceled when
+// ctx is done, and so that SDK log messages about it, such as retries, are
+// passed ctx if the Logger is a ContextLogger. Contexts are only applied by
+// APIClients that implement ContextAPIClient, such as DefaultAPIClient.
+func WithContext(ctx context.Context) CallOption {
+	return func(o *callOptions) {
+		o.ctx = ctx
+	}
+}
+
+// WithTimeout applies a deadline to a single API request. A request that takes
+// longer than timeout fails and is not retried. A non-positive timeout means no
+// deadline.
+//
+// Timeouts are only enforced by APIClients that implement ContextAPIClient,
+// such as DefaultAPIClient.
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithRowLimit truncates the result of Query to at most n rows, by wrapping the
+// query in a subquery with LIMIT n, so that the server never sends more. The
+// query must be a single SELECT statement. It has no effect on other calls.
+func WithRowLimit(n int) CallOption {
+	return func(o *callOptions) {
+		o.rowLimit = n
+	}
+}
+
+// WithMaxRows fails Query with an error wrapping ErrResultTooLarge if the query
+// would return more than n rows, protecting against accidentally pulling a huge
+// table through the JSON API. The query is wrapped in a subquery with
+// LIMIT n+1, so the server never sends more than one row too many. The query
+// must be a single SELECT statement. It has no effect on other calls.
+func WithMaxRows(n int) CallOption {
+	return func(o *callOptions) {
+		o.maxRows = n
+	}
+}
+
+// limitQuery applies the row guardrails in o to queryString, returning the
+// query to send.
+func (o *callOptions) limitQuery(queryString string) string {
+	limit := o.rowLimit
+	if o.maxRows > 0 && (limit <= 0 || o.maxRows < limit) {
+		limit = o.maxRows + 1
+	}
+	if limit <= 0 {
+		return queryString
+	}
+	return fmt.Sprintf("%s LIMIT %d", subquery(queryString, "bitdotio_limit"), limit)
+}
+
+// withoutRowLimits disables WithRowLimit and WithMaxRows, for calls that bound
+// their results themselves, such as the pages of QueryPages.
+func withoutRowLimits(o *callOptions) {
+	o.rowLimit, o.maxRows = 0, 0
+}
+
+// subquery returns a query selecting all rows of queryString, a single SELECT
+// statement, as a subquery named alias. A trailing semicolon is removed, and
+// queryString is put on its own lines, so that a trailing -- comment does not
+// comment out the closing parenthesis.
+func subquery(queryString, alias string) string {
+	queryString = strings.TrimRight(strings.TrimSpace(queryString), "; \t\r\n")
+	return fmt.Sprintf("SELECT * FROM (\n%s\n) AS %s", queryString, alias)
+}
+
+// checkRows returns an error if a result of rows rows exceeds o's maximum.
+func (o *callOptions) checkRows(rows int) error {
+	if o.maxRows > 0 && rows > o.maxRows {
+		return fmt.Errorf("%w: more than %d rows", ErrResultTooLarge, o.maxRows)
+	}
+	return nil
+}
+
+// WithConfirm confirms a destructive call, such as DeleteDatabase, on the
+// resource named name, as required in protected mode, see
+// ClientConfig.ProtectedMode.
+func WithConfirm(name string) CallOption {
+	return func(o *callOptions) {
+		o.confirm = name
+	}
+}
+
+// confirm returns an error wrapping ErrNotConfirmed if c is in protected mode
+// and opts do not confirm a destructive call on the resource named name.
+func (c *Client) confirm(opts []CallOption, name string) error {
+	if !c.config.ProtectedMode {
+		return nil
+	}
+	if options := c.newCallOptions(opts); options.confirm != name {
+		return fmt.Errorf("%w: protected mode requires WithConfirm(%q)", ErrNotConfirmed, name)
+	}
+	return nil
+}
+
+// newCallOptions applies opts to the default options for Client c.
+func (c *Client) newCallOptions(opts []CallOption) *callOptions {
+	options := &callOptions{timeout: c.config.Timeout}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// ContextAPIClient is an APIClient that also supports requests bounded by a
+// context, used to apply per-call options like WithTimeout.
+type ContextAPIClient interface {
+	APIClient
+	CallContext(ctx context.Context, method, path string, body []byte) ([]byte, error)
+	CallMultipartContext(ctx context.Context, method, path string, fields map[string]io.Reader, files fileParts) ([]byte, error)
+}
+
+// decoderKey is the context key for the response decoder set by withDecoder.
+type decoderKey struct{}
+
+// withDecoder makes a request decode a successful response body with decode
+// while it is received, instead of reading it into memory, if the Client's
+// APIClient is a DefaultAPIClient. The body is then not returned. Otherwise,
+// decode is not called.
+func withDecoder(decode func(body io.Reader) error) CallOption {
+	return func(o *callOptions) {
+		o.decode = decode
+	}
+}
+
+// callWithOptions calls do with a context that applies options, falling back
+// to fallback if apiClient does not support contexts or options set no
+// context, deadline, response recorder, or decoder.
+func callWithOptions(apiClient APIClient, options *callOptions, fallback func() ([]byte, error), do func(ctx context.Context, apiClient ContextAPIClient) ([]byte, error)) ([]byte, error) {
+	ctxClient, ok := apiClient.(ContextAPIClient)
+	if !ok || (options.ctx == nil && options.timeout <= 0 && options.response == nil && options.decode == nil) {
+		return fallback()
+	}
+	ctx := options.context()
+	if options.response != nil {
+		ctx = context.WithValue(ctx, responseKey{}, options.response)
+	}
+	if options.decode != nil {
+		ctx = context.WithValue(ctx, decoderKey{}, options.decode)
+	}
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	return do(ctx, ctxClient)
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStopPages can be returned by a QueryPages callback to stop paging without
+// QueryPages returning an error.
+var ErrStopPages = errors.New("stop pages")
+
+// QueryPages executes a query using the HTTP API one page of at most pageSize
+// rows at a time, calling fn with each page in order, so that large results
+// can be processed without one enormous response. It stops after the first
+// page with fewer than pageSize rows, or when fn returns an error, which is
+// returned unless it is ErrStopPages.
+//
+// Pages are fetched by wrapping queryString, which must be a single SELECT
+// statement, in a subquery with LIMIT and OFFSET. queryString should have an
+// ORDER BY on a unique key, since otherwise Postgres does not guarantee that
+// pages are disjoint, and concurrent writes can shift rows between pages.
+// Pages are bounded by pageSize, so WithRowLimit and WithMaxRows do not apply.
+func (c *Client) QueryPages(fullDBName, queryString string, pageSize int, fn func(page *QueryResult) error, opts ...CallOption) error {
+	if pageSize <= 0 {
+		return fmt.Errorf("page size must be positive, got %d", pageSize)
+	}
+	opts = append(opts[:len(opts):len(opts)], withoutRowLimits)
+	for offset := 0; ; offset += pageSize {
+		pageQuery := fmt.Sprintf("%s LIMIT %d OFFSET %d", subquery(queryString, "bitdotio_page"), pageSize, offset)
+		page, err := c.Query(fullDBName, pageQuery, opts...)
+		if err != nil {
+			return fmt.Errorf("page at offset %d failed: %w", offset, err)
+		}
+		page.QueryString = queryString
+		if err = fn(page); err != nil {
+			if errors.Is(err, ErrStopPages) {
+				return nil
+			}
+			return err
+		}
+		if len(page.Data) < pageSize {
+			return nil
+		}
+	}
+}
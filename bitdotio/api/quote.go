@@ -0,0 +1,32 @@
+package api
+
+import "strings"
+
+// QuoteIdentifier quotes an identifier, such as a table name from untrusted
+// input, for use in SQL. Multiple parts are joined with dots, e.g.
+// QuoteIdentifier("public", "users") returns `"public"."users"`. Double quotes
+// in a part are escaped, and NUL characters, which Postgres does not allow, are
+// removed, as by pgx.Identifier.Sanitize.
+func QuoteIdentifier(parts ...string) string {
+	quoted := make([]string, len(parts))
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "\x00", "")
+		quoted[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, ".")
+}
+
+// QuoteLiteral quotes a string as a SQL string literal, for dynamic SQL where
+// query parameters cannot be used, such as DDL or HTTP queries. Single quotes
+// are escaped, and NUL characters are removed. As with Postgres's
+// quote_literal, a string containing backslashes is quoted as an escape string
+// (E'...') with doubled backslashes, so that it is safe whatever the setting of
+// standard_conforming_strings.
+func QuoteLiteral(s string) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	s = strings.ReplaceAll(s, "'", "''")
+	if strings.Contains(s, `\`) {
+		return `E'` + strings.ReplaceAll(s, `\`, `\\`) + `'`
+	}
+	return "'" + s + "'"
+}
@@ -0,0 +1,127 @@
+package api
+
+import "encoding/json"
+
+// Note for reviewers: the UnmarshalJSON methods live on the outer types rather
+// than on DatabaseID or TransferJob, since a method on an embedded struct would
+// be promoted and shadow decoding of the outer type's own fields.
+
+// UnmarshalJSON decodes a database, tolerating a numeric ID and string
+// storage sizes, and keeps its raw JSON in Raw.
+func (d *Database) UnmarshalJSON(data []byte) error {
+	type database Database
+	v := struct {
+		*database
+		ID                flexString `json:"id"`
+		StorageLimitBytes flexInt64  `json:"storage_limit_bytes"`
+		StorageUsageBytes flexInt64  `json:"storage_usage_bytes"`
+	}{database: (*database)(d)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	d.ID = string(v.ID)
+	d.StorageLimitBytes = int64(v.StorageLimitBytes)
+	d.StorageUsageBytes = int64(v.StorageUsageBytes)
+	d.Raw = copyRaw(data)
+	return nil
+}
+
+// UnmarshalJSON decodes usage, tolerating a string row count.
+func (u *Usage) UnmarshalJSON(data []byte) error {
+	type usage Usage
+	v := struct {
+		*usage
+		RowsQueried flexInt64 `json:"rows_queried"`
+	}{usage: (*usage)(u)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	u.RowsQueried = int64(v.RowsQueried)
+	return nil
+}
+
+// UnmarshalJSON decodes a service account, tolerating numeric IDs and string
+// token counts, and keeps its raw JSON in Raw.
+func (s *ServiceAccount) UnmarshalJSON(data []byte) error {
+	type serviceAccount ServiceAccount
+	v := struct {
+		*serviceAccount
+		ID               flexString      `json:"id"`
+		Databases        flexDatabaseIDs `json:"databases"`
+		TokenCount       flexInt64       `json:"token_count"`
+		ActiveTokenCount flexInt64       `json:"active_token_count"`
+	}{serviceAccount: (*serviceAccount)(s)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	s.ID = string(v.ID)
+	s.Databases = v.Databases
+	s.TokenCount = int64(v.TokenCount)
+	s.ActiveTokenCount = int64(v.ActiveTokenCount)
+	s.Raw = copyRaw(data)
+	return nil
+}
+
+// transferJobFields holds the TransferJob fields that tolerate either JSON
+// type.
+type transferJobFields struct {
+	ID      flexString `json:"id"`
+	Retries flexInt64  `json:"retries"`
+}
+
+func (f *transferJobFields) apply(job *TransferJob) {
+	job.ID = string(f.ID)
+	job.Retries = int64(f.Retries)
+}
+
+// UnmarshalJSON decodes an export job, tolerating a numeric ID and a string
+// retry count, and keeps its raw JSON in Raw.
+func (e *ExportJob) UnmarshalJSON(data []byte) error {
+	type exportJob ExportJob
+	v := struct {
+		*exportJob
+		transferJobFields
+	}{exportJob: (*exportJob)(e)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	v.apply(&e.TransferJob)
+	e.Raw = copyRaw(data)
+	return nil
+}
+
+// UnmarshalJSON decodes an import job, tolerating a numeric ID and a string
+// retry count, and keeps its raw JSON in Raw.
+func (i *ImportJob) UnmarshalJSON(data []byte) error {
+	type importJob ImportJob
+	v := struct {
+		*importJob
+		transferJobFields
+	}{importJob: (*importJob)(i)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	v.apply(&i.TransferJob)
+	i.Raw = copyRaw(data)
+	return nil
+}
+
+// RawField decodes the field name of an API object's raw JSON, such as
+// Database.Raw, into dest. It reports whether the field was present, so that
+// callers can read fields that the SDK does not model yet.
+func RawField(raw json.RawMessage, name string, dest interface{}) (bool, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false, err
+	}
+	value, ok := fields[name]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(value, dest)
+}
+
+// copyRaw copies data, which json.Unmarshaler implementations must not retain.
+func copyRaw(data []byte) json.RawMessage {
+	return append(json.RawMessage(nil), data...)
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// APIResponse describes the HTTP response to an API call, for callers that need
+// header-driven behavior such as pagination links, rate limits, or deprecation
+// notices. See WithResponse.
+type APIResponse struct {
+	// Status is the HTTP status code of the response.
+	Status int
+	// Header contains the response headers.
+	Header http.Header
+}
+
+// WithResponse records the HTTP status and headers of an API call's response
+// in resp, alongside the decoded value returned by the method, e.g.
+//
+//	var resp api.APIResponse
+//	db, err := client.GetDatabase(username, dbName, api.WithResponse(&resp))
+//
+// If the call is retried, resp describes the last attempt. resp is left
+// unchanged if no response was received, or if the Client's APIClient does not
+// implement ContextAPIClient.
+func WithResponse(resp *APIResponse) CallOption {
+	return func(o *callOptions) {
+		o.response = resp
+	}
+}
+
+// responseKey is the context key for the *APIResponse set by WithResponse.
+type responseKey struct{}
+
+// recordResponse records res in the *APIResponse carried by ctx, if any.
+func recordResponse(ctx context.Context, res *http.Response) {
+	resp, ok := ctx.Value(responseKey{}).(*APIResponse)
+	if !ok || resp == nil {
+		return
+	}
+	resp.Status = res.StatusCode
+	resp.Header = res.Header.Clone()
+}
@@ -0,0 +1,550 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// decodeQueryResult decodes the JSON of a query result in memory into r, as
+// for QueryResult.UnmarshalJSON. Query responses are decoded as they are
+// received by decodeQueryResultStream instead.
+//
+// Dashboard queries can return hundreds of thousands of cells, and decoding
+// them with json.Unmarshal into [][]interface{} grows every row slice by
+// reflection and boxes every cell separately. Instead, the data array is
+// scanned directly: rows and the outer slice are allocated at their final
+// size, counted in a pass over the array, and a string or number cell
+// equal to the cell above it reuses that cell's boxed value, so the repeated
+// values common in grouped results are neither copied nor boxed again. Cells
+// are immutable strings, float64s, bools, and nils, except JSON objects and
+// arrays, which are decoded separately for each cell, so sharing values
+// between rows is safe.
+//
+// The input is validated with json.Valid before scanning, so the scanner only
+// has to handle well-formed JSON. Other fields are decoded with encoding/json,
+// and cell values have the same types as with json.Unmarshal. Fields are
+// decoded after the whole object is scanned, so the expected row width is
+// known from the metadata wherever it appears.
+func decodeQueryResult(data []byte, r *QueryResult) error {
+	fields, err := scanQueryResult(data)
+	if err != nil || fields == nil {
+		return err
+	}
+	if raw, ok := fields["query_string"]; ok {
+		if err = json.Unmarshal(raw, &r.QueryString); err != nil {
+			return err
+		}
+	}
+	if raw, ok := fields["metadata"]; ok {
+		if r.Columns, err = decodeMetadata(json.NewDecoder(bytes.NewReader(raw)), r); err != nil {
+			return err
+		}
+	}
+	// Columns named explicitly, e.g. when a result marshaled by the SDK is
+	// decoded, take precedence over the order of the metadata, which is only
+	// the column order in API responses.
+	if raw, ok := fields["columns"]; ok {
+		r.Columns = nil
+		if err = json.Unmarshal(raw, &r.Columns); err != nil {
+			return err
+		}
+	}
+	if raw, ok := fields["data"]; ok {
+		s := &resultScanner{data: raw}
+		return s.rows(r, len(r.Columns))
+	}
+	return nil
+}
+
+// decodeQueryResultStream decodes the JSON of a query result from body into r
+// as it is read, with the token stream of a json.Decoder, so that a large
+// response is never held in memory as well as its decoded rows. As with
+// decodeQueryResult, rows are allocated at the width of the metadata, which
+// the API sends before the data, and a string or number cell equal to the
+// cell above it reuses that cell's boxed value, so that the repeated values
+// of grouped results are not retained separately. Cell values have the same
+// types as with json.Unmarshal.
+func decodeQueryResultStream(body io.Reader, r *QueryResult) error {
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err != nil || token == nil {
+		return err
+	}
+	if token != json.Delim('{') {
+		return fmt.Errorf("query result is not an object")
+	}
+	explicitColumns := false
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		key := token.(string)
+		switch {
+		case strings.EqualFold(key, "query_string"):
+			err = decoder.Decode(&r.QueryString)
+		case strings.EqualFold(key, "metadata"):
+			var columns []string
+			if columns, err = decodeMetadata(decoder, r); !explicitColumns {
+				r.Columns = columns
+			}
+		case strings.EqualFold(key, "columns"):
+			// See decodeQueryResult.
+			r.Columns, explicitColumns = nil, true
+			err = decoder.Decode(&r.Columns)
+		case strings.EqualFold(key, "data"):
+			err = decodeRows(decoder, r)
+		default:
+			err = decoder.Decode(new(json.RawMessage))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// decodeRows decodes the data array of a query result from decoder into
+// r.Data.
+func decodeRows(decoder *json.Decoder, r *QueryResult) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		r.Data = nil
+		return nil
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("query result data is not an array")
+	}
+	r.Data = [][]interface{}{}
+	width := len(r.Columns)
+	var above []interface{}
+	for i := 0; decoder.More(); i++ {
+		row, err := decodeRow(decoder, width, above)
+		if err != nil {
+			return fmt.Errorf("query result data row %d: %w", i, err)
+		}
+		r.Data = append(r.Data, row)
+		if row != nil {
+			above, width = row, len(row)
+		}
+	}
+	_, err = decoder.Token()
+	return err
+}
+
+// decodeRow decodes a row of a query result from decoder, reusing the boxed
+// values of the row above for equal cells.
+func decodeRow(decoder *json.Decoder, width int, above []interface{}) ([]interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil || token == nil {
+		return nil, err
+	}
+	if token != json.Delim('[') {
+		return nil, fmt.Errorf("row is not an array")
+	}
+	row := make([]interface{}, 0, width)
+	for j := 0; decoder.More(); j++ {
+		if token, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		switch value := token.(type) {
+		case json.Delim:
+			if token, err = decodeComposite(decoder, value); err != nil {
+				return nil, err
+			}
+		case string:
+			if p, ok := cellAbove(above, j).(string); ok && p == value {
+				token = above[j]
+			}
+		case float64:
+			if p, ok := cellAbove(above, j).(float64); ok && math.Float64bits(p) == math.Float64bits(value) {
+				token = above[j]
+			}
+		}
+		row = append(row, token)
+	}
+	_, err = decoder.Token()
+	return row, err
+}
+
+// cellAbove returns the cell of above in column j, or nil if there is none.
+func cellAbove(above []interface{}, j int) interface{} {
+	if j < len(above) {
+		return above[j]
+	}
+	return nil
+}
+
+// decodeComposite decodes the JSON object or array opened by delim from
+// decoder's tokens into a map[string]interface{} or []interface{}.
+func decodeComposite(decoder *json.Decoder, delim json.Delim) (interface{}, error) {
+	decodeValue := func() (interface{}, error) {
+		token, err := decoder.Token()
+		if delim, ok := token.(json.Delim); ok && err == nil {
+			return decodeComposite(decoder, delim)
+		}
+		return token, err
+	}
+	var value interface{}
+	if delim == '{' {
+		object := make(map[string]interface{})
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			if object[key.(string)], err = decodeValue(); err != nil {
+				return nil, err
+			}
+		}
+		value = object
+	} else {
+		array := []interface{}{}
+		for decoder.More() {
+			element, err := decodeValue()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		value = array
+	}
+	_, err := decoder.Token()
+	return value, err
+}
+
+// scanQueryResult validates the JSON of a query result and returns the raw
+// values of its fields, keyed by their JSON names in QueryResult and matched
+// case-insensitively, as with json.Unmarshal. It returns nil fields for null.
+func scanQueryResult(data []byte) (map[string][]byte, error) {
+	if !json.Valid(data) {
+		// Unmarshal to report the syntax error.
+		return nil, json.Unmarshal(data, new(json.RawMessage))
+	}
+	s := &resultScanner{data: data}
+	s.skipSpace()
+	switch s.data[s.pos] {
+	case 'n':
+		return nil, nil
+	case '{':
+	default:
+		// Unmarshal to report the type error.
+		type queryResult QueryResult
+		return nil, json.Unmarshal(data, new(queryResult))
+	}
+	s.pos++
+	fields := make(map[string][]byte)
+	for {
+		s.skipSpace()
+		if s.data[s.pos] == '}' {
+			return fields, nil
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			s.skipSpace()
+		}
+		start := s.pos
+		s.skip()
+		var key string
+		if err := json.Unmarshal(s.data[start:s.pos], &key); err != nil {
+			return nil, err
+		}
+		s.skipSpace()
+		s.pos++ // ':'
+		s.skipSpace()
+		start = s.pos
+		s.skip()
+		for _, name := range []string{"query_string", "metadata", "columns", "data"} {
+			if strings.EqualFold(key, name) {
+				fields[name] = s.data[start:s.pos]
+			}
+		}
+	}
+}
+
+// decodeMetadata decodes the metadata object of a query result from decoder
+// into r.Metadata in a single pass, returning its keys in order. Null metadata
+// has no columns.
+func decodeMetadata(decoder *json.Decoder, r *QueryResult) ([]string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		r.Metadata = nil
+		return nil, nil
+	}
+	if token != json.Delim('{') {
+		return nil, fmt.Errorf("query result metadata is not an object")
+	}
+	r.Metadata = make(map[string]string)
+	var columns []string
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var typeName string
+		if err = decoder.Decode(&typeName); err != nil {
+			return nil, err
+		}
+		r.Metadata[key.(string)] = typeName
+		columns = append(columns, key.(string))
+	}
+	_, err = decoder.Token()
+	return columns, err
+}
+
+// resultScanner scans validated JSON.
+type resultScanner struct {
+	data []byte
+	pos  int
+}
+
+// rows decodes the data array of a query result into r.Data. width is the
+// expected row width, or 0 if unknown.
+func (s *resultScanner) rows(r *QueryResult, width int) error {
+	if s.data[s.pos] == 'n' {
+		s.pos += len("null")
+		r.Data = nil
+		return nil
+	}
+	if s.data[s.pos] != '[' {
+		return fmt.Errorf("query result data is not an array")
+	}
+	r.Data = make([][]interface{}, 0, s.count())
+	s.pos++
+	var above []interface{}
+	for i := 0; ; i++ {
+		s.skipSpace()
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return nil
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			s.skipSpace()
+		}
+		row, err := s.row(width, above)
+		if err != nil {
+			return fmt.Errorf("query result data row %d: %w", i, err)
+		}
+		r.Data = append(r.Data, row)
+		if row != nil {
+			above, width = row, len(row)
+		}
+	}
+}
+
+// row decodes a row of a query result, reusing the boxed values of the row
+// above for equal cells.
+func (s *resultScanner) row(width int, above []interface{}) ([]interface{}, error) {
+	if s.data[s.pos] == 'n' {
+		s.pos += len("null")
+		return nil, nil
+	}
+	if s.data[s.pos] != '[' {
+		return nil, fmt.Errorf("row is not an array")
+	}
+	s.pos++
+	row := make([]interface{}, 0, width)
+	for j := 0; ; j++ {
+		s.skipSpace()
+		if s.data[s.pos] == ']' {
+			s.pos++
+			return row, nil
+		}
+		if s.data[s.pos] == ',' {
+			s.pos++
+			s.skipSpace()
+		}
+		var prev interface{}
+		if j < len(above) {
+			prev = above[j]
+		}
+		cell, err := s.cell(prev)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+	}
+}
+
+// cell decodes a cell value, returning prev instead if it is an equal string
+// or number.
+func (s *resultScanner) cell(prev interface{}) (interface{}, error) {
+	start := s.pos
+	switch c := s.data[s.pos]; {
+	case c == '"':
+		plain := s.skipString()
+		value := s.data[start+1 : s.pos-1]
+		if !plain {
+			var str string
+			if err := json.Unmarshal(s.data[start:s.pos], &str); err != nil {
+				return nil, err
+			}
+			return str, nil
+		}
+		if p, ok := prev.(string); ok && p == string(value) {
+			return prev, nil
+		}
+		return string(value), nil
+	case c == 'n':
+		s.pos += len("null")
+		return nil, nil
+	case c == 't':
+		s.pos += len("true")
+		return true, nil
+	case c == 'f':
+		s.pos += len("false")
+		return false, nil
+	case c == '{' || c == '[':
+		s.skip()
+		var value interface{}
+		if err := json.Unmarshal(s.data[start:s.pos], &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	default:
+		s.skip()
+		f, err := strconv.ParseFloat(string(s.data[start:s.pos]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s: %w", s.data[start:s.pos], err)
+		}
+		if p, ok := prev.(float64); ok && math.Float64bits(p) == math.Float64bits(f) {
+			return prev, nil
+		}
+		return f, nil
+	}
+}
+
+// scalar advances past the value at the current position and returns its
+// text: the decoded value of a string, for which quoted is true, and otherwise
+// the JSON itself.
+func (s *resultScanner) scalar() (text []byte, quoted bool, err error) {
+	start := s.pos
+	if s.data[s.pos] != '"' {
+		s.skip()
+		return s.data[start:s.pos], false, nil
+	}
+	if s.skipString() {
+		return s.data[start+1 : s.pos-1], true, nil
+	}
+	var str string
+	if err := json.Unmarshal(s.data[start:s.pos], &str); err != nil {
+		return nil, false, err
+	}
+	return []byte(str), true, nil
+}
+
+// count returns the number of elements of the array at the current position,
+// without advancing.
+func (s *resultScanner) count() int {
+	start := s.pos
+	defer func() { s.pos = start }()
+	n, depth, empty := 1, 0, true
+	for {
+		c := s.data[s.pos]
+		if depth == 1 && c > ' ' && c != ']' {
+			empty = false
+		}
+		switch c {
+		case '"':
+			s.skipString()
+			continue
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				if empty {
+					return 0
+				}
+				return n
+			}
+		case ',':
+			if depth == 1 {
+				n++
+			}
+		}
+		s.pos++
+	}
+}
+
+// skipSpace advances past JSON whitespace.
+func (s *resultScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\r', '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// skip advances past the value at the current position.
+func (s *resultScanner) skip() {
+	switch s.data[s.pos] {
+	case '"':
+		s.skipString()
+	case '{', '[':
+		depth := 0
+		for {
+			switch s.data[s.pos] {
+			case '"':
+				s.skipString()
+				continue
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+			s.pos++
+			if depth == 0 {
+				return
+			}
+		}
+	default:
+		for s.pos < len(s.data) {
+			switch s.data[s.pos] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return
+			}
+			s.pos++
+		}
+	}
+}
+
+// skipString advances past the string at the current position, reporting
+// whether it is plain, i.e. has no escapes or invalid UTF-8, so that its bytes
+// are its value.
+func (s *resultScanner) skipString() bool {
+	start := s.pos
+	s.pos++
+	plain, ascii := true, true
+	for {
+		switch c := s.data[s.pos]; {
+		case c == '"':
+			s.pos++
+			return plain && (ascii || utf8.Valid(s.data[start+1:s.pos-1]))
+		case c == '\\':
+			plain = false
+			s.pos += 2
+			continue
+		case c >= utf8.RuneSelf:
+			ascii = false
+		}
+		s.pos++
+	}
+}
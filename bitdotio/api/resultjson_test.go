@@ -0,0 +1,152 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testQueryResultJSON is a query result with cells of every JSON type,
+// repeated values, a null row, and fields in an unusual case and order.
+const testQueryResultJSON = `{
+	"Query_String": "SELECT 1",
+	"metadata": {"id": "integer", "name": "text", "score": "double precision", "ok": "boolean", "doc": "jsonb"},
+	"extra": {"ignored": [1, 2]},
+	"data": [
+		[1, "a", 1.5, true, {"k": [1, "x", null]}],
+		[2, "a", 1.5, false, []],
+		null,
+		[3, "café \"b\"", -2e3, null, "s"]
+	]
+}`
+
+func TestDecodeQueryResultStream(t *testing.T) {
+	var want QueryResult
+	if err := decodeQueryResult([]byte(testQueryResultJSON), &want); err != nil {
+		t.Fatal(err)
+	}
+	var got QueryResult
+	if err := decodeQueryResultStream(strings.NewReader(testQueryResultJSON), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeQueryResultStream = %#v, want %#v", got, want)
+	}
+	if want := []string{"id", "name", "score", "ok", "doc"}; !reflect.DeepEqual(got.Columns, want) {
+		t.Errorf("Columns = %v, want %v", got.Columns, want)
+	}
+
+	var plain struct {
+		Data [][]interface{}
+	}
+	if err := json.Unmarshal([]byte(testQueryResultJSON), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.Data, plain.Data) {
+		t.Errorf("Data = %#v, want %#v as with json.Unmarshal", got.Data, plain.Data)
+	}
+}
+
+func TestDecodeQueryResultStreamErrors(t *testing.T) {
+	for _, body := range []string{
+		`[]`,
+		`{"data": {}}`,
+		`{"data": [[1, 2]`,
+		`{"metadata": [], "data": []}`,
+		`{"data": [1]}`,
+	} {
+		var r QueryResult
+		if err := decodeQueryResultStream(strings.NewReader(body), &r); err == nil {
+			t.Errorf("decodeQueryResultStream(%s) succeeded, want an error", body)
+		}
+	}
+}
+
+func TestQueryDecodesResponseStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// An unbuffered body shows that the response is not read up front.
+		w.(http.Flusher).Flush()
+		io.WriteString(w, testQueryResultJSON)
+	}))
+	defer server.Close()
+	c := NewClientWithConfig("token", &ClientConfig{BaseURL: server.URL})
+	result, err := c.Query("username/db", "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 4 || result.Data[3][1] != "café \"b\"" {
+		t.Errorf("Data = %v", result.Data)
+	}
+}
+
+// largeQueryResultJSON returns a query result of rows rows of mixed types,
+// with the repeated values of a grouped dashboard query.
+func largeQueryResultJSON(rows int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"query_string": "SELECT ...", "metadata": {"day": "date", "region": "text", "product": "text", "orders": "bigint", "revenue": "numeric", "active": "boolean", "note": "text", "attrs": "jsonb"}, "data": [`)
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `["2023-01-%02d", "region-%d", "product-%d", %d, %d.25, %t, null, {"rank": %d}]`,
+			i/1000%28+1, i/100%10, i%50, i%7, i%1000, i%2 == 0, i%3)
+	}
+	b.WriteString(`]}`)
+	return b.Bytes()
+}
+
+// BenchmarkQueryDecodeBuffered measures the previous decoding of query
+// responses: reading the body into memory and then scanning it.
+func BenchmarkQueryDecodeBuffered(b *testing.B) {
+	data := largeQueryResultJSON(100000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		body, err := io.ReadAll(bytes.NewReader(data))
+		if err != nil {
+			b.Fatal(err)
+		}
+		var r QueryResult
+		if err = decodeQueryResult(body, &r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkQueryDecodeStream measures decoding query responses as they are
+// received, as Query does.
+func BenchmarkQueryDecodeStream(b *testing.B) {
+	data := largeQueryResultJSON(100000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var r QueryResult
+		if err := decodeQueryResultStream(bytes.NewReader(data), &r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkQueryDecodeUnmarshal measures the naive decoding with
+// json.Unmarshal into [][]interface{}, for comparison.
+func BenchmarkQueryDecodeUnmarshal(b *testing.B) {
+	data := largeQueryResultJSON(100000)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var r struct {
+			QueryString string            `json:"query_string"`
+			Metadata    map[string]string `json:"metadata"`
+			Data        [][]interface{}   `json:"data"`
+		}
+		if err := json.Unmarshal(data, &r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
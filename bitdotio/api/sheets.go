@@ -0,0 +1,68 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+// GoogleSheet selects the data of a Google Sheets spreadsheet to import, see
+// ImportJobConfig.GoogleSheet. The spreadsheet must be shared so that anyone
+// with the link can view it.
+type GoogleSheet struct {
+	// URL is the URL of the spreadsheet, as copied from the browser or the
+	// share dialog, e.g. https://docs.google.com/spreadsheets/d/<id>/edit#gid=0.
+	URL string
+	// Worksheet is the name of the worksheet (tab) to import. Defaults to the
+	// worksheet selected in URL by its gid, or else the first worksheet.
+	Worksheet string
+	// Range is a range of cells in A1 notation to import, e.g. "A1:D100" or
+	// "B:E". Defaults to all cells with data.
+	Range string
+}
+
+var (
+	// sheetIDPattern matches the spreadsheet ID in a Google Sheets URL path.
+	sheetIDPattern = regexp.MustCompile(`^/spreadsheets/d/([A-Za-z0-9_-]+)`)
+	// sheetGIDPattern matches a worksheet gid in a URL fragment.
+	sheetGIDPattern = regexp.MustCompile(`(?:^|&)gid=([0-9]+)`)
+	// a1RangePattern matches a range of cells in A1 notation.
+	a1RangePattern = regexp.MustCompile(`^[A-Za-z]{0,3}[0-9]*(:[A-Za-z]{0,3}[0-9]*)?$`)
+)
+
+// ExportURL returns the URL of the selected worksheet and range exported as
+// CSV, which bit.io downloads as the file_url of an import job.
+func (g *GoogleSheet) ExportURL() (string, error) {
+	u, err := url.Parse(g.URL)
+	if err != nil {
+		return "", fmt.Errorf("invalid Google Sheets URL: %v", err)
+	}
+	match := sheetIDPattern.FindStringSubmatch(u.Path)
+	if u.Host != "docs.google.com" || match == nil {
+		return "", fmt.Errorf("%q is not a Google Sheets spreadsheet URL", g.URL)
+	}
+	if g.Range != "" && !a1RangePattern.MatchString(g.Range) {
+		return "", fmt.Errorf("invalid range %q, expected A1 notation such as A1:D100; select the worksheet with Worksheet", g.Range)
+	}
+
+	base := "https://docs.google.com/spreadsheets/d/" + match[1]
+	query := url.Values{}
+	if g.Range != "" {
+		query.Set("range", g.Range)
+	}
+	if g.Worksheet != "" {
+		// Only the visualization endpoint selects worksheets by name.
+		query.Set("tqx", "out:csv")
+		query.Set("sheet", g.Worksheet)
+		return base + "/gviz/tq?" + query.Encode(), nil
+	}
+	query.Set("format", "csv")
+	gid := u.Query().Get("gid")
+	if m := sheetGIDPattern.FindStringSubmatch(u.Fragment); m != nil {
+		gid = m[1]
+	}
+	if gid != "" {
+		query.Set("gid", gid)
+	}
+	return base + "/export?" + query.Encode(), nil
+}
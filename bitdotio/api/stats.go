@@ -0,0 +1,238 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// CallStats describes a single completed API request.
+type CallStats struct {
+	Method string
+	Path   string
+	// Status is the HTTP status code of an error response, or 0 if the request
+	// succeeded or no response was received.
+	Status  int
+	Latency time.Duration
+	// Retries is the number of earlier attempts of the same request.
+	Retries int
+	Err     error
+}
+
+// StatusClass returns "2xx" for a successful request, "4xx" or "5xx" for an
+// error response, and "error" if no response was received.
+func (s *CallStats) StatusClass() string {
+	switch {
+	case s.Err == nil:
+		return "2xx"
+	case s.Status >= 500:
+		return "5xx"
+	case s.Status >= 400:
+		return "4xx"
+	default:
+		return "error"
+	}
+}
+
+// StatsHook receives stats for every API request made by a Client. Hooks are
+// called synchronously after each request completes, so they should return
+// quickly.
+type StatsHook func(stats *CallStats)
+
+// SetStatsHook sets a hook that is called after every API request, so that
+// request counts, latencies, status classes, and retries can be fed to a
+// metrics system. A nil hook disables reporting.
+func (c *Client) SetStatsHook(hook StatsHook) {
+	if hook == nil {
+		c.statsHook.Store(nil)
+		return
+	}
+	c.statsHook.Store(&hook)
+}
+
+// call executes a request with apiClient, retrying under the Client's
+// RetryPolicy, reports each attempt to the stats hook, and reports the outcome
+// to the audit hook.
+func (c *Client) call(apiClient APIClient, method, path string, body []byte, opts ...CallOption) ([]byte, error) {
+	data, err := c.retry(apiClient, method, path, body, opts...)
+	c.audit(method, path, func() string { return summarizeBody(body) }, err)
+	return data, err
+}
+
+// retry executes a request with apiClient, retrying under the Client's
+// RetryPolicy, and reports each attempt to the stats hook.
+func (c *Client) retry(apiClient APIClient, method, path string, body []byte, opts ...CallOption) ([]byte, error) {
+	data, err := c.attempt(apiClient, 0, method, path, body, opts...)
+	policy := c.config.RetryPolicy
+	if policy == nil {
+		return data, err
+	}
+	ctx := c.newCallOptions(opts).context()
+	for retry := 1; err != nil && retry <= policy.MaxRetries && shouldRetry(ctx, policy, method, err); retry++ {
+		backoff := policy.backoff(retry)
+		c.logfCtx(ctx, "bitdotio: retrying %s %s in %s after error: %v", method, path, backoff, err)
+		c.config.Events.Publish(&Event{Type: EventRequestRetried, Method: method, Path: path, Err: err})
+		if !sleepContext(ctx, backoff) {
+			break
+		}
+		data, err = c.attempt(apiClient, retry, method, path, body, opts...)
+	}
+	return data, err
+}
+
+// shouldRetry reports whether a request that failed with err may be retried
+// under policy: ctx is live, the attempt did not exceed its own deadline, see
+// WithTimeout, and policy considers err retryable.
+func shouldRetry(ctx context.Context, policy *RetryPolicy, method string, err error) bool {
+	return ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) && policy.retryable(method, err)
+}
+
+// sleepContext waits for d or until ctx is done, and reports whether the full
+// delay elapsed.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// attempt executes a single attempt of a request with apiClient, which has
+// already been attempted retries times, and reports it to the stats hook.
+func (c *Client) attempt(apiClient APIClient, retries int, method, path string, body []byte, opts ...CallOption) ([]byte, error) {
+	return c.observe(method, path, retries, func() ([]byte, error) {
+		return c.recordLast(method, path, func() string { return summarizeBody(body) }, opts, func(options *callOptions) ([]byte, error) {
+			return callWithOptions(apiClient, options, func() ([]byte, error) {
+				return apiClient.Call(method, path, body)
+			}, func(ctx context.Context, apiClient ContextAPIClient) ([]byte, error) {
+				return apiClient.CallContext(ctx, method, path, body)
+			})
+		})
+	})
+}
+
+// callMultipart executes a multipart request with apiClient and reports it to
+// the stats and audit hooks. The request is retried under the Client's
+// RetryPolicy only if all of its parts can be rewound.
+func (c *Client) callMultipart(apiClient APIClient, method, path string, fields map[string]io.Reader, files fileParts, opts ...CallOption) ([]byte, error) {
+	attempt := func(retries int) ([]byte, error) {
+		return c.observe(method, path, retries, func() ([]byte, error) {
+			return c.recordLast(method, path, func() string { return summarizeMultipart(fields, files) }, opts, func(options *callOptions) ([]byte, error) {
+				return callWithOptions(apiClient, options, func() ([]byte, error) {
+					return apiClient.CallMultipart(method, path, fields, files)
+				}, func(ctx context.Context, apiClient ContextAPIClient) ([]byte, error) {
+					return apiClient.CallMultipartContext(ctx, method, path, fields, files)
+				})
+			})
+		})
+	}
+	rewind, rewindable := multipartRewinder(fields, files)
+	data, err := attempt(0)
+	if policy := c.config.RetryPolicy; policy != nil && rewindable {
+		ctx := c.newCallOptions(opts).context()
+		for retry := 1; err != nil && retry <= policy.MaxRetries && shouldRetry(ctx, policy, method, err); retry++ {
+			backoff := policy.backoff(retry)
+			c.logfCtx(ctx, "bitdotio: retrying %s %s in %s after error: %v", method, path, backoff, err)
+			c.config.Events.Publish(&Event{Type: EventRequestRetried, Method: method, Path: path, Err: err})
+			if !sleepContext(ctx, backoff) {
+				break
+			}
+			if rewindErr := rewind(); rewindErr != nil {
+				c.logfCtx(ctx, "bitdotio: unable to rewind %s %s for retry: %v", method, path, rewindErr)
+				break
+			}
+			data, err = attempt(retry)
+		}
+	}
+	c.audit(method, path, func() string { return summarizeMultipart(fields, files) }, err)
+	return data, err
+}
+
+// observe calls do and reports the outcome to the stats hook, if any.
+// Errors returned by do are annotated with the request's method, path, and
+// retry count.
+func (c *Client) observe(method, path string, retries int, do func() ([]byte, error)) ([]byte, error) {
+	hook := c.statsHook.Load()
+	if hook == nil {
+		data, err := do()
+		c.annotateError(err, method, path, retries)
+		return data, err
+	}
+	start := time.Now()
+	data, err := do()
+	c.annotateError(err, method, path, retries)
+	stats := &CallStats{
+		Method:  method,
+		Path:    path,
+		Latency: time.Since(start),
+		Retries: retries,
+		Err:     err,
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		stats.Status = apiErr.Status
+	}
+	(*hook)(stats)
+	return data, err
+}
+
+// annotateError records the request that produced err, if it is an *APIError,
+// and publishes an EventRateLimited event if the request was rate limited.
+func (c *Client) annotateError(err error, method, path string, retries int) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return
+	}
+	apiErr.Method = method
+	apiErr.Path = path
+	apiErr.Retries = retries
+	if apiErr.Status == http.StatusTooManyRequests {
+		c.config.Events.Publish(&Event{Type: EventRateLimited, Method: method, Path: path, Err: err})
+	}
+}
+
+// multipartRewinder returns a function that seeks every part of a multipart
+// request back to its current offset, and reports whether every part is an
+// io.Seeker that supports this.
+func multipartRewinder(fields map[string]io.Reader, files fileParts) (func() error, bool) {
+	type mark struct {
+		seeker io.Seeker
+		offset int64
+	}
+	var marks []mark
+	add := func(r io.Reader) bool {
+		seeker, ok := r.(io.Seeker)
+		if !ok {
+			return false
+		}
+		offset, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return false
+		}
+		marks = append(marks, mark{seeker, offset})
+		return true
+	}
+	for _, r := range fields {
+		if !add(r) {
+			return nil, false
+		}
+	}
+	for _, formFile := range files {
+		if !add(formFile.file) {
+			return nil, false
+		}
+	}
+	return func() error {
+		for _, m := range marks {
+			if _, err := m.seeker.Seek(m.offset, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, true
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeMode controls how date and timestamp values in query results are
+// represented, so that results are consistent between the HTTP API, which
+// returns timestamps as strings in the session time zone, and pgx, which
+// returns time.Time values in the local time zone.
+type TimeMode string
+
+const (
+	// TimeModeDefault leaves values as returned by each transport.
+	TimeModeDefault TimeMode = ""
+	// TimeModeUTC returns time.Time values in UTC.
+	TimeModeUTC TimeMode = "utc"
+	// TimeModeSession returns time.Time values in the session time zone of the
+	// database connection, as shown by psql.
+	TimeModeSession TimeMode = "session"
+	// TimeModeString returns strings: dates as 2006-01-02, timestamps without
+	// time zone as 2006-01-02T15:04:05.999999999, and timestamps with time
+	// zone in RFC 3339 format in UTC.
+	TimeModeString TimeMode = "string"
+)
+
+// Validate returns an error if m is not a supported time mode.
+func (m TimeMode) Validate() error {
+	switch m {
+	case TimeModeDefault, TimeModeUTC, TimeModeSession, TimeModeString:
+		return nil
+	}
+	return fmt.Errorf("TimeMode options are %q, %q, or %q, got %q", TimeModeUTC, TimeModeSession, TimeModeString, m)
+}
+
+// ConvertTimes converts the date and timestamp cells of r in place according
+// to mode. Cells may be strings, as from the HTTP API, or time.Time values, as
+// from pgx. session is the session time zone for TimeModeSession; time.Time
+// values keep their zone if it is nil, as do strings, which already carry the
+// session offset. It requires r.Columns to name the columns of r.Data in
+// order.
+func (r *QueryResult) ConvertTimes(mode TimeMode, session *time.Location) error {
+	if err := mode.Validate(); err != nil {
+		return err
+	}
+	if mode == TimeModeDefault {
+		return nil
+	}
+	for j, column := range r.Columns {
+		typeName := normalizeTypeName(r.Metadata[column])
+		if !isTimeType(typeName) {
+			continue
+		}
+		for i, row := range r.Data {
+			if len(row) != len(r.Columns) {
+				return fmt.Errorf("row %d has %d values, but result has %d columns", i, len(row), len(r.Columns))
+			}
+			value, err := convertTime(typeName, row[j], mode, session)
+			if err != nil {
+				return fmt.Errorf("unable to convert row %d column %s: %w", i, column, err)
+			}
+			row[j] = value
+		}
+	}
+	return nil
+}
+
+// isTimeType reports whether a normalized type name is a date or timestamp
+// type.
+func isTimeType(typeName string) bool {
+	switch typeName {
+	case "date", "timestamp", "timestamptz", "timestamp without time zone", "timestamp with time zone":
+		return true
+	}
+	return false
+}
+
+// convertTime converts a date or timestamp cell of type typeName according to
+// mode.
+func convertTime(typeName string, v interface{}, mode TimeMode, session *time.Location) (interface{}, error) {
+	var t time.Time
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		t = v
+	case string:
+		parsed, err := parseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		t = parsed
+	default:
+		// e.g. pgtype.InfinityModifier for infinite timestamps.
+		return v, nil
+	}
+	withZone := typeName == "timestamptz" || typeName == "timestamp with time zone"
+	switch mode {
+	case TimeModeUTC:
+		return t.UTC(), nil
+	case TimeModeSession:
+		if withZone && session != nil {
+			return t.In(session), nil
+		}
+		return t, nil
+	}
+	switch {
+	case typeName == "date":
+		return t.Format("2006-01-02"), nil
+	case withZone:
+		return t.UTC().Format(time.RFC3339Nano), nil
+	default:
+		return t.Format("2006-01-02T15:04:05.999999999"), nil
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Note for reviewers: the v2beta API has sent some IDs as numbers and some
+// counts as strings. The types below accept either form, and are substituted
+// for the affected fields by the UnmarshalJSON methods in raw.go, so that such
+// server-side changes do not break decoding.
+
+// flexString decodes a JSON string or number as a string, e.g. for IDs.
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '"' {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*s = flexString(n)
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = flexString(v)
+	return nil
+}
+
+// flexInt64 decodes a JSON number or numeric string as an int64, e.g. for
+// counts. Integral floats, such as 12.0, are accepted.
+type flexInt64 int64
+
+func (i *flexInt64) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			return nil
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		*i = flexInt64(n)
+		return nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+		return fmt.Errorf("cannot decode %s as an integer", data)
+	}
+	*i = flexInt64(f)
+	return nil
+}
+
+// flexDatabaseIDs decodes a list of DatabaseIDs whose IDs may be numbers.
+type flexDatabaseIDs []*DatabaseID
+
+func (ids *flexDatabaseIDs) UnmarshalJSON(data []byte) error {
+	var values []*struct {
+		ID   flexString `json:"id"`
+		Name string     `json:"name"`
+	}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if values == nil {
+		*ids = nil
+		return nil
+	}
+	*ids = make(flexDatabaseIDs, len(values))
+	for i, v := range values {
+		if v != nil {
+			(*ids)[i] = &DatabaseID{ID: string(v.ID), Name: v.Name}
+		}
+	}
+	return nil
+}
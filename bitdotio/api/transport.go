@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the HTTP transport of API requests, e.g. so that
+// applications submitting many jobs concurrently are not limited by the two
+// idle connections per host that net/http keeps by default. Zero values keep
+// the defaults of http.DefaultTransport.
+type TransportConfig struct {
+	// MaxIdleConns limits idle connections across all hosts. Defaults to 100.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits idle connections kept for reuse per host.
+	// Defaults to 2, so concurrent requests beyond 2 open new connections.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits connections per host, in any state; requests
+	// beyond the limit wait for a connection. Defaults to no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept. Defaults to 90s.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives uses a new connection for every request.
+	DisableKeepAlives bool
+	// KeepAlive is the interval of TCP keep-alive probes. Defaults to 30s; a
+	// negative interval disables them.
+	KeepAlive time.Duration
+	// DialTimeout limits establishing a TCP connection. Defaults to 30s.
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout limits the TLS handshake. Defaults to 10s.
+	TLSHandshakeTimeout time.Duration
+	// DisableHTTP2 uses HTTP/1.1 only. By default, HTTP/2 is negotiated when
+	// the server supports it, multiplexing requests over one connection.
+	DisableHTTP2 bool
+}
+
+// NewTransport returns an http.Transport with the settings of t, based on a
+// clone of http.DefaultTransport, so that it keeps its proxy settings.
+func (t *TransportConfig) NewTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if t.MaxIdleConns > 0 {
+		transport.MaxIdleConns = t.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	if t.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = t.MaxConnsPerHost
+	}
+	if t.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = t.IdleConnTimeout
+	}
+	transport.DisableKeepAlives = t.DisableKeepAlives
+	if t.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = t.TLSHandshakeTimeout
+	}
+	if t.KeepAlive != 0 || t.DialTimeout > 0 {
+		dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+		if t.KeepAlive != 0 {
+			dialer.KeepAlive = t.KeepAlive
+		}
+		if t.DialTimeout > 0 {
+			dialer.Timeout = t.DialTimeout
+		}
+		transport.DialContext = dialer.DialContext
+	}
+	if t.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto disables HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	return transport
+}
+
+// newHTTPClient returns the HTTP client for config: its HTTPClient, if set,
+// or else a client with its Transport, if set, or else nil.
+func newHTTPClient(config *ClientConfig) *http.Client {
+	if config.HTTPClient != nil {
+		return config.HTTPClient
+	}
+	if config.Transport != nil {
+		return &http.Client{Transport: config.Transport.NewTransport()}
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DatabaseList contains a list of Databases.
+type DatabaseList struct {
+	Databases []*Database `json:"databases"`
+}
+
+// DatabaseID contains identifying information for a bit.io database.
+type DatabaseID struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Database contains metadata about a bit.io database.
+type Database struct {
+	DatabaseID
+	DateCreated       time.Time `json:"date_created"`
+	IsPrivate         bool      `json:"is_private"`
+	Role              string    `json:"role"`
+	StorageLimitBytes int64     `json:"storage_limit_bytes"`
+	StorageUsageBytes int64     `json:"storage_usage_bytes"`
+	UsageCurrent      *Usage    `json:"usage_current"`
+	UsagePrevious     *Usage    `json:"usage_previous"`
+	// Raw is the database's JSON as returned by the API, including any fields
+	// that are not modeled above.
+	Raw json.RawMessage `json:"-"`
+}
+
+// Usage contains current rows queried for a bit.io database.
+// TODO: Possibly parse out the Dates as time.Time type
+type Usage struct {
+	RowsQueried int64  `json:"rows_queried"`
+	PeriodStart string `json:"period_start"`
+	PeriodEnd   string `json:"period_end"`
+}
+
+// Visibility controls whether a database is private or public.
+type Visibility string
+
+const (
+	// VisibilityPrivate restricts database access to the owner and collaborators.
+	VisibilityPrivate Visibility = "private"
+	// VisibilityPublic allows any bit.io user to query the database.
+	VisibilityPublic Visibility = "public"
+)
+
+// DatabaseConfig maps the Create Database JSON body to a Go struct for
+// marshalling. Visibility has no default and must be set explicitly, so that a
+// database is never made public by omission. See NewDatabaseConfig.
+type DatabaseConfig struct {
+	Name              string
+	Visibility        Visibility
+	StorageLimitBytes int64
+	// Extra contains additional request fields, e.g. API parameters that the
+	// SDK does not model yet. They override fields of the same name.
+	Extra map[string]interface{}
+}
+
+// NewDatabaseConfig constructs a DatabaseConfig for a new database with an
+// explicit visibility.
+func NewDatabaseConfig(name string, visibility Visibility) *DatabaseConfig {
+	return &DatabaseConfig{Name: name, Visibility: visibility}
+}
+
+// WithStorageLimit sets the storage limit of a new database in bytes.
+func (d *DatabaseConfig) WithStorageLimit(bytes int64) *DatabaseConfig {
+	d.StorageLimitBytes = bytes
+	return d
+}
+
+// MarshalJSON implements custom marshalling to map Visibility to the API's
+// is_private field and reject a missing or unknown Visibility.
+func (d DatabaseConfig) MarshalJSON() ([]byte, error) {
+	if d.Visibility != VisibilityPrivate && d.Visibility != VisibilityPublic {
+		return nil, fmt.Errorf("Visibility must be %q or %q, got %q", VisibilityPrivate, VisibilityPublic, d.Visibility)
+	}
+	body, err := json.Marshal(struct {
+		Name              string `json:"name,omitempty"`
+		IsPrivate         bool   `json:"is_private"`
+		StorageLimitBytes int64  `json:"storage_limit_bytes,omitempty"`
+	}{d.Name, d.Visibility == VisibilityPrivate, d.StorageLimitBytes})
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtra(body, d.Extra)
+}
+
+// DatabaseUpdate maps the Update Database JSON body to a Go struct for
+// marshalling. Nil fields are omitted from the request, leaving the
+// corresponding settings unchanged. See String, Bool, and Int64 for setting
+// fields inline.
+//
+// StorageLimitBytes may be set to a pointer to 0 to set a zero limit. To remove
+// the storage limit entirely, set ClearStorageLimit instead.
+type DatabaseUpdate struct {
+	Name              *string
+	IsPrivate         *bool
+	StorageLimitBytes *int64
+	ClearStorageLimit bool
+}
+
+// MarshalJSON implements custom marshalling to send an explicit null storage
+// limit when ClearStorageLimit is set.
+func (d DatabaseUpdate) MarshalJSON() ([]byte, error) {
+	body := map[string]interface{}{}
+	if d.Name != nil {
+		body["name"] = *d.Name
+	}
+	if d.IsPrivate != nil {
+		body["is_private"] = *d.IsPrivate
+	}
+	if d.ClearStorageLimit {
+		if d.StorageLimitBytes != nil {
+			return nil, fmt.Errorf("cannot set both StorageLimitBytes and ClearStorageLimit")
+		}
+		body["storage_limit_bytes"] = nil
+	} else if d.StorageLimitBytes != nil {
+		body["storage_limit_bytes"] = *d.StorageLimitBytes
+	}
+	return json.Marshal(body)
+}
+
+// String returns a pointer to a string value, for use in optional fields.
+func String(v string) *string { return &v }
+
+// Bool returns a pointer to a bool value, for use in optional fields.
+func Bool(v bool) *bool { return &v }
+
+// Int64 returns a pointer to an int64 value, for use in optional fields.
+func Int64(v int64) *int64 { return &v }
+
+// Credentials contains credentials for a personal or service account.
+type Credentials struct {
+	Username string `json:"username"`
+	APIKEY   string `json:"api_key"`
+}
+
+// ServiceAccountList contains a list of service accounts.
+type ServiceAccountList struct {
+	ServiceAccounts []*ServiceAccount `json:"service_accounts"`
+}
+
+// ServiceAccount contains metadata about a bit.io service account.
+type ServiceAccount struct {
+	ID               string        `json:"id"`
+	Name             string        `json:"name"`
+	DateCreated      time.Time     `json:"date_created"`
+	Role             string        `json:"role"`
+	Databases        []*DatabaseID `json:"databases"`
+	TokenCount       int64         `json:"token_count"`
+	ActiveTokenCount int64         `json:"active_token_count"`
+	// Raw is the service account's JSON as returned by the API, including any
+	// fields that are not modeled above.
+	Raw json.RawMessage `json:"-"`
+}
+
+// TransferJob contains metadata about an import or export job.
+type TransferJob struct {
+	ID           string    `json:"id"`
+	DateCreated  time.Time `json:"date_created"`
+	DateFinished time.Time `json:"date_finished"`
+	State        string    `json:"state"`
+	Retries      int64     `json:"retries"`
+	ErrorType    string    `json:"error_type"`
+	ErrorID      string    `json:"error_id"`
+	StatusURL    string    `json:"status_url"`
+}
+
+// Job states reported in TransferJob.State.
+const (
+	JobStatePending = "PENDING"
+	JobStateRunning = "RUNNING"
+	JobStateDone    = "DONE"
+	JobStateFailed  = "FAILED"
+)
+
+// ExportJob contains metadata about an export job.
+type ExportJob struct {
+	TransferJob
+	ExportFormat string `json:"export_format"`
+	FileName     string `json:"file_name"`
+	DownloadURL  string `json:"download_url"`
+	// Raw is the job's JSON as returned by the API, including any fields that
+	// are not modeled above.
+	Raw json.RawMessage `json:"-"`
+}
+
+// ImportJob contains metadata about an import job.
+// TODO: Possibly handle "error_details" differently
+type ImportJob struct {
+	TransferJob
+	// Raw is the job's JSON as returned by the API, including any fields that
+	// are not modeled above, such as "error_details".
+	Raw json.RawMessage `json:"-"`
+}
+
+// InferHeader controls how an import job detects a header row.
+type InferHeader string
+
+const (
+	// InferHeaderAuto lets bit.io detect whether the first row is a header.
+	InferHeaderAuto InferHeader = "auto"
+	// InferHeaderFirstRow treats the first row as a header.
+	InferHeaderFirstRow InferHeader = "first_row"
+	// InferHeaderNone treats every row as data.
+	InferHeaderNone InferHeader = "no_header"
+	// InferHeaderHeader is the "header" value that was accepted before these
+	// constants, and is still sent to bit.io as is.
+	//
+	// Deprecated: Use InferHeaderAuto, InferHeaderFirstRow, or InferHeaderNone.
+	InferHeaderHeader InferHeader = "header"
+)
+
+// Validate returns an error if h is not a supported header inference mode.
+// The empty value is valid and uses the API default, and the deprecated
+// InferHeaderHeader is still accepted.
+func (h InferHeader) Validate() error {
+	switch h {
+	case "", InferHeaderAuto, InferHeaderFirstRow, InferHeaderNone, InferHeaderHeader:
+		return nil
+	}
+	return fmt.Errorf("InferHeader options are %q, %q, or %q, got %q", InferHeaderAuto, InferHeaderFirstRow, InferHeaderNone, h)
+}
+
+// ImportFormat identifies the format of an import file.
+type ImportFormat string
+
+const (
+	// ImportFormatCSV is a delimited text file.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatJSON is a JSON array of objects.
+	ImportFormatJSON ImportFormat = "json"
+	// ImportFormatNDJSON is newline-delimited JSON, with one object per line.
+	ImportFormatNDJSON ImportFormat = "ndjson"
+)
+
+// Validate returns an error if f is not a supported import format. The empty
+// value is valid and lets bit.io detect the format.
+func (f ImportFormat) Validate() error {
+	switch f {
+	case "", ImportFormatCSV, ImportFormatJSON, ImportFormatNDJSON:
+		return nil
+	}
+	return fmt.Errorf("Format options are %q, %q, or %q, got %q", ImportFormatCSV, ImportFormatJSON, ImportFormatNDJSON, f)
+}
+
+// ImportJobConfig contains configuration parameters for a new import job.
+type ImportJobConfig struct {
+	SchemaName  string       `json:"schema_name,omitempty"`
+	InferHeader InferHeader  `json:"infer_header,omitempty"`
+	Format      ImportFormat `json:"format,omitempty"`
+	FileURL     string       `json:"file_url,omitempty"`
+	File        io.Reader    `json:"-"`
+	// FilePath is the path of a local file to upload, as an alternative to File
+	// that CreateImportJob opens and closes itself.
+	FilePath string `json:"-"`
+	// GoogleSheet imports a worksheet and range of a Google Sheets spreadsheet,
+	// as an alternative to FileURL, by passing its CSV export URL as the
+	// file_url.
+	GoogleSheet *GoogleSheet `json:"-"`
+	// Extra contains additional form fields, e.g. API parameters that the SDK
+	// does not model yet. Strings are sent as is, and other values as JSON.
+	// They override fields of the same name.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// FileFormat implements custom marshalling to enforce supported export types and
+// set a default
+// Note for reviewers: I think the validation in this marshaller might actually
+// be better handled with a normal validation function in the related method.
+type FileFormat string
+
+func (f FileFormat) MarshalJSON() ([]byte, error) {
+	var s string
+	if f == "" {
+		s = "csv"
+	} else {
+		s = string(f)
+	}
+	supportedFormats := []string{"csv", "json", "xls", "parquet"}
+	for _, format := range supportedFormats {
+		if s == format {
+			return []byte(`"` + s + `"`), nil
+		}
+	}
+	return nil, fmt.Errorf("%s not in supported formats %v", s, supportedFormats)
+}
+
+// ExportJobConfig contains configuration parameters for a new export job.
+type ExportJobConfig struct {
+	QueryString  string     `json:"query_string,omitempty"`
+	TableName    string     `json:"table_name,omitempty"`
+	SchemaName   string     `json:"schema_name,omitempty"`
+	FileName     string     `json:"file_name,omitempty"`
+	ExportFormat FileFormat `json:"export_format"` // "csv", "json", "xls", "parquet"
+	// Extra contains additional request fields, e.g. API parameters that the
+	// SDK does not model yet. They override fields of the same name.
+	Extra map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON implements custom marshalling to merge in Extra.
+func (e ExportJobConfig) MarshalJSON() ([]byte, error) {
+	type exportJobConfig ExportJobConfig
+	body, err := json.Marshal(exportJobConfig(e))
+	if err != nil {
+		return nil, err
+	}
+	return mergeExtra(body, e.Extra)
+}
+
+// Query defines an HTTP query.
+type Query struct {
+	DatabaseName string `json:"database_name"`
+	QueryString  string `json:"query_string"`
+}
+
+// Query defines an HTTP query result.
+type QueryResult struct {
+	QueryString string            `json:"query_string"`
+	Metadata    map[string]string `json:"metadata"`
+	Data        [][]interface{}   `json:"data"`
+	// Columns names the columns of Data in order, since Metadata, which maps
+	// column names to Postgres type names, is unordered.
+	Columns []string `json:"columns,omitempty"`
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxNameLength is the maximum length in bytes of a Postgres identifier, which
+// bounds database, schema, table, and column names.
+const maxNameLength = 63
+
+// ErrInvalidName indicates that a database, schema, or table name was rejected
+// before making a request.
+var ErrInvalidName = errors.New("invalid name")
+
+// Note for reviewers: these checks only reject names that bit.io or Postgres
+// would certainly reject, rather than mirroring the server's exact rules, so
+// that a change on the server never makes the SDK refuse a valid name.
+
+// ValidateName checks a bare database, schema, or table name: it must be
+// non-empty, at most 63 bytes, have no leading or trailing whitespace, and
+// contain no slashes or control characters.
+func ValidateName(kind, name string) error {
+	var reason string
+	switch {
+	case name == "":
+		reason = "must not be empty"
+	case len(name) > maxNameLength:
+		reason = fmt.Sprintf("must be at most %d bytes, got %d", maxNameLength, len(name))
+	case strings.TrimSpace(name) != name:
+		reason = "must not have leading or trailing whitespace"
+	case strings.Contains(name, "/"):
+		reason = "must not contain '/'"
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		reason = "must not contain control characters"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%w: %s name %q %s", ErrInvalidName, kind, name, reason)
+}
+
+// ValidateFullDatabaseName checks a full, user-qualified database name, which
+// must have the form `username/dbname` with valid parts.
+func ValidateFullDatabaseName(fullDBName string) error {
+	username, dbName, ok := strings.Cut(fullDBName, "/")
+	if !ok {
+		return fmt.Errorf("%w: database name %q must have the form username/dbname", ErrInvalidName, fullDBName)
+	}
+	if err := ValidateName("user", username); err != nil {
+		return err
+	}
+	return ValidateName("database", dbName)
+}
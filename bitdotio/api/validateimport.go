@@ -0,0 +1,381 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// headerSampleRows is the number of data rows sampled to infer whether the
+// first row of a CSV file is a header.
+const headerSampleRows = 100
+
+// ImportReport describes an import file checked by ValidateImport.
+type ImportReport struct {
+	// Format is the format the file was checked as: the configured Format, or
+	// else the format detected from its extension or content.
+	Format ImportFormat
+	// Header reports whether the first row of a CSV file is a header, as
+	// configured with InferHeader or, for InferHeaderAuto, as inferred.
+	Header bool
+	// Columns are the column names from a CSV header, or the keys of JSON
+	// objects in the order first seen.
+	Columns []string
+	// NumColumns is the number of columns.
+	NumColumns int
+	// Rows is the number of data rows or objects.
+	Rows int64
+	// Warnings describe problems that do not prevent an import but likely
+	// produce an unintended table, such as duplicate column names.
+	Warnings []string
+}
+
+// ValidateImport checks the local file of config, given by File or FilePath,
+// for problems that would fail an import job or produce an unintended table,
+// without submitting it, so that pipelines can fail fast on malformed inputs
+// before consuming quota. It returns an error for a file that cannot be
+// parsed in its format, such as a CSV row with the wrong number of fields or
+// invalid JSON, naming the line of the problem, and otherwise a report of the
+// file's columns and rows.
+//
+// For InferHeaderAuto, or no InferHeader, the header is inferred as bit.io
+// would: the first row is a header if its fields are distinct, non-empty, and
+// not numbers, dates, or booleans, and some column's values in the following
+// rows are. bit.io's detection may differ for ambiguous files, so set
+// InferHeader explicitly where it matters.
+//
+// A File that implements io.Seeker is rewound afterwards, so that config can
+// be passed to CreateImportJob. FileURL and GoogleSheet imports cannot be
+// validated locally.
+func ValidateImport(config *ImportJobConfig) (*ImportReport, error) {
+	if config.FileURL != "" || config.GoogleSheet != nil {
+		return nil, errors.New("only imports of local files can be validated")
+	}
+	if err := config.InferHeader.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Format.Validate(); err != nil {
+		return nil, err
+	}
+	f := config.File
+	if config.FilePath != "" {
+		file, err := os.Open(config.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open import file: %w", err)
+		}
+		defer file.Close()
+		f = file
+	}
+	if f == nil {
+		return nil, errors.New("Must provide File or FilePath")
+	}
+	if seeker, ok := f.(io.Seeker); ok && config.File != nil {
+		start, err := seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("unable to rewind import file: %v", err)
+		}
+		defer seeker.Seek(start, io.SeekStart)
+	}
+
+	r := bufio.NewReader(f)
+	// Skip a UTF-8 byte order mark, which encoding/json rejects.
+	if bom, _ := r.Peek(3); bytes.Equal(bom, []byte("\ufeff")) {
+		r.Discard(3)
+	}
+	format := config.Format
+	if format == "" {
+		format = detectImportFormat(config.FilePath, r)
+	}
+	report := &ImportReport{Format: format}
+	var err error
+	switch format {
+	case ImportFormatJSON:
+		err = validateJSONImport(r, report)
+	case ImportFormatNDJSON:
+		err = validateNDJSONImport(r, report)
+	default:
+		err = validateCSVImport(r, config.InferHeader, report)
+	}
+	if err != nil {
+		return report, err
+	}
+	checkColumnNames(report)
+	return report, nil
+}
+
+// detectImportFormat returns the format of a file from its path's extension,
+// or else from its first non-space byte.
+func detectImportFormat(path string, r *bufio.Reader) ImportFormat {
+	if format, ok := importFileExtensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return format
+	}
+	for n := 1; ; n++ {
+		peek, _ := r.Peek(n)
+		if len(peek) < n {
+			return ImportFormatCSV
+		}
+		switch peek[n-1] {
+		case '[':
+			return ImportFormatJSON
+		case '{':
+			return ImportFormatNDJSON
+		case ' ', '\t', '\r', '\n':
+		default:
+			return ImportFormatCSV
+		}
+	}
+}
+
+// validateCSVImport parses a CSV file, which must have the same number of
+// fields in every row, and infers its header.
+func validateCSVImport(r io.Reader, inferHeader InferHeader, report *ImportReport) error {
+	reader := csv.NewReader(r)
+	first, err := reader.Read()
+	if err == io.EOF {
+		return errors.New("import file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid CSV: %w", err)
+	}
+	report.NumColumns = len(first)
+	if err = checkUTF8(first, 1); err != nil {
+		return err
+	}
+	var sample [][]string
+	rows := int64(1)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		if err = checkUTF8(record, line); err != nil {
+			return err
+		}
+		if len(sample) < headerSampleRows {
+			sample = append(sample, record)
+		}
+		rows++
+	}
+
+	switch inferHeader {
+	case InferHeaderFirstRow:
+		report.Header = true
+	case InferHeaderNone:
+	default:
+		report.Header = looksLikeHeader(first, sample)
+	}
+	report.Rows = rows
+	if report.Header {
+		report.Columns = first
+		report.Rows--
+	}
+	return nil
+}
+
+// checkUTF8 returns an error if a field of the record on line is not valid
+// UTF-8.
+func checkUTF8(record []string, line int) error {
+	for i, field := range record {
+		if !utf8.ValidString(field) {
+			return fmt.Errorf("invalid UTF-8 on line %d in field %d", line, i+1)
+		}
+	}
+	return nil
+}
+
+// looksLikeHeader reports whether first is a header row of a CSV file whose
+// following rows begin with sample: its fields are distinct, non-empty, and
+// untyped, and at least one column has typed values in every sampled row.
+func looksLikeHeader(first []string, sample [][]string) bool {
+	seen := make(map[string]bool, len(first))
+	for _, name := range first {
+		if name == "" || seen[name] || isTypedValue(name) {
+			return false
+		}
+		seen[name] = true
+	}
+	if len(sample) == 0 {
+		return false
+	}
+	for j := range first {
+		typed := true
+		for _, record := range sample {
+			if !isTypedValue(record[j]) {
+				typed = false
+				break
+			}
+		}
+		if typed {
+			return true
+		}
+	}
+	return false
+}
+
+// isTypedValue reports whether a CSV field is a number, date, or boolean.
+func isTypedValue(field string) bool {
+	field = strings.TrimSpace(field)
+	if _, err := strconv.ParseFloat(field, 64); err == nil {
+		return true
+	}
+	switch strings.ToLower(field) {
+	case "true", "false", "t", "f":
+		return true
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+		if _, err := time.Parse(layout, field); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// validateJSONImport parses a JSON array of objects.
+func validateJSONImport(r io.Reader, report *ImportReport) error {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return errors.New("import file is empty")
+	}
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if token != json.Delim('[') {
+		return errors.New("invalid JSON: import files must contain an array of objects")
+	}
+	columns := newColumnSet()
+	for decoder.More() {
+		var object jsonObject
+		if err = decoder.Decode(&object); err != nil {
+			return fmt.Errorf("invalid JSON in object %d: %w", report.Rows+1, err)
+		}
+		if object.keys == nil {
+			return fmt.Errorf("invalid JSON: element %d is not an object", report.Rows+1)
+		}
+		columns.add(object.keys)
+		report.Rows++
+	}
+	if _, err = decoder.Token(); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err = decoder.Token(); err != io.EOF {
+		return errors.New("invalid JSON: data after the array")
+	}
+	report.Columns, report.NumColumns = columns.names, len(columns.names)
+	return nil
+}
+
+// validateNDJSONImport parses newline-delimited JSON objects. Blank lines are
+// allowed.
+func validateNDJSONImport(r *bufio.Reader, report *ImportReport) error {
+	columns := newColumnSet()
+	for line := 1; ; line++ {
+		data, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 {
+			var object jsonObject
+			if jsonErr := json.Unmarshal(trimmed, &object); jsonErr != nil {
+				return fmt.Errorf("invalid NDJSON on line %d: %w", line, jsonErr)
+			}
+			if object.keys == nil {
+				return fmt.Errorf("invalid NDJSON on line %d: not an object", line)
+			}
+			columns.add(object.keys)
+			report.Rows++
+		}
+		if err == io.EOF {
+			break
+		}
+	}
+	if report.Rows == 0 {
+		return errors.New("import file is empty")
+	}
+	report.Columns, report.NumColumns = columns.names, len(columns.names)
+	return nil
+}
+
+// jsonObject decodes the keys of a JSON object in order, leaving keys nil for
+// other JSON values.
+type jsonObject struct {
+	keys []string
+}
+
+func (o *jsonObject) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return err
+	}
+	o.keys = []string{}
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		o.keys = append(o.keys, key.(string))
+		var value json.RawMessage
+		if err = decoder.Decode(&value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnSet collects column names in the order first seen.
+type columnSet struct {
+	names []string
+	seen  map[string]bool
+}
+
+func newColumnSet() *columnSet {
+	return &columnSet{seen: make(map[string]bool)}
+}
+
+func (s *columnSet) add(names []string) {
+	for _, name := range names {
+		if !s.seen[name] {
+			s.seen[name] = true
+			s.names = append(s.names, name)
+		}
+	}
+}
+
+// checkColumnNames adds warnings for column names that are empty, or that
+// collide once folded to lower case or truncated to Postgres's identifier
+// length.
+func checkColumnNames(report *ImportReport) {
+	seen := make(map[string]string, len(report.Columns))
+	for i, name := range report.Columns {
+		if strings.TrimSpace(name) == "" {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("column %d has an empty name", i+1))
+			continue
+		}
+		if len(name) > maxNameLength {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("column name %q is longer than %d bytes and will be truncated", name, maxNameLength))
+		}
+		folded := strings.ToLower(name)
+		if len(folded) > maxNameLength {
+			folded = folded[:maxNameLength]
+		}
+		if other, ok := seen[folded]; ok {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("column names %q and %q collide", other, name))
+			continue
+		}
+		seen[folded] = name
+	}
+}
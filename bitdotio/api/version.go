@@ -0,0 +1,148 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// versionProbeInterval is the minimum interval between probes of the available
+// API versions, so that ordinary 404 responses, e.g. for a missing database,
+// do not cause a probe each.
+const versionProbeInterval = time.Minute
+
+// versionNegotiator selects the first of a list of candidate API versions, in
+// order of preference, that the service supports. It is shared by the
+// DefaultAPIClients of a Client. A nil *versionNegotiator negotiates nothing.
+type versionNegotiator struct {
+	candidates []string
+
+	lock    sync.Mutex
+	current string
+	probed  time.Time
+	// probing is closed when the probe in progress finishes, or is nil if
+	// there is none. Probes run without holding lock, so that requests
+	// that do not need one are not blocked by it.
+	probing chan struct{}
+}
+
+// newVersionNegotiator constructs a negotiator for candidates, or returns nil
+// if there are none.
+func newVersionNegotiator(candidates []string) *versionNegotiator {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return &versionNegotiator{candidates: append([]string(nil), candidates...)}
+}
+
+// version returns the API version to use for c's requests.
+func (c *DefaultAPIClient) version(ctx context.Context) string {
+	n := c.versions
+	if n == nil {
+		return c.APIVersion
+	}
+	if current := n.get(); current != "" {
+		return current
+	}
+	n.negotiate(ctx, c)
+	if current := n.get(); current != "" {
+		return current
+	}
+	// ctx was done while waiting for another request's probe.
+	return n.candidates[0]
+}
+
+// get returns the selected version, or "" if none has been selected yet.
+func (n *versionNegotiator) get() string {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	return n.current
+}
+
+// renegotiate probes the available versions again after a request for version
+// was rejected with 404 Not Found or 410 Gone, and reports whether a different
+// version is now selected. Such responses usually reject a resource, e.g. a
+// missing database, rather than the version, so the versions are probed again
+// only if version itself is rejected, and no more than once per
+// versionProbeInterval.
+func (n *versionNegotiator) renegotiate(ctx context.Context, c *DefaultAPIClient, version string) bool {
+	if n == nil {
+		return false
+	}
+	n.lock.Lock()
+	if n.current != version {
+		n.lock.Unlock()
+		return true
+	}
+	if n.probing != nil || time.Since(n.probed) < versionProbeInterval {
+		n.lock.Unlock()
+		return false
+	}
+	n.probed = time.Now()
+	n.lock.Unlock()
+
+	if supported, err := c.probeVersion(ctx, version); err != nil || supported {
+		return false
+	}
+	n.negotiate(ctx, c)
+	return n.get() != version
+}
+
+// negotiate selects the first candidate version that c's service supports, or
+// waits, until ctx is done, for a probe already in progress to do so. If no
+// candidate can be confirmed, e.g. because the service is unreachable, the
+// current version is kept, or the first candidate is used if there is none.
+func (n *versionNegotiator) negotiate(ctx context.Context, c *DefaultAPIClient) {
+	n.lock.Lock()
+	if probing := n.probing; probing != nil {
+		n.lock.Unlock()
+		select {
+		case <-probing:
+		case <-ctx.Done():
+		}
+		return
+	}
+	probing := make(chan struct{})
+	n.probing = probing
+	n.probed = time.Now()
+	selected := n.current
+	n.lock.Unlock()
+
+	for _, version := range n.candidates {
+		supported, err := c.probeVersion(ctx, version)
+		if err != nil {
+			break
+		}
+		if supported {
+			selected = version
+			break
+		}
+	}
+	if selected == "" {
+		selected = n.candidates[0]
+	}
+
+	n.lock.Lock()
+	n.current = selected
+	n.probing = nil
+	n.lock.Unlock()
+	close(probing)
+}
+
+// probeVersion reports whether c's service supports API version, i.e. whether
+// listing databases under version is not rejected with 404 Not Found or
+// 410 Gone. Any other response, such as 403 Forbidden for a service account,
+// shows that the version exists.
+func (c *DefaultAPIClient) probeVersion(ctx context.Context, version string) (bool, error) {
+	req, err := c.newRequest(version, http.MethodGet, "db/", nil)
+	if err != nil {
+		return false, err
+	}
+	res, err := c.handler()(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	res.Body.Close()
+	return res.StatusCode != http.StatusNotFound && res.StatusCode != http.StatusGone, nil
+}
@@ -0,0 +1,385 @@
+// Package arrow materializes columns as Apache Arrow arrays and record
+// batches, and reads and writes them as Arrow IPC streams, without
+// dependencies outside the standard library.
+//
+// An Array holds its values in the buffers of the Arrow columnar format: a
+// validity bitmap, 32-bit offsets for variable-length values, and
+// little-endian values. The buffers can be handed to Arrow implementations
+// without conversion, e.g. with memory.NewBufferBytes of the Arrow Go module,
+// and a Writer writes records as an IPC stream, which pyarrow reads with
+// pyarrow.ipc.open_stream. Only the types of Type, which cover the columns of
+// bit.io query results, are supported; a Reader reports others as errors.
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Type is the Arrow type of a Field.
+type Type int
+
+const (
+	// Bool stores booleans as a bitmap.
+	Bool Type = iota
+	// Int64 stores signed 64-bit integers.
+	Int64
+	// Float64 stores double precision floats.
+	Float64
+	// Utf8 stores strings.
+	Utf8
+	// Binary stores byte strings.
+	Binary
+	// Date32 stores dates as days since the Unix epoch.
+	Date32
+	// Timestamp stores times as microseconds since the Unix epoch.
+	Timestamp
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Int64:
+		return "int64"
+	case Float64:
+		return "float64"
+	case Utf8:
+		return "utf8"
+	case Binary:
+		return "binary"
+	case Date32:
+		return "date32"
+	case Timestamp:
+		return "timestamp[us]"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// width returns the size in bytes of the values of fixed-width types other
+// than Bool, or 0.
+func (t Type) width() int {
+	switch t {
+	case Int64, Float64, Timestamp:
+		return 8
+	case Date32:
+		return 4
+	}
+	return 0
+}
+
+// Field is a column of a Schema.
+type Field struct {
+	Name string
+	Type Type
+	// TimeZone is the time zone of a Timestamp field, such as "UTC". The
+	// values of Timestamp fields without a time zone are wall clock times.
+	TimeZone string
+	Nullable bool
+}
+
+// Schema is the columns of records.
+type Schema struct {
+	Fields []Field
+}
+
+// Equal reports whether s and other have the same fields.
+func (s *Schema) Equal(other *Schema) bool {
+	if len(s.Fields) != len(other.Fields) {
+		return false
+	}
+	for i, field := range s.Fields {
+		if field != other.Fields[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Array is the values of a column of a record in the Arrow columnar layout.
+type Array struct {
+	Length    int
+	NullCount int
+	// Validity is a bitmap of the values that are not NULL, least significant
+	// bit first, or nil if NullCount is 0.
+	Validity []byte
+	// Offsets is the Length+1 int32 offsets of the values of Utf8 and Binary
+	// arrays in Data.
+	Offsets []byte
+	// Data is the values, as a bitmap for Bool arrays.
+	Data []byte
+}
+
+// Record is a record batch: a schema and an array of equal length for each of
+// its fields.
+type Record struct {
+	Schema  *Schema
+	NumRows int
+	Columns []*Array
+}
+
+// NewRecord returns a record of columns, which must have the types of the
+// fields of schema and equal lengths.
+func NewRecord(schema *Schema, columns []*Array) (*Record, error) {
+	if len(columns) != len(schema.Fields) {
+		return nil, fmt.Errorf("record has %d columns, but schema has %d fields", len(columns), len(schema.Fields))
+	}
+	r := &Record{Schema: schema, Columns: columns}
+	for i, column := range columns {
+		if i == 0 {
+			r.NumRows = column.Length
+		}
+		if column.Length != r.NumRows {
+			return nil, fmt.Errorf("column %s has %d values, want %d", schema.Fields[i].Name, column.Length, r.NumRows)
+		}
+		if err := column.validate(schema.Fields[i].Type); err != nil {
+			return nil, fmt.Errorf("column %s: %w", schema.Fields[i].Name, err)
+		}
+	}
+	return r, nil
+}
+
+// Value returns the value of column j in row i, or nil if it is NULL. Values
+// are bool, int64, float64, string, []byte, or time.Time in UTC, for Date32
+// and Timestamp columns.
+func (r *Record) Value(j, i int) interface{} {
+	a := r.Columns[j]
+	if a.IsNull(i) {
+		return nil
+	}
+	switch r.Schema.Fields[j].Type {
+	case Bool:
+		return a.Bool(i)
+	case Int64:
+		return a.Int64(i)
+	case Float64:
+		return a.Float64(i)
+	case Utf8:
+		return a.String(i)
+	case Binary:
+		return a.Bytes(i)
+	case Date32:
+		return time.Unix(int64(a.Int32(i))*86400, 0).UTC()
+	case Timestamp:
+		return time.UnixMicro(a.Int64(i)).UTC()
+	}
+	return nil
+}
+
+// Row returns the values of row i, see Value.
+func (r *Record) Row(i int) []interface{} {
+	row := make([]interface{}, len(r.Columns))
+	for j := range r.Columns {
+		row[j] = r.Value(j, i)
+	}
+	return row
+}
+
+// IsNull reports whether the value at i is NULL.
+func (a *Array) IsNull(i int) bool {
+	return a.NullCount > 0 && a.Validity[i/8]&(1<<(i%8)) == 0
+}
+
+// Bool returns the value at i of a Bool array.
+func (a *Array) Bool(i int) bool {
+	return a.Data[i/8]&(1<<(i%8)) != 0
+}
+
+// Int32 returns the value at i of a Date32 array.
+func (a *Array) Int32(i int) int32 {
+	return int32(binary.LittleEndian.Uint32(a.Data[4*i:]))
+}
+
+// Int64 returns the value at i of an Int64 or Timestamp array.
+func (a *Array) Int64(i int) int64 {
+	return int64(binary.LittleEndian.Uint64(a.Data[8*i:]))
+}
+
+// Float64 returns the value at i of a Float64 array.
+func (a *Array) Float64(i int) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(a.Data[8*i:]))
+}
+
+// Bytes returns the value at i of a Utf8 or Binary array, which aliases the
+// array's data.
+func (a *Array) Bytes(i int) []byte {
+	start := binary.LittleEndian.Uint32(a.Offsets[4*i:])
+	end := binary.LittleEndian.Uint32(a.Offsets[4*i+4:])
+	return a.Data[start:end:end]
+}
+
+// String returns the value at i of a Utf8 array.
+func (a *Array) String(i int) string {
+	return string(a.Bytes(i))
+}
+
+// validate checks that the buffers of a hold Length values of type t.
+func (a *Array) validate(t Type) error {
+	if a.Length < 0 || a.NullCount < 0 || a.NullCount > a.Length {
+		return fmt.Errorf("invalid length %d with %d NULLs", a.Length, a.NullCount)
+	}
+	bitmapLen := (a.Length + 7) / 8
+	if a.NullCount > 0 && len(a.Validity) < bitmapLen {
+		return errors.New("validity bitmap is too short")
+	}
+	switch t {
+	case Bool:
+		if len(a.Data) < bitmapLen {
+			return errors.New("data is too short")
+		}
+	case Utf8, Binary:
+		if a.Length == 0 && len(a.Offsets) == 0 {
+			// Empty arrays may omit their single offset.
+			break
+		}
+		if len(a.Offsets) < 4*(a.Length+1) {
+			return errors.New("offsets are too short")
+		}
+		prev := binary.LittleEndian.Uint32(a.Offsets)
+		for i := 1; i <= a.Length; i++ {
+			offset := binary.LittleEndian.Uint32(a.Offsets[4*i:])
+			if offset < prev || offset > math.MaxInt32 {
+				return errors.New("offsets are not increasing")
+			}
+			prev = offset
+		}
+		if uint64(prev) > uint64(len(a.Data)) {
+			return errors.New("data is too short")
+		}
+	case Int64, Float64, Date32, Timestamp:
+		if len(a.Data)/t.width() < a.Length {
+			return errors.New("data is too short")
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// newArray returns an array of n values with the validity bitmap of nulls,
+// which reports which values are NULL, or is nil if none are.
+func newArray(n int, nulls []bool) *Array {
+	a := &Array{Length: n}
+	for i := 0; i < n && i < len(nulls); i++ {
+		if !nulls[i] {
+			continue
+		}
+		if a.Validity == nil {
+			a.Validity = make([]byte, (n+7)/8)
+			for j := 0; j < n; j++ {
+				a.Validity[j/8] |= 1 << (j % 8)
+			}
+		}
+		a.Validity[i/8] &^= 1 << (i % 8)
+		a.NullCount++
+	}
+	return a
+}
+
+// NewBoolArray returns a Bool array of values, with NULLs where nulls is true.
+// nulls may be nil or shorter than values, for values that are not NULL.
+func NewBoolArray(values []bool, nulls []bool) *Array {
+	a := newArray(len(values), nulls)
+	a.Data = make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			a.Data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return a
+}
+
+// NewInt64Array returns an Int64 array of values, see NewBoolArray.
+func NewInt64Array(values []int64, nulls []bool) *Array {
+	a := newArray(len(values), nulls)
+	a.Data = make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		a.Data = binary.LittleEndian.AppendUint64(a.Data, uint64(v))
+	}
+	return a
+}
+
+// NewFloat64Array returns a Float64 array of values, see NewBoolArray.
+func NewFloat64Array(values []float64, nulls []bool) *Array {
+	a := newArray(len(values), nulls)
+	a.Data = make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		a.Data = binary.LittleEndian.AppendUint64(a.Data, math.Float64bits(v))
+	}
+	return a
+}
+
+// NewStringArray returns a Utf8 array of values, see NewBoolArray. It fails if
+// the values total more than the 2 GiB that 32-bit offsets address.
+func NewStringArray(values []string, nulls []bool) (*Array, error) {
+	size := 0
+	for _, v := range values {
+		size += len(v)
+	}
+	a, err := newOffsetArray(len(values), nulls, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		a.Data = append(a.Data, v...)
+		a.Offsets = binary.LittleEndian.AppendUint32(a.Offsets, uint32(len(a.Data)))
+	}
+	return a, nil
+}
+
+// NewBinaryArray returns a Binary array of values, see NewStringArray.
+func NewBinaryArray(values [][]byte, nulls []bool) (*Array, error) {
+	size := 0
+	for _, v := range values {
+		size += len(v)
+	}
+	a, err := newOffsetArray(len(values), nulls, size)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		a.Data = append(a.Data, v...)
+		a.Offsets = binary.LittleEndian.AppendUint32(a.Offsets, uint32(len(a.Data)))
+	}
+	return a, nil
+}
+
+// newOffsetArray returns an array of n variable-length values of size bytes
+// in total, with the first offset.
+func newOffsetArray(n int, nulls []bool, size int) (*Array, error) {
+	if size > math.MaxInt32 {
+		return nil, fmt.Errorf("values of %d bytes exceed the 2 GiB of a record batch column", size)
+	}
+	a := newArray(n, nulls)
+	a.Offsets = make([]byte, 4, 4*(n+1))
+	a.Data = make([]byte, 0, size)
+	return a, nil
+}
+
+// NewDate32Array returns a Date32 array of the dates of values, in their
+// locations, see NewBoolArray.
+func NewDate32Array(values []time.Time, nulls []bool) *Array {
+	a := newArray(len(values), nulls)
+	a.Data = make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		year, month, day := v.Date()
+		days := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix() / 86400
+		a.Data = binary.LittleEndian.AppendUint32(a.Data, uint32(int32(days)))
+	}
+	return a
+}
+
+// NewTimestampArray returns a Timestamp array of values, truncated to
+// microseconds, see NewBoolArray. The values of Timestamp fields without a
+// time zone should be wall clock times in UTC.
+func NewTimestampArray(values []time.Time, nulls []bool) *Array {
+	a := newArray(len(values), nulls)
+	a.Data = make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		a.Data = binary.LittleEndian.AppendUint64(a.Data, uint64(v.UnixMicro()))
+	}
+	return a
+}
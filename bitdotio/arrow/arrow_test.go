@@ -0,0 +1,284 @@
+package arrow
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/go.arrows")
+
+func testRecord(t *testing.T) *Record {
+	at := time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)
+	schema := &Schema{Fields: []Field{
+		{Name: "id", Type: Int64},
+		{Name: "score", Type: Float64, Nullable: true},
+		{Name: "active", Type: Bool, Nullable: true},
+		{Name: "name", Type: Utf8, Nullable: true},
+		{Name: "raw", Type: Binary, Nullable: true},
+		{Name: "day", Type: Date32, Nullable: true},
+		{Name: "created_at", Type: Timestamp, TimeZone: "UTC", Nullable: true},
+		{Name: "local", Type: Timestamp},
+	}}
+	nulls := []bool{false, true, false}
+	name, err := NewStringArray([]string{"alpha", "", "β"}, nulls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := NewBinaryArray([][]byte{{0, 1}, nil, {}}, nulls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRecord(schema, []*Array{
+		NewInt64Array([]int64{1, 2, -3}, nil),
+		NewFloat64Array([]float64{1.5, 0, -2.25}, nulls),
+		NewBoolArray([]bool{true, false, false}, nulls),
+		name,
+		raw,
+		NewDate32Array([]time.Time{at, {}, time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC)}, nulls),
+		NewTimestampArray([]time.Time{at, {}, time.Unix(-1, 0)}, nulls),
+		NewTimestampArray([]time.Time{at, at, at}, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+var testRows = [][]interface{}{
+	{int64(1), 1.5, true, "alpha", []byte{0, 1}, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC), time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)},
+	{int64(2), nil, nil, nil, nil, nil, nil, time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)},
+	{int64(-3), -2.25, false, "β", []byte{}, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), time.Unix(-1, 0).UTC(), time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)},
+}
+
+func TestRecord(t *testing.T) {
+	r := testRecord(t)
+	if r.NumRows != 3 {
+		t.Fatalf("NumRows = %d, want 3", r.NumRows)
+	}
+	for i, want := range testRows {
+		if got := r.Row(i); !reflect.DeepEqual(got, want) {
+			t.Errorf("row %d = %#v, want %#v", i, got, want)
+		}
+	}
+	// Buffers are in the Arrow layout.
+	if got := r.Columns[1].Validity; !bytes.Equal(got, []byte{0b101}) {
+		t.Errorf("validity = %08b, want 00000101", got)
+	}
+	if got := r.Columns[3].Offsets; !bytes.Equal(got, []byte{0, 0, 0, 0, 5, 0, 0, 0, 5, 0, 0, 0, 7, 0, 0, 0}) {
+		t.Errorf("offsets = %v", got)
+	}
+	if r.Columns[0].Validity != nil || r.Columns[0].NullCount != 0 {
+		t.Error("column without NULLs has a validity bitmap")
+	}
+
+	if _, err := NewRecord(r.Schema, r.Columns[:2]); err == nil {
+		t.Error("NewRecord with too few columns succeeded")
+	}
+	short := *r.Columns[0]
+	short.Data = short.Data[:16]
+	if _, err := NewRecord(&Schema{Fields: r.Schema.Fields[:1]}, []*Array{&short}); err == nil {
+		t.Error("NewRecord with short data succeeded")
+	}
+}
+
+func TestStream(t *testing.T) {
+	r := testRecord(t)
+	var b bytes.Buffer
+	w, err := NewWriter(&b, r.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stream := b.Bytes()
+	// Messages and their bodies are 8-byte aligned, and the stream ends with
+	// the end-of-stream marker.
+	if len(stream)%8 != 0 || !bytes.HasSuffix(stream, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}) {
+		t.Errorf("stream of %d bytes does not end with an aligned end-of-stream marker", len(stream))
+	}
+
+	reader, err := NewReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reader.Schema().Equal(r.Schema) {
+		t.Errorf("Schema = %+v, want %+v", reader.Schema(), r.Schema)
+	}
+	n := 0
+	for reader.Next() {
+		record := reader.Record()
+		for i, want := range testRows {
+			if got := record.Row(i); !reflect.DeepEqual(got, want) {
+				t.Errorf("record %d row %d = %#v, want %#v", n, i, got, want)
+			}
+		}
+		n++
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("read %d records, want 2", n)
+	}
+
+	// Streams without the continuation marker of Arrow 0.15 are read too.
+	legacy := make([]byte, 0, len(stream))
+	for rest := stream; len(rest) > 0; {
+		size := int(binary.LittleEndian.Uint32(rest[4:]))
+		legacy = append(legacy, rest[4:8+size]...)
+		rest = rest[8+size:]
+		if size > 0 {
+			message := fbRoot(legacy[len(legacy)-size:])
+			body := int(message.int64(3, 0))
+			legacy = append(legacy, rest[:body]...)
+			rest = rest[body:]
+		}
+	}
+	reader, err = NewReader(bytes.NewReader(legacy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n = 0; reader.Next(); n++ {
+	}
+	if err = reader.Err(); err != nil || n != 2 {
+		t.Errorf("read %d legacy records with error %v, want 2", n, err)
+	}
+
+	if err = w.Write(r); err == nil {
+		t.Error("Write after Close succeeded")
+	}
+	w, err = NewWriter(&bytes.Buffer{}, &Schema{Fields: r.Schema.Fields[:1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Write(r); err == nil {
+		t.Error("Write of a record with another schema succeeded")
+	}
+}
+
+func TestStreamCorrupt(t *testing.T) {
+	r := testRecord(t)
+	var b bytes.Buffer
+	w, err := NewWriter(&b, r.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Write(r); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stream := b.Bytes()
+	// Reading must not panic, whichever byte is corrupted.
+	for i := range stream {
+		for _, x := range []byte{0x01, 0x80, 0xff} {
+			corrupt := append([]byte(nil), stream...)
+			corrupt[i] ^= x
+			reader, err := NewReader(bytes.NewReader(corrupt))
+			if err != nil {
+				continue
+			}
+			for reader.Next() {
+				record := reader.Record()
+				for j := 0; j < record.NumRows; j++ {
+					record.Row(j)
+				}
+			}
+		}
+	}
+	schemaEnd := 8 + int(binary.LittleEndian.Uint32(stream[4:]))
+	for n := 0; n < len(stream); n++ {
+		reader, err := NewReader(bytes.NewReader(stream[:n]))
+		if err != nil {
+			continue
+		}
+		for reader.Next() {
+		}
+		// Streams may end after any message without the end-of-stream marker.
+		if reader.Err() == nil && n != schemaEnd && n != len(stream)-8 {
+			t.Errorf("stream truncated to %d bytes read without an error", n)
+		}
+	}
+}
+
+// TestWriterGolden writes two testRecords as testdata/go.arrows, which
+// testdata/pyarrow_golden.py checks that pyarrow reads, and fails if the
+// stream has changed, unless run with -update.
+func TestWriterGolden(t *testing.T) {
+	r := testRecord(t)
+	var b bytes.Buffer
+	w, err := NewWriter(&b, r.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err = w.Write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err = os.WriteFile("testdata/go.arrows", b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile("testdata/go.arrows")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Error("written stream differs from testdata/go.arrows; check it with testdata/pyarrow_golden.py and run with -update")
+	}
+}
+
+// TestPyarrowGolden reads testdata/pyarrow.arrows, two records of testRows
+// written by pyarrow with testdata/pyarrow_golden.py.
+func TestPyarrowGolden(t *testing.T) {
+	f, err := os.Open("testdata/pyarrow.arrows")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/pyarrow.arrows is missing; run testdata/pyarrow_golden.py")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	reader, err := NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := testRecord(t).Schema; !reader.Schema().Equal(want) {
+		t.Errorf("Schema = %+v, want %+v", reader.Schema(), want)
+	}
+	n := 0
+	for ; reader.Next(); n++ {
+		record := reader.Record()
+		if record.NumRows != len(testRows) {
+			t.Fatalf("record %d has %d rows, want %d", n, record.NumRows, len(testRows))
+		}
+		for i, want := range testRows {
+			if got := record.Row(i); !reflect.DeepEqual(got, want) {
+				t.Errorf("record %d row %d = %#v, want %#v", n, i, got, want)
+			}
+		}
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("read %d records, want 2", n)
+	}
+}
@@ -0,0 +1,295 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+)
+
+// Arrow IPC messages are FlatBuffers. fbTable and its values describe the
+// tables of a message for fbBuilder, which writes each table before the
+// tables, strings, and vectors it refers to, since offsets point forward.
+
+// fbField is a field of a table: a bool, uint8, int16, int32, or int64
+// scalar, or a string, fbTable, fbTables, or fbStructs stored by offset.
+type fbField struct {
+	id    int
+	value interface{}
+}
+
+// fbTable is a table, whose fields may be in any order.
+type fbTable []fbField
+
+// fbTables is a vector of tables.
+type fbTables []fbTable
+
+// fbStructs is a vector of n structs of 8-byte aligned fields.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+// fbBuilder writes a FlatBuffer.
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbSize returns the size of the inline value of a field.
+func fbSize(v interface{}) int {
+	switch v.(type) {
+	case bool, uint8:
+		return 1
+	case int16:
+		return 2
+	case int64:
+		return 8
+	}
+	// int32, and the offsets of other values.
+	return 4
+}
+
+// fbFinish returns the FlatBuffer of root, padded to 8 bytes.
+func fbFinish(root fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	b.patch(0, b.table(root))
+	b.pad(8, 0)
+	return b.buf
+}
+
+// pad appends zeros until the length of the buffer is rem modulo align.
+func (b *fbBuilder) pad(align, rem int) {
+	for len(b.buf)%align != rem {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patch writes the offset from at to target at at.
+func (b *fbBuilder) patch(at, target int) {
+	binary.LittleEndian.PutUint32(b.buf[at:], uint32(target-at))
+}
+
+// table writes t, preceded by its vtable, and returns its position.
+func (b *fbBuilder) table(t fbTable) int {
+	// With the largest fields first and the table's fields starting 8-byte
+	// aligned, every field is aligned.
+	fields := append(fbTable(nil), t...)
+	sort.SliceStable(fields, func(i, j int) bool { return fbSize(fields[i].value) > fbSize(fields[j].value) })
+	numIDs := 0
+	for _, f := range fields {
+		if f.id >= numIDs {
+			numIDs = f.id + 1
+		}
+	}
+	vtable := make([]byte, 4+2*numIDs)
+	offset := 4
+	for _, f := range fields {
+		binary.LittleEndian.PutUint16(vtable[4+2*f.id:], uint16(offset))
+		offset += fbSize(f.value)
+	}
+	binary.LittleEndian.PutUint16(vtable, uint16(len(vtable)))
+	binary.LittleEndian.PutUint16(vtable[2:], uint16(offset))
+
+	b.pad(8, ((4-len(vtable))%8+8)%8)
+	b.buf = append(b.buf, vtable...)
+	start := len(b.buf)
+	// The vtable is at the table's position less this offset.
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(vtable)))
+	// Values stored by offset are written after the table, and their offsets
+	// patched in.
+	type ref struct {
+		at    int
+		value interface{}
+	}
+	var refs []ref
+	for _, f := range fields {
+		switch v := f.value.(type) {
+		case bool:
+			if v {
+				b.buf = append(b.buf, 1)
+			} else {
+				b.buf = append(b.buf, 0)
+			}
+		case uint8:
+			b.buf = append(b.buf, v)
+		case int16:
+			b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(v))
+		case int32:
+			b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v))
+		case int64:
+			b.buf = binary.LittleEndian.AppendUint64(b.buf, uint64(v))
+		default:
+			refs = append(refs, ref{at: len(b.buf), value: v})
+			b.buf = append(b.buf, 0, 0, 0, 0)
+		}
+	}
+	for _, r := range refs {
+		b.patch(r.at, b.object(r.value))
+	}
+	return start
+}
+
+// object writes a value stored by offset and returns its position.
+func (b *fbBuilder) object(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		b.pad(4, 0)
+		start := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+		b.buf = append(append(b.buf, v...), 0)
+		return start
+	case fbTable:
+		return b.table(v)
+	case fbTables:
+		b.pad(4, 0)
+		start := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+		b.buf = append(b.buf, make([]byte, 4*len(v))...)
+		for i, t := range v {
+			b.patch(start+4+4*i, b.table(t))
+		}
+		return start
+	case fbStructs:
+		// The structs follow the length, 8-byte aligned.
+		b.pad(8, 4)
+		start := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v.n))
+		b.buf = append(b.buf, v.data...)
+		return start
+	}
+	panic("arrow: unsupported FlatBuffer value")
+}
+
+var errCorruptMessage = errors.New("corrupt IPC message")
+
+// fbView reads a table of a FlatBuffer. Reads out of bounds panic with
+// errCorruptMessage, which readMessage recovers.
+type fbView struct {
+	buf []byte
+	pos int
+}
+
+// fbRoot returns the root table of buf.
+func fbRoot(buf []byte) fbView {
+	v := fbView{buf: buf}
+	v.pos = v.deref(0)
+	return v
+}
+
+func (v fbView) check(pos, n int) {
+	if pos < 0 || n < 0 || pos > len(v.buf)-n {
+		panic(errCorruptMessage)
+	}
+}
+
+func (v fbView) u16(pos int) int {
+	v.check(pos, 2)
+	return int(binary.LittleEndian.Uint16(v.buf[pos:]))
+}
+
+func (v fbView) u32(pos int) uint32 {
+	v.check(pos, 4)
+	return binary.LittleEndian.Uint32(v.buf[pos:])
+}
+
+func (v fbView) u64(pos int) uint64 {
+	v.check(pos, 8)
+	return binary.LittleEndian.Uint64(v.buf[pos:])
+}
+
+// deref returns the position of the value whose offset is at pos.
+func (v fbView) deref(pos int) int {
+	return pos + int(v.u32(pos))
+}
+
+// field returns the position of the field id, or 0 if it is absent.
+func (v fbView) field(id int) int {
+	vtable := v.pos - int(int32(v.u32(v.pos)))
+	if 4+2*id >= v.u16(vtable) {
+		return 0
+	}
+	offset := v.u16(vtable + 4 + 2*id)
+	if offset == 0 {
+		return 0
+	}
+	return v.pos + offset
+}
+
+// uint8 returns the uint8 field id, or def if it is absent.
+func (v fbView) uint8(id int, def uint8) uint8 {
+	pos := v.field(id)
+	if pos == 0 {
+		return def
+	}
+	v.check(pos, 1)
+	return v.buf[pos]
+}
+
+// int16 returns the int16 field id, or def if it is absent.
+func (v fbView) int16(id int, def int16) int16 {
+	pos := v.field(id)
+	if pos == 0 {
+		return def
+	}
+	return int16(v.u16(pos))
+}
+
+// int32 returns the int32 field id, or def if it is absent.
+func (v fbView) int32(id int, def int32) int32 {
+	pos := v.field(id)
+	if pos == 0 {
+		return def
+	}
+	return int32(v.u32(pos))
+}
+
+// int64 returns the int64 field id, or def if it is absent.
+func (v fbView) int64(id int, def int64) int64 {
+	pos := v.field(id)
+	if pos == 0 {
+		return def
+	}
+	return int64(v.u64(pos))
+}
+
+// table returns the table field id, and whether it is present.
+func (v fbView) table(id int) (fbView, bool) {
+	pos := v.field(id)
+	if pos == 0 {
+		return fbView{}, false
+	}
+	return fbView{buf: v.buf, pos: v.deref(pos)}, true
+}
+
+// string returns the string field id, or "" if it is absent.
+func (v fbView) string(id int) string {
+	pos := v.field(id)
+	if pos == 0 {
+		return ""
+	}
+	pos = v.deref(pos)
+	n := int(v.u32(pos))
+	v.check(pos+4, n)
+	return string(v.buf[pos+4 : pos+4+n])
+}
+
+// vector returns the position of the elements of the vector field id and
+// their number, which is 0 if it is absent. Elements are checked to be in
+// bounds for elements of size bytes.
+func (v fbView) vector(id, size int) (int, int) {
+	pos := v.field(id)
+	if pos == 0 {
+		return 0, 0
+	}
+	pos = v.deref(pos)
+	n := int(v.u32(pos))
+	if n > (len(v.buf)-pos-4)/size {
+		panic(errCorruptMessage)
+	}
+	return pos + 4, n
+}
+
+// tableAt returns the table whose offset is at pos, an element of a vector
+// of tables.
+func (v fbView) tableAt(pos int) fbView {
+	return fbView{buf: v.buf, pos: v.deref(pos)}
+}
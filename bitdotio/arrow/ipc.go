@@ -0,0 +1,483 @@
+package arrow
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	// metadataVersion is V5 of the IPC format, of Arrow 1.0 and later.
+	metadataVersion = 4
+
+	// maxMetadataSize bounds the size of the metadata of a message, which
+	// describes the schema or the buffers of a record batch.
+	maxMetadataSize = 1 << 26
+
+	// continuation precedes the metadata size of messages.
+	continuation = 0xffffffff
+)
+
+// Message header types.
+const (
+	headerSchema      = 1
+	headerDictionary  = 2
+	headerRecordBatch = 3
+)
+
+// FlatBuffer type IDs of the Type union of Field.
+const (
+	typeInt           = 2
+	typeFloatingPoint = 3
+	typeBinary        = 4
+	typeUtf8          = 5
+	typeBool          = 6
+	typeDate          = 8
+	typeTimestamp     = 10
+)
+
+// Enum values of the types' parameters.
+const (
+	precisionDouble  = 2
+	dateUnitDay      = 0
+	timeUnitMicros   = 2
+	endiannessLittle = 0
+)
+
+// Writer writes records as an Arrow IPC stream: a schema message, followed by
+// a message per record batch.
+type Writer struct {
+	w      io.Writer
+	schema *Schema
+	err    error
+}
+
+// NewWriter returns a Writer that writes an IPC stream of records of schema to
+// w. Close must be called to end the stream.
+func NewWriter(w io.Writer, schema *Schema) (*Writer, error) {
+	fields := make(fbTables, len(schema.Fields))
+	for i, field := range schema.Fields {
+		typeID, typeTable, err := fieldType(field)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		fields[i] = fbTable{
+			{0, field.Name},
+			{1, field.Nullable},
+			{2, typeID},
+			{3, typeTable},
+			{5, fbTables{}},
+		}
+	}
+	writer := &Writer{w: w, schema: schema}
+	header := fbTable{{0, int16(endiannessLittle)}, {1, fields}}
+	if err := writer.writeMessage(headerSchema, header, nil, 0); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// fieldType returns the FlatBuffer type ID and type table of field.
+func fieldType(field Field) (uint8, fbTable, error) {
+	switch field.Type {
+	case Bool:
+		return typeBool, fbTable{}, nil
+	case Int64:
+		return typeInt, fbTable{{0, int32(64)}, {1, true}}, nil
+	case Float64:
+		return typeFloatingPoint, fbTable{{0, int16(precisionDouble)}}, nil
+	case Utf8:
+		return typeUtf8, fbTable{}, nil
+	case Binary:
+		return typeBinary, fbTable{}, nil
+	case Date32:
+		return typeDate, fbTable{{0, int16(dateUnitDay)}}, nil
+	case Timestamp:
+		t := fbTable{{0, int16(timeUnitMicros)}}
+		if field.TimeZone != "" {
+			t = append(t, fbField{1, field.TimeZone})
+		}
+		return typeTimestamp, t, nil
+	}
+	return 0, nil, fmt.Errorf("unsupported type %s", field.Type)
+}
+
+// Write writes r as a record batch. Its schema must equal the Writer's.
+func (w *Writer) Write(r *Record) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !r.Schema.Equal(w.schema) {
+		return errors.New("record schema differs from the stream's")
+	}
+	for i, column := range r.Columns {
+		if column.Length != r.NumRows {
+			return fmt.Errorf("column %s has %d values, want %d", w.schema.Fields[i].Name, column.Length, r.NumRows)
+		}
+		if err := column.validate(w.schema.Fields[i].Type); err != nil {
+			return fmt.Errorf("column %s: %w", w.schema.Fields[i].Name, err)
+		}
+	}
+	var nodes, buffers []byte
+	var body [][]byte
+	var bodyLength int64
+	addBuffer := func(b []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(bodyLength))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(b)))
+		body = append(body, b)
+		// Buffers are padded to 8 bytes.
+		bodyLength += int64(len(b)+7) &^ 7
+	}
+	for i, column := range r.Columns {
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(column.Length))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(column.NullCount))
+		if column.NullCount > 0 {
+			addBuffer(column.Validity)
+		} else {
+			addBuffer(nil)
+		}
+		if t := r.Schema.Fields[i].Type; t == Utf8 || t == Binary {
+			addBuffer(column.Offsets)
+		}
+		addBuffer(column.Data)
+	}
+	header := fbTable{
+		{0, int64(r.NumRows)},
+		{1, fbStructs{n: len(r.Columns), data: nodes}},
+		{2, fbStructs{n: len(buffers) / 16, data: buffers}},
+	}
+	return w.writeMessage(headerRecordBatch, header, body, bodyLength)
+}
+
+// Close writes the end of the stream. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.write(binary.LittleEndian.AppendUint32([]byte{0xff, 0xff, 0xff, 0xff}, 0)); err != nil {
+		return err
+	}
+	w.err = errors.New("arrow writer is closed")
+	return nil
+}
+
+// writeMessage writes a message with header, and body, the buffers of a
+// record batch, each padded to 8 bytes.
+func (w *Writer) writeMessage(headerType uint8, header fbTable, body [][]byte, bodyLength int64) error {
+	metadata := fbFinish(fbTable{
+		{0, int16(metadataVersion)},
+		{1, headerType},
+		{2, header},
+		{3, bodyLength},
+	})
+	prefix := binary.LittleEndian.AppendUint32(nil, continuation)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(metadata)))
+	if err := w.write(append(prefix, metadata...)); err != nil {
+		return err
+	}
+	var padding [8]byte
+	for _, b := range body {
+		if err := w.write(b); err != nil {
+			return err
+		}
+		if n := len(b) % 8; n != 0 {
+			if err := w.write(padding[n:]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Writer) write(b []byte) error {
+	if _, err := w.w.Write(b); err != nil {
+		w.err = err
+		return err
+	}
+	return nil
+}
+
+// Reader reads the records of an Arrow IPC stream, used like pgx.Rows:
+//
+//	r, err := arrow.NewReader(stream)
+//	...
+//	for r.Next() {
+//		record := r.Record()
+//		...
+//	}
+//	if err := r.Err(); err != nil {
+//		...
+//	}
+//
+// Streams with dictionary encoded or compressed buffers are reported as
+// errors.
+type Reader struct {
+	r      io.Reader
+	schema *Schema
+	record *Record
+	err    error
+}
+
+// NewReader returns a Reader of the IPC stream r, reading its schema.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{r: r}
+	headerType, header, _, err := reader.readMessage()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read arrow schema: %w", err)
+	}
+	if headerType != headerSchema {
+		return nil, fmt.Errorf("arrow stream starts with message type %d, not a schema", headerType)
+	}
+	if reader.schema, err = parseSchema(header); err != nil {
+		return nil, fmt.Errorf("unsupported arrow schema: %w", err)
+	}
+	return reader, nil
+}
+
+// Schema returns the schema of the stream's records.
+func (r *Reader) Schema() *Schema {
+	return r.schema
+}
+
+// Next reads the next record, returning false at the end of the stream or on
+// an error, which Err returns.
+func (r *Reader) Next() bool {
+	r.record = nil
+	if r.err != nil {
+		return false
+	}
+	headerType, header, body, err := r.readMessage()
+	switch {
+	case err == io.EOF:
+		r.err = io.EOF
+	case err != nil:
+		r.err = fmt.Errorf("unable to read arrow record batch: %w", err)
+	case headerType == headerDictionary:
+		r.err = errors.New("dictionary encoded arrow streams are not supported")
+	case headerType != headerRecordBatch:
+		r.err = fmt.Errorf("unexpected arrow message type %d", headerType)
+	default:
+		r.record, r.err = r.parseRecord(header, body)
+	}
+	return r.record != nil
+}
+
+// Record returns the record read by Next.
+func (r *Reader) Record() *Record {
+	return r.record
+}
+
+// Err returns the error that stopped Next, if any.
+func (r *Reader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// readMessage reads a message, returning io.EOF at the end of the stream.
+func (r *Reader) readMessage() (headerType uint8, header fbView, body []byte, err error) {
+	var prefix [4]byte
+	if _, err = io.ReadFull(r.r, prefix[:]); err != nil {
+		return 0, fbView{}, nil, err
+	}
+	size := binary.LittleEndian.Uint32(prefix[:])
+	// Streams before Arrow 0.15 omit the continuation.
+	if size == continuation {
+		if _, err = io.ReadFull(r.r, prefix[:]); err != nil {
+			return 0, fbView{}, nil, noEOF(err)
+		}
+		size = binary.LittleEndian.Uint32(prefix[:])
+	}
+	if size == 0 {
+		return 0, fbView{}, nil, io.EOF
+	}
+	if size > maxMetadataSize {
+		return 0, fbView{}, nil, errors.New("corrupt IPC message, metadata is too large")
+	}
+	metadata := make([]byte, size)
+	if _, err = io.ReadFull(r.r, metadata); err != nil {
+		return 0, fbView{}, nil, noEOF(err)
+	}
+	var bodyLength int64
+	err = recoverCorrupt(func() {
+		message := fbRoot(metadata)
+		if version := message.int16(0, 0); version < 3 {
+			err = fmt.Errorf("unsupported IPC metadata version %d", version)
+			return
+		}
+		headerType = message.uint8(1, 0)
+		var ok bool
+		if header, ok = message.table(2); !ok {
+			panic(errCorruptMessage)
+		}
+		bodyLength = message.int64(3, 0)
+	})
+	if err != nil {
+		return 0, fbView{}, nil, err
+	}
+	if bodyLength < 0 {
+		return 0, fbView{}, nil, errCorruptMessage
+	}
+	// The body is read incrementally, rather than allocated from its length,
+	// so that a corrupt length fails at the end of the stream.
+	if body, err = io.ReadAll(io.LimitReader(r.r, bodyLength)); err != nil {
+		return 0, fbView{}, nil, err
+	}
+	if int64(len(body)) < bodyLength {
+		return 0, fbView{}, nil, io.ErrUnexpectedEOF
+	}
+	return headerType, header, body, nil
+}
+
+// noEOF converts io.EOF in the middle of a message to io.ErrUnexpectedEOF.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// recoverCorrupt calls f, returning errCorruptMessage if it reads out of the
+// bounds of a message.
+func recoverCorrupt(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errCorruptMessage {
+				panic(r)
+			}
+			err = errCorruptMessage
+		}
+	}()
+	f()
+	return nil
+}
+
+// parseSchema parses the header of a schema message.
+func parseSchema(header fbView) (schema *Schema, err error) {
+	schema = &Schema{}
+	corrupt := recoverCorrupt(func() {
+		if header.int16(0, endiannessLittle) != endiannessLittle {
+			err = errors.New("big-endian streams are not supported")
+			return
+		}
+		pos, n := header.vector(1, 4)
+		for i := 0; i < n && err == nil; i++ {
+			var field Field
+			field, err = parseField(header.tableAt(pos + 4*i))
+			schema.Fields = append(schema.Fields, field)
+		}
+	})
+	if corrupt != nil {
+		return nil, corrupt
+	}
+	return schema, err
+}
+
+// parseField parses a Field table of a schema.
+func parseField(t fbView) (Field, error) {
+	field := Field{Name: t.string(0), Nullable: t.uint8(1, 0) != 0}
+	if _, ok := t.table(4); ok {
+		return field, fmt.Errorf("field %s: dictionary encoding is not supported", field.Name)
+	}
+	if _, n := t.vector(5, 4); n > 0 {
+		return field, fmt.Errorf("field %s: nested types are not supported", field.Name)
+	}
+	typeID := t.uint8(2, 0)
+	typeTable, ok := t.table(3)
+	if !ok {
+		return field, fmt.Errorf("field %s has no type", field.Name)
+	}
+	supported := true
+	switch typeID {
+	case typeBool:
+		field.Type = Bool
+	case typeInt:
+		field.Type = Int64
+		supported = typeTable.int32(0, 0) == 64 && typeTable.uint8(1, 0) != 0
+	case typeFloatingPoint:
+		field.Type = Float64
+		supported = typeTable.int16(0, 0) == precisionDouble
+	case typeUtf8:
+		field.Type = Utf8
+	case typeBinary:
+		field.Type = Binary
+	case typeDate:
+		field.Type = Date32
+		supported = typeTable.int16(0, 1) == dateUnitDay
+	case typeTimestamp:
+		field.Type = Timestamp
+		field.TimeZone = typeTable.string(1)
+		supported = typeTable.int16(0, 0) == timeUnitMicros
+	default:
+		supported = false
+	}
+	if !supported {
+		return field, fmt.Errorf("field %s: unsupported type %d", field.Name, typeID)
+	}
+	return field, nil
+}
+
+// parseRecord parses the header of a record batch message and returns the
+// record of the arrays in body, which they alias.
+func (r *Reader) parseRecord(header fbView, body []byte) (record *Record, err error) {
+	var numRows int64
+	var columns []*Array
+	corrupt := recoverCorrupt(func() {
+		if _, ok := header.table(3); ok {
+			err = errors.New("compressed arrow record batches are not supported")
+			return
+		}
+		if numRows = header.int64(0, 0); numRows < 0 {
+			panic(errCorruptMessage)
+		}
+		nodes, numNodes := header.vector(1, 16)
+		buffers, numBuffers := header.vector(2, 16)
+		if numNodes != len(r.schema.Fields) {
+			err = fmt.Errorf("corrupt IPC message, record batch has %d columns, but the schema has %d", numNodes, len(r.schema.Fields))
+			return
+		}
+		buffer := func() []byte {
+			if numBuffers == 0 {
+				panic(errCorruptMessage)
+			}
+			offset, length := int64(header.u64(buffers)), int64(header.u64(buffers+8))
+			buffers, numBuffers = buffers+16, numBuffers-1
+			if offset < 0 || length < 0 || offset > int64(len(body))-length {
+				panic(errCorruptMessage)
+			}
+			return body[offset : offset+length : offset+length]
+		}
+		for i, field := range r.schema.Fields {
+			length, nullCount := int64(header.u64(nodes+16*i)), int64(header.u64(nodes+16*i+8))
+			if length != numRows || nullCount < 0 || nullCount > length {
+				panic(errCorruptMessage)
+			}
+			a := &Array{Length: int(length), NullCount: int(nullCount), Validity: buffer()}
+			if a.NullCount == 0 {
+				a.Validity = nil
+			}
+			if field.Type == Utf8 || field.Type == Binary {
+				a.Offsets = buffer()
+			}
+			a.Data = buffer()
+			columns = append(columns, a)
+		}
+	})
+	if corrupt != nil {
+		return nil, corrupt
+	}
+	if err != nil {
+		return nil, err
+	}
+	if record, err = NewRecord(r.schema, columns); err != nil {
+		return nil, fmt.Errorf("corrupt IPC message: %w", err)
+	}
+	record.NumRows = int(numRows)
+	return record, nil
+}
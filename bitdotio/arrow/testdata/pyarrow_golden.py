@@ -0,0 +1,79 @@
+#!/usr/bin/env python3
+"""Checks the arrow package against pyarrow, the reference implementation.
+
+Writes pyarrow.arrows, an IPC stream of two record batches that TestPyarrowGolden
+reads, and checks that pyarrow reads go.arrows, written by TestWriterGolden, as
+the same batches:
+
+    go test -run TestWriterGolden -update
+    python3 testdata/pyarrow_golden.py
+
+The rows are those of testRecord in arrow_test.go.
+"""
+
+import datetime
+import os
+import sys
+
+import pyarrow as pa
+import pyarrow.ipc
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+UTC = datetime.timezone.utc
+AT = datetime.datetime(2023, 1, 2, 3, 4, 5, 678901)
+
+SCHEMA = pa.schema([
+    pa.field("id", pa.int64(), nullable=False),
+    pa.field("score", pa.float64()),
+    pa.field("active", pa.bool_()),
+    pa.field("name", pa.string()),
+    pa.field("raw", pa.binary()),
+    pa.field("day", pa.date32()),
+    pa.field("created_at", pa.timestamp("us", tz="UTC")),
+    pa.field("local", pa.timestamp("us"), nullable=False),
+])
+
+ROWS = [
+    (1, 1.5, True, "alpha", b"\x00\x01", datetime.date(2023, 1, 2),
+     AT.replace(tzinfo=UTC), AT),
+    (2, None, None, None, None, None, None, AT),
+    (-3, -2.25, False, "β", b"", datetime.date(1969, 12, 31),
+     datetime.datetime(1969, 12, 31, 23, 59, 59, tzinfo=UTC), AT),
+]
+
+
+def batch():
+    columns = list(zip(*ROWS))
+    return pa.RecordBatch.from_arrays(
+        [pa.array(values, type=field.type) for values, field in zip(columns, SCHEMA)],
+        schema=SCHEMA)
+
+
+def main():
+    with pa.OSFile(os.path.join(HERE, "pyarrow.arrows"), "wb") as sink:
+        with pa.ipc.new_stream(sink, SCHEMA) as writer:
+            writer.write_batch(batch())
+            writer.write_batch(batch())
+
+    path = os.path.join(HERE, "go.arrows")
+    if not os.path.exists(path):
+        sys.exit("go.arrows is missing; run go test -run TestWriterGolden -update")
+    with pa.OSFile(path, "rb") as source:
+        reader = pa.ipc.open_stream(source)
+        if reader.schema != SCHEMA:
+            sys.exit("go.arrows schema is\n%s\nwant\n%s" % (reader.schema, SCHEMA))
+        batches = list(reader)
+    if len(batches) != 2:
+        sys.exit("go.arrows has %d batches, want 2" % len(batches))
+    for n, got in enumerate(batches):
+        got.validate(full=True)
+        for i, (row, want) in enumerate(zip(got.to_pylist(), ROWS)):
+            if tuple(row[field.name] for field in SCHEMA) != want:
+                sys.exit("go.arrows batch %d row %d is %r, want %r" % (n, i, row, want))
+        if got.num_rows != len(ROWS):
+            sys.exit("go.arrows batch %d has %d rows, want %d" % (n, got.num_rows, len(ROWS)))
+    print("ok")
+
+
+if __name__ == "__main__":
+    main()
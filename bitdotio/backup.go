@@ -0,0 +1,254 @@
+package bitdotio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+)
+
+// backupTimeFormat is the timestamp format in backup file names, which sorts
+// chronologically.
+const backupTimeFormat = "20060102T150405Z"
+
+// BackupTarget is a database to back up: the listed tables, or every base
+// table in the database if Tables is empty.
+type BackupTarget struct {
+	// DBName is the full name of the database, e.g. `username/dbname`.
+	DBName string
+	// Tables are table names, optionally schema-qualified.
+	Tables []string
+}
+
+// BackupConfig configures a BackupScheduler. Exactly one of Dir and Writer
+// must be set.
+type BackupConfig struct {
+	Targets  []BackupTarget
+	Schedule Schedule
+	// Format is the export format. Defaults to csv.
+	Format FileFormat
+	// Dir is a directory to write backups to, as files named
+	// `<db>.<table>.<timestamp>.<format>`, where <db> is the database's full
+	// name with '/' replaced by "__". Files are written atomically, see
+	// DownloadExportToFile.
+	Dir string
+	// Writer, if set instead of Dir, opens the destination of each backup, and
+	// the backup is written to it and closed.
+	Writer func(dbName, table string, t time.Time) (io.WriteCloser, error)
+	// Retain is the number of backups of each table to keep in Dir, pruning
+	// the oldest. Defaults to keeping all.
+	Retain int
+	// MaxAge prunes backups in Dir older than MaxAge. Defaults to keeping all.
+	MaxAge time.Duration
+	// OnBackup, if set, is called after each table is backed up.
+	OnBackup func(dbName, table string)
+	// OnFailure, if set, is called with the error of each failed table backup
+	// or prune.
+	OnFailure func(dbName, table string, err error)
+}
+
+// BackupScheduler periodically exports tables with export jobs, downloads them
+// to a destination, and prunes old backups. Construct one with
+// NewBackupScheduler.
+type BackupScheduler struct {
+	b      *BitDotIO
+	config BackupConfig
+	// ctx is the context of scheduled backups, which Stop cancels.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stop    chan struct{}
+	once    sync.Once
+	done    chan struct{}
+	running sync.Mutex
+}
+
+// NewBackupScheduler validates config and starts a BackupScheduler, whose
+// first backup is at the first time of config.Schedule after now; use RunNow
+// to back up immediately. Call Stop, or b's Shutdown, to stop it.
+func (b *BitDotIO) NewBackupScheduler(config *BackupConfig) (*BackupScheduler, error) {
+	if (config.Dir == "") == (config.Writer == nil) {
+		return nil, errors.New("backup config must have exactly one of Dir and Writer")
+	}
+	if config.Schedule == nil {
+		return nil, errors.New("backup config has no schedule")
+	}
+	if len(config.Targets) == 0 {
+		return nil, errors.New("backup config has no targets")
+	}
+	s := &BackupScheduler{b: b, config: *config, stop: make(chan struct{}), done: make(chan struct{})}
+	if s.config.Format == "" {
+		s.config.Format = "csv"
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	go func() {
+		defer close(s.done)
+		runScheduled(s.config.Schedule, s.stop, func() { s.RunNow(s.ctx) })
+	}()
+	b.lifecycle.register(s.Stop)
+	return s, nil
+}
+
+// RunNow backs up every target immediately, after any backup in progress, and
+// prunes old backups. It returns the errors of all failed table backups and
+// prunes, joined, which are also reported to OnFailure.
+func (s *BackupScheduler) RunNow(ctx context.Context) error {
+	s.running.Lock()
+	defer s.running.Unlock()
+	var errs []string
+	fail := func(dbName, table string, err error) {
+		err = fmt.Errorf("backup of %s table %s failed: %w", dbName, table, err)
+		s.b.logfCtx(ctx, "bitdotio: %v", err)
+		errs = append(errs, err.Error())
+		if s.config.OnFailure != nil {
+			s.config.OnFailure(dbName, table, err)
+		}
+	}
+	for _, target := range s.config.Targets {
+		tables := target.Tables
+		if len(tables) == 0 {
+			var err error
+			if tables, err = s.listTables(ctx, target.DBName); err != nil {
+				fail(target.DBName, "*", err)
+				continue
+			}
+		}
+		for _, table := range tables {
+			if err := s.backup(ctx, target.DBName, table); err != nil {
+				fail(target.DBName, table, err)
+				continue
+			}
+			if s.config.OnBackup != nil {
+				s.config.OnBackup(target.DBName, table)
+			}
+			if err := s.prune(target.DBName, table); err != nil {
+				fail(target.DBName, table, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Stop stops scheduling backups, cancels the context of a scheduled backup in
+// progress, and waits for it to finish.
+func (s *BackupScheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+		s.cancel()
+	})
+	<-s.done
+	s.running.Lock()
+	s.running.Unlock()
+}
+
+// listTables returns the schema-qualified names of the base tables in dbName.
+func (s *BackupScheduler) listTables(ctx context.Context, dbName string) ([]string, error) {
+	result, err := s.b.queryHTTP(ctx, dbName, `SELECT table_schema || '.' || table_name FROM information_schema.tables `+
+		`WHERE table_type = 'BASE TABLE' AND table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1`)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]string, 0, len(result.Data))
+	for _, row := range result.Data {
+		if len(row) > 0 {
+			if table, ok := row[0].(string); ok {
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables, nil
+}
+
+// backup exports table from dbName and downloads it to the destination.
+func (s *BackupScheduler) backup(ctx context.Context, dbName, table string) error {
+	config := &api.ExportJobConfig{TableName: table, ExportFormat: s.config.Format}
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		config.SchemaName, config.TableName = schema, name
+	}
+	exportJob, err := s.b.CreateExportJob(dbName, config, api.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if exportJob, err = s.b.WaitForExportJob(ctx, exportJob.ID, 0); err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	if s.config.Dir != "" {
+		_, err = s.b.DownloadExportToFile(ctx, exportJob, filepath.Join(s.config.Dir, s.fileName(dbName, table, now)), nil)
+		return err
+	}
+	w, err := s.config.Writer(dbName, table, now)
+	if err != nil {
+		return err
+	}
+	body, err := s.b.DownloadExport(ctx, exportJob)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	defer body.Close()
+	if _, err = io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// filePrefix returns the common prefix of the names of table's backup files.
+func (s *BackupScheduler) filePrefix(dbName, table string) string {
+	return strings.ReplaceAll(dbName, "/", "__") + "." + table + "."
+}
+
+// fileName returns the name of a backup file of table taken at t.
+func (s *BackupScheduler) fileName(dbName, table string, t time.Time) string {
+	return s.filePrefix(dbName, table) + t.Format(backupTimeFormat) + "." + string(s.config.Format)
+}
+
+// prune removes the backups of table in Dir beyond the retention policy.
+func (s *BackupScheduler) prune(dbName, table string) error {
+	if s.config.Dir == "" || (s.config.Retain <= 0 && s.config.MaxAge <= 0) {
+		return nil
+	}
+	prefix := s.filePrefix(dbName, table)
+	suffix := "." + string(s.config.Format)
+	entries, err := os.ReadDir(s.config.Dir)
+	if err != nil {
+		return err
+	}
+	type backup struct {
+		name  string
+		taken time.Time
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		taken, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup{name, taken})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].taken.After(backups[j].taken) })
+	cutoff := time.Now().Add(-s.config.MaxAge)
+	for i, b := range backups {
+		if (s.config.Retain > 0 && i >= s.config.Retain) || (s.config.MaxAge > 0 && b.taken.Before(cutoff)) {
+			if err := os.Remove(filepath.Join(s.config.Dir, b.name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
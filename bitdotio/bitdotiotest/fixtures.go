@@ -0,0 +1,136 @@
+// Package bitdotiotest provides utilities for integration tests against bit.io
+// databases.
+package bitdotiotest
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/pool"
+)
+
+// LoadFixtures loads fixture files into existing tables of a bit.io database
+// with a pool managed by m, and truncates the tables when the test and its
+// subtests complete. Tables are also truncated before loading, so each test
+// starts from exactly its fixtures. Any failure fails the test immediately.
+//
+// Each file is loaded into the table named by its base name without extension,
+// which may be schema-qualified, e.g. `testdata/public.users.csv` loads into
+// public.users. Supported formats are:
+//
+//   - .csv: CSV with a header row naming the columns, loaded with COPY. Empty
+//     unquoted values load as NULL.
+//   - .json: a JSON array of objects keyed by column name
+//   - .ndjson, .jsonl: newline-delimited JSON objects keyed by column name
+//
+// JSON fixtures are converted to the table's row type with
+// json_populate_recordset, so missing keys load as NULL.
+func LoadFixtures(tb testing.TB, m *pool.Manager, dbName string, paths ...string) {
+	tb.Helper()
+	ctx := context.Background()
+	tables := make([]string, len(paths))
+	for i, path := range paths {
+		base := filepath.Base(path)
+		tables[i] = pgx.Identifier(strings.Split(strings.TrimSuffix(base, filepath.Ext(base)), ".")).Sanitize()
+	}
+	truncate := func() error {
+		if len(tables) == 0 {
+			return nil
+		}
+		p, err := m.GetPool(dbName)
+		if err != nil {
+			return err
+		}
+		_, err = p.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY")
+		return err
+	}
+	if err := truncate(); err != nil {
+		tb.Fatalf("unable to truncate fixture tables on db %s: %v", dbName, err)
+	}
+	tb.Cleanup(func() {
+		if err := truncate(); err != nil {
+			tb.Errorf("unable to truncate fixture tables on db %s: %v", dbName, err)
+		}
+	})
+	for i, path := range paths {
+		if err := loadFixture(ctx, m, dbName, tables[i], path); err != nil {
+			tb.Fatalf("unable to load fixture %s into %s on db %s: %v", path, tables[i], dbName, err)
+		}
+	}
+}
+
+// loadFixture loads the fixture file at path into table.
+func loadFixture(ctx context.Context, m *pool.Manager, dbName, table, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		// Read the header to name the columns, so that CSV columns need not be in
+		// table order, then rewind for COPY to skip it.
+		header, err := csv.NewReader(f).Read()
+		if err != nil {
+			return fmt.Errorf("unable to read CSV header: %w", err)
+		}
+		if _, err = f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		columns := make([]string, len(header))
+		for i, column := range header {
+			columns[i] = pgx.Identifier{column}.Sanitize()
+		}
+		sql := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv, HEADER true)", table, strings.Join(columns, ", "))
+		_, err = conn.Conn().PgConn().CopyFrom(ctx, f, sql)
+		return err
+	case ".json", ".ndjson", ".jsonl":
+		var rows []json.RawMessage
+		if ext == ".json" {
+			err = json.NewDecoder(f).Decode(&rows)
+		} else {
+			rows, err = readNDJSON(f)
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read JSON: %w", err)
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Exec(ctx, "INSERT INTO "+table+" SELECT * FROM json_populate_recordset(null::"+table+", $1)", string(data))
+		return err
+	default:
+		return fmt.Errorf("unsupported fixture file extension %s", ext)
+	}
+}
+
+// readNDJSON reads newline-delimited JSON values from f, skipping blank lines.
+func readNDJSON(f *os.File) ([]json.RawMessage, error) {
+	var rows []json.RawMessage
+	decoder := json.NewDecoder(f)
+	for decoder.More() {
+		var row json.RawMessage
+		if err := decoder.Decode(&row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,112 @@
+package bitdotiotest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// Namespace isolates the databases and tables created by a test run, so that
+// parallel CI runs against a shared bit.io account do not collide. Names are
+// prefixed with a unique run ID, and everything created through the Namespace
+// is deleted by Cleanup, which is also registered with the test.
+type Namespace struct {
+	tb    testing.TB
+	b     *bitdotio.BitDotIO
+	runID string
+
+	lock      sync.Mutex
+	databases []string
+	tables    map[string][]string
+	cleaned   bool
+}
+
+// NewNamespace constructs a Namespace with a new run ID for a test, and
+// registers its Cleanup with tb.
+func NewNamespace(tb testing.TB, b *bitdotio.BitDotIO) *Namespace {
+	tb.Helper()
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		tb.Fatalf("unable to generate a run ID: %v", err)
+	}
+	ns := &Namespace{
+		tb:     tb,
+		b:      b,
+		runID:  "t" + hex.EncodeToString(id),
+		tables: make(map[string][]string),
+	}
+	tb.Cleanup(ns.Cleanup)
+	return ns
+}
+
+// RunID returns the unique ID of the Namespace, which starts with a letter so
+// that prefixed names are valid unquoted identifiers.
+func (ns *Namespace) RunID() string {
+	return ns.runID
+}
+
+// Name returns name prefixed with the run ID, e.g. `t1a2b3c4d_users`.
+func (ns *Namespace) Name(name string) string {
+	return ns.runID + "_" + name
+}
+
+// CreateDatabase creates a private database named Name(name) and returns its
+// full name (e.g. `username/t1a2b3c4d_name`). The database is deleted, after
+// closing any pool for it, by Cleanup.
+func (ns *Namespace) CreateDatabase(name string) string {
+	ns.tb.Helper()
+	database, err := ns.b.CreateDatabase(bitdotio.NewDatabaseConfig(ns.Name(name), bitdotio.VisibilityPrivate))
+	if err != nil {
+		ns.tb.Fatalf("unable to create database %s: %v", ns.Name(name), err)
+	}
+	ns.lock.Lock()
+	ns.databases = append(ns.databases, database.Name)
+	ns.lock.Unlock()
+	return database.Name
+}
+
+// Table returns Name(name) for a table that the test creates in a database
+// with an existing pool, and registers the table to be dropped by Cleanup.
+// Tables in databases created with CreateDatabase need not be registered.
+func (ns *Namespace) Table(dbName, name string) string {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	table := ns.Name(name)
+	ns.tables[dbName] = append(ns.tables[dbName], table)
+	return table
+}
+
+// Cleanup drops the tables registered with Table and deletes the databases
+// created with CreateDatabase, reporting failures as test errors. It is called
+// automatically when the test completes, and does nothing after the first call.
+func (ns *Namespace) Cleanup() {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	if ns.cleaned {
+		return
+	}
+	ns.cleaned = true
+	ctx := context.Background()
+	for dbName, tables := range ns.tables {
+		p, err := ns.b.GetPool(dbName)
+		if err != nil {
+			ns.tb.Errorf("unable to drop tables %v on db %s: %v", tables, dbName, err)
+			continue
+		}
+		if _, err = p.Exec(ctx, "DROP TABLE IF EXISTS "+strings.Join(tables, ", ")); err != nil {
+			ns.tb.Errorf("unable to drop tables %v on db %s: %v", tables, dbName, err)
+		}
+	}
+	for _, fullName := range ns.databases {
+		ns.b.ClosePool(fullName)
+		username, dbName, _ := strings.Cut(fullName, "/")
+		if err := ns.b.DeleteDatabase(username, dbName, bitdotio.WithConfirm(fullName)); err != nil {
+			ns.tb.Errorf("unable to delete database %s: %v", fullName, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package bitdotio_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// newExampleClient returns a client for the examples, connected to a fake
+// bit.io API so that go test can check their output, and a function that
+// stops the fake. Outside of examples, connect to bit.io with an API key:
+//
+//	b := bitdotio.NewBitDotIO(os.Getenv("BITDOTIO_TOKEN"))
+func newExampleClient() (*bitdotio.BitDotIO, func()) {
+	server := httptest.NewServer(nil)
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.Method + " " + strings.TrimPrefix(r.URL.Path, "/v2beta")
+		switch route {
+		case "GET /db/":
+			io.WriteString(w, `{"databases": [
+				{"id": "1", "name": "username/demo", "role": "owner", "date_created": "2023-01-02T03:04:05Z"},
+				{"id": "2", "name": "username/iris", "role": "owner", "date_created": "2023-01-02T03:04:05Z"}
+			]}`)
+		case "GET /db/username/demo":
+			io.WriteString(w, `{"id": "1", "name": "username/demo", "role": "owner", "date_created": "2023-01-02T03:04:05Z",
+				"usage_current": {"rows_queried": 150, "period_start": "2023-01-01", "period_end": "2023-02-01"}}`)
+		case "DELETE /db/username/demo":
+			w.WriteHeader(http.StatusNoContent)
+		case "GET /service-account/":
+			io.WriteString(w, `{"service_accounts": [
+				{"id": "sa-1", "name": "etl", "role": "reader", "date_created": "2023-01-02T03:04:05Z",
+					"databases": [{"id": "1", "name": "username/demo"}]}
+			]}`)
+		case "POST /query":
+			io.WriteString(w, `{"metadata": {"id": "integer", "message": "text"}, "data": [[1, "hello"], [2, "world"]]}`)
+		case "POST /db/username/demo/import/":
+			io.WriteString(w, `{"id": "import-1", "state": "RUNNING"}`)
+		case "GET /import/import-1":
+			io.WriteString(w, `{"id": "import-1", "state": "DONE"}`)
+		case "POST /db/username/demo/export/":
+			io.WriteString(w, `{"id": "export-1", "state": "RUNNING", "export_format": "csv"}`)
+		case "GET /export/export-1":
+			fmt.Fprintf(w, `{"id": "export-1", "state": "DONE", "export_format": "csv", "download_url": "%s/download/export-1.csv"}`, server.URL)
+		case "GET /download/export-1.csv":
+			io.WriteString(w, "id,message\n1,hello\n2,world\n")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	return bitdotio.NewBitDotIO("token", bitdotio.WithAPIURL(server.URL)), server.Close
+}
+
+func ExampleBitDotIO_ListDatabases() {
+	b, done := newExampleClient()
+	defer done()
+
+	databases, err := b.ListDatabases()
+	if err != nil {
+		fmt.Println("failed to list databases:", err)
+		return
+	}
+	fmt.Printf("Found %d databases:\n", len(databases))
+	for _, db := range databases {
+		fmt.Printf("- %s\n", db.Name)
+	}
+	// Output:
+	// Found 2 databases:
+	// - username/demo
+	// - username/iris
+}
+
+func ExampleBitDotIO_EnsureDatabase() {
+	b, done := newExampleClient()
+	defer done()
+
+	// EnsureDatabase creates the database unless it exists, so that scripts
+	// can be rerun.
+	database, err := b.EnsureDatabase(bitdotio.NewDatabaseConfig("demo", bitdotio.VisibilityPrivate))
+	if err != nil {
+		fmt.Println("failed to create database:", err)
+		return
+	}
+	fmt.Println(database.Name)
+	// Output:
+	// username/demo
+}
+
+func ExampleBitDotIO_GetDatabase() {
+	b, done := newExampleClient()
+	defer done()
+
+	database, err := b.GetDatabase("username", "demo")
+	if err != nil {
+		fmt.Println("failed to get database:", err)
+		return
+	}
+	if usage := database.UsageCurrent; usage != nil {
+		fmt.Printf("%s queried %d rows from %s to %s\n", database.Name, usage.RowsQueried, usage.PeriodStart, usage.PeriodEnd)
+	}
+	// Output:
+	// username/demo queried 150 rows from 2023-01-01 to 2023-02-01
+}
+
+func ExampleBitDotIO_DeleteDatabase() {
+	b, done := newExampleClient()
+	defer done()
+
+	// WithConfirm is required for destructive calls in protected mode, see
+	// WithProtectedMode, and harmless otherwise.
+	if err := b.DeleteDatabase("username", "demo", bitdotio.WithConfirm("username/demo")); err != nil {
+		fmt.Println("failed to delete database:", err)
+		return
+	}
+	fmt.Println("Deleted database username/demo")
+	// Output:
+	// Deleted database username/demo
+}
+
+func ExampleBitDotIO_ListServiceAccounts() {
+	b, done := newExampleClient()
+	defer done()
+
+	serviceAccounts, err := b.ListServiceAccounts()
+	if err != nil {
+		fmt.Println("failed to list service accounts:", err)
+		return
+	}
+	for _, s := range serviceAccounts {
+		fmt.Printf("%s with role %s can access:\n", s.Name, s.Role)
+		for _, db := range s.Databases {
+			fmt.Printf("- %s\n", db.Name)
+		}
+	}
+	// Output:
+	// etl with role reader can access:
+	// - username/demo
+}
+
+func ExampleBitDotIO_Query() {
+	b, done := newExampleClient()
+	defer done()
+
+	result, err := b.Query("username/demo", "SELECT 1 AS id, 'hello' AS message UNION ALL SELECT 2, 'world'")
+	if err != nil {
+		fmt.Println("failed to query:", err)
+		return
+	}
+	// Rows decode into structs by their db tags.
+	var greetings []struct {
+		ID      int    `db:"id"`
+		Message string `db:"message"`
+	}
+	if err = result.Decode(&greetings); err != nil {
+		fmt.Println("failed to decode query result:", err)
+		return
+	}
+	for _, g := range greetings {
+		fmt.Printf("%d: %s\n", g.ID, g.Message)
+	}
+	// Output:
+	// 1: hello
+	// 2: world
+}
+
+// The pool example needs a bit.io database to connect to, so it is compiled
+// but not run.
+func ExampleBitDotIO_CreatePool() {
+	ctx := context.Background()
+	b := bitdotio.NewBitDotIO(os.Getenv("BITDOTIO_TOKEN"))
+	pool, err := b.CreatePool(ctx, "username/demo")
+	if err != nil {
+		fmt.Println("failed to create pool:", err)
+		return
+	}
+	defer b.ClosePool("username/demo")
+
+	var greeting string
+	if err = pool.QueryRow(ctx, "SELECT 'Hello, world!'").Scan(&greeting); err != nil {
+		fmt.Println("failed to query pool:", err)
+		return
+	}
+	fmt.Println(greeting)
+
+	// QueryContext queries through the pool, and transparently through the
+	// HTTP API when Postgres is unreachable.
+	result, err := b.QueryContext(ctx, "username/demo", "SELECT now() AS now")
+	if err != nil {
+		fmt.Println("failed to query:", err)
+		return
+	}
+	fmt.Println("Server time:", result.Data[0][0])
+}
+
+func ExampleBitDotIO_ImportAndWait() {
+	b, done := newExampleClient()
+	defer done()
+
+	// A FilePath uploads a local file instead.
+	csv := strings.NewReader("id,message\n1,hello\n2,world\n")
+	importJob, err := b.ImportAndWait(context.Background(), "username/demo", "greetings", &bitdotio.ImportJobConfig{
+		File:   csv,
+		Format: bitdotio.ImportFormatCSV,
+	}, &bitdotio.ImportOptions{MaxRetries: 1})
+	if err != nil {
+		fmt.Println("failed to import:", err)
+		return
+	}
+	fmt.Printf("Import job %s finished in state %s\n", importJob.ID, importJob.State)
+	// Output:
+	// Import job import-1 finished in state DONE
+}
+
+func ExampleBitDotIO_DownloadExport() {
+	b, done := newExampleClient()
+	defer done()
+	ctx := context.Background()
+
+	exportJob, err := b.CreateExportJob("username/demo", &bitdotio.ExportJobConfig{TableName: "greetings", ExportFormat: "csv"})
+	if err != nil {
+		fmt.Println("failed to create export job:", err)
+		return
+	}
+	if exportJob, err = b.WaitForExportJob(ctx, exportJob.ID, 0); err != nil {
+		fmt.Println("failed to wait for export job:", err)
+		return
+	}
+	r, err := b.DownloadExport(ctx, exportJob)
+	if err != nil {
+		fmt.Println("failed to download export:", err)
+		return
+	}
+	defer r.Close()
+	if _, err = io.Copy(os.Stdout, r); err != nil {
+		fmt.Println("failed to download export:", err)
+	}
+	// Output:
+	// id,message
+	// 1,hello
+	// 2,world
+}
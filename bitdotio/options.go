@@ -0,0 +1,221 @@
+package bitdotio
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/pool"
+)
+
+// Option configures a BitDotIO client constructed with NewBitDotIO.
+type Option func(*config)
+
+// config collects the configuration of the API client and pool manager of a
+// BitDotIO client.
+type config struct {
+	client    api.ClientConfig
+	manager   pool.ManagerConfig
+	transport Transport
+	// dbTransports overrides transport for individual databases.
+	dbTransports  map[string]Transport
+	probeInterval time.Duration
+	probeTimeout  time.Duration
+	// autoMaxConns and onAcquire are set by WithAutoMaxConns and
+	// WithAcquireHook. They are kept apart from manager.PoolDefaults, so that
+	// WithPoolDefaults does not replace them, and merged into them by
+	// managerConfig.
+	autoMaxConns *pool.AutoMaxConns
+	onAcquire    func(info *pool.AcquireInfo)
+}
+
+// transportFor returns the transport for dbName.
+func (c *config) transportFor(dbName string) Transport {
+	if transport, ok := c.dbTransports[dbName]; ok {
+		return transport
+	}
+	return c.transport
+}
+
+// newConfig applies opts to an empty configuration with a new event bus.
+func newConfig(opts []Option) *config {
+	events := api.NewEventBus()
+	c := &config{}
+	c.client.Events = events
+	c.manager.Events = events
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithHTTPClient sets the HTTP client used for API requests and export
+// downloads.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.client.HTTPClient = httpClient
+	}
+}
+
+// WithHTTPTransport tunes the HTTP transport used for API requests and export
+// downloads, such as its connection pool size, keep-alives, HTTP/2 support, and
+// TLS handshake timeout, see TransportConfig. It is ignored if WithHTTPClient
+// is also set.
+func WithHTTPTransport(transport TransportConfig) Option {
+	return func(c *config) {
+		c.client.Transport = &transport
+	}
+}
+
+// WithLogger sets a logger for diagnostic messages from both the developer API
+// client and the connection pools, such as retries. It is satisfied by
+// *log.Logger.
+func WithLogger(logger Logger) Option {
+	return func(c *config) {
+		c.client.Logger = logger
+		c.manager.Logger = logger
+	}
+}
+
+// WithAPIURL sets the URL of the bit.io developer API service, e.g. for a proxy.
+func WithAPIURL(apiURL string) Option {
+	return func(c *config) {
+		c.client.BaseURL = apiURL
+	}
+}
+
+// WithAPIVersions enables API version negotiation between versions, in order
+// of preference, e.g. WithAPIVersions("v3", "v2beta"), see
+// ClientConfig.APIVersions.
+func WithAPIVersions(versions ...string) Option {
+	return func(c *config) {
+		c.client.APIVersions = versions
+	}
+}
+
+// WithTimeMode controls how QueryContext and Query return date and timestamp
+// values, so that results are consistent between the Postgres and HTTP
+// transports, see TimeMode.
+func WithTimeMode(mode TimeMode) Option {
+	return func(c *config) {
+		c.client.TimeMode = mode
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying failed API requests. By default,
+// API requests are not retried.
+func WithRetryPolicy(policy *RetryPolicy) Option {
+	return func(c *config) {
+		c.client.RetryPolicy = policy
+	}
+}
+
+// WithDefaultTimeout sets the default deadline for each API request, which can
+// be overridden per call with WithTimeout.
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.client.Timeout = timeout
+	}
+}
+
+// WithMaxPollErrors sets the number of consecutive transient failures of job
+// status requests tolerated while waiting for import and export jobs, see
+// ClientConfig.MaxPollErrors.
+func WithMaxPollErrors(n int) Option {
+	return func(c *config) {
+		c.client.MaxPollErrors = n
+	}
+}
+
+// WithUserAgentSuffix appends suffix to the User-Agent header of API requests,
+// e.g. to identify the application or subsystem making requests.
+func WithUserAgentSuffix(suffix string) Option {
+	return func(c *config) {
+		c.client.UserAgentSuffix = suffix
+	}
+}
+
+// WithMetadataCache caches GetDatabase and ListDatabases results for ttl,
+// invalidated by changes made through the same client, see
+// ClientConfig.MetadataCacheTTL.
+func WithMetadataCache(ttl time.Duration) Option {
+	return func(c *config) {
+		c.client.MetadataCacheTTL = ttl
+	}
+}
+
+// WithProtectedMode requires destructive calls, such as DeleteDatabase, to be
+// confirmed with WithConfirm naming the affected resource.
+func WithProtectedMode() Option {
+	return func(c *config) {
+		c.client.ProtectedMode = true
+	}
+}
+
+// WithDBHost sets the host, optionally with a port (e.g. `localhost:6432`), for
+// database connections, e.g. for a local proxy.
+func WithDBHost(host string) Option {
+	return func(c *config) {
+		c.manager.Host = host
+	}
+}
+
+// WithAutoMaxConns sizes pools created with CreatePool from the connection
+// limit of each database's plan, leaving headroom for other clients, instead
+// of the pgxpool default, see AutoMaxConns. It applies regardless of its
+// order relative to WithPoolDefaults, and overrides its AutoMaxConns.
+func WithAutoMaxConns(autoMaxConns AutoMaxConns) Option {
+	return func(c *config) {
+		c.autoMaxConns = &autoMaxConns
+	}
+}
+
+// WithAcquireHook reports every connection acquired for a query, with its wait
+// time and outcome, to onAcquire, for pools created with CreatePool, see
+// PoolConfig.OnAcquire. It applies regardless of its order relative to
+// WithPoolDefaults, and overrides its OnAcquire.
+func WithAcquireHook(onAcquire func(info *AcquireInfo)) Option {
+	return func(c *config) {
+		c.onAcquire = onAcquire
+	}
+}
+
+// WithPoolDefaults sets the configuration for pools created with CreatePool and
+// CreatePoolWithMaxConns.
+func WithPoolDefaults(poolConfig PoolConfig) Option {
+	return func(c *config) {
+		c.manager.PoolDefaults = poolConfig
+	}
+}
+
+// WithTransport sets the transport used by QueryContext for databases without
+// a transport set by WithDatabaseTransport. Defaults to TransportAuto.
+func WithTransport(transport Transport) Option {
+	return func(c *config) {
+		c.transport = transport
+	}
+}
+
+// WithDatabaseTransport sets the transport used by QueryContext for dbName,
+// overriding WithTransport.
+func WithDatabaseTransport(dbName string, transport Transport) Option {
+	return func(c *config) {
+		// Copy rather than mutate, since configs are shared with derived clients.
+		dbTransports := make(map[string]Transport, len(c.dbTransports)+1)
+		for name, t := range c.dbTransports {
+			dbTransports[name] = t
+		}
+		dbTransports[dbName] = transport
+		c.dbTransports = dbTransports
+	}
+}
+
+// WithFailoverProbe sets the interval between health probes of a database that
+// QueryContext failed over to the HTTP API, and the deadline for each probe.
+// Defaults to a probe every 30s with a 5s deadline.
+func WithFailoverProbe(interval, timeout time.Duration) Option {
+	return func(c *config) {
+		c.probeInterval = interval
+		c.probeTimeout = timeout
+	}
+}
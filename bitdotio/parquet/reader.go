@@ -0,0 +1,418 @@
+// Package parquet reads and writes Apache Parquet files with flat schemas,
+// such as bit.io's parquet exports, without dependencies outside the standard
+// library.
+//
+// The reader supports PLAIN and dictionary encoded columns, data pages of
+// both versions, and the UNCOMPRESSED, SNAPPY, and GZIP codecs, which cover
+// files written by pyarrow, pandas, and Spark with their default settings.
+// Nested and repeated columns, the DELTA and BYTE_STREAM_SPLIT encodings, and
+// other codecs are reported as errors. The Writer writes PLAIN encoded data
+// pages of version 1, compressed with SNAPPY by default.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// magic starts and ends every Parquet file.
+const magic = "PAR1"
+
+// maxPageSize and maxPageValues bound the sizes and counts read from a file
+// before allocating buffers for them, so that a corrupt file fails rather
+// than exhausting memory. Writers limit pages to far fewer values.
+const (
+	maxPageSize   = 1 << 30
+	maxPageValues = 1 << 24
+)
+
+// Compression codecs.
+const (
+	codecUncompressed = 0
+	codecSnappy       = 1
+	codecGzip         = 2
+)
+
+// Encodings.
+const (
+	encodingPlain           = 0
+	encodingPlainDictionary = 2
+	encodingRLE             = 3
+	encodingRLEDictionary   = 8
+)
+
+// Page types.
+const (
+	pageData       = 0
+	pageDictionary = 2
+	pageDataV2     = 3
+)
+
+// File is a Parquet file opened for reading with Open.
+type File struct {
+	r         io.ReaderAt
+	size      int64
+	columns   []Column
+	rowGroups []thriftStruct
+	numRows   int64
+}
+
+// Open reads the metadata of the Parquet file of size bytes in r.
+func Open(r io.ReaderAt, size int64) (*File, error) {
+	if size < int64(2*len(magic)+4) {
+		return nil, errors.New("not a parquet file, too short")
+	}
+	tail := make([]byte, 4+len(magic))
+	if _, err := r.ReadAt(tail, size-int64(len(tail))); err != nil {
+		return nil, fmt.Errorf("unable to read parquet footer: %w", err)
+	}
+	head := make([]byte, len(magic))
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, fmt.Errorf("unable to read parquet header: %w", err)
+	}
+	if string(head) != magic || string(tail[4:]) != magic {
+		return nil, errors.New("not a parquet file, missing magic bytes")
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail))
+	if footerSize > size-int64(len(magic)+len(tail)) {
+		return nil, errors.New("corrupt parquet file, footer is larger than the file")
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-int64(len(tail))-footerSize); err != nil {
+		return nil, fmt.Errorf("unable to read parquet footer: %w", err)
+	}
+	d := &thriftDecoder{data: footer}
+	metadata, err := d.readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("corrupt parquet metadata: %w", err)
+	}
+	columns, err := parseSchema(metadata.list(2))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported parquet schema: %w", err)
+	}
+	f := &File{r: r, size: size, columns: columns, numRows: metadata.int(3)}
+	for i, g := range metadata.list(4) {
+		rowGroup, _ := g.(thriftStruct)
+		if len(rowGroup.list(1)) != len(columns) {
+			return nil, fmt.Errorf("corrupt parquet metadata, row group %d has %d columns, but the schema has %d", i, len(rowGroup.list(1)), len(columns))
+		}
+		f.rowGroups = append(f.rowGroups, rowGroup)
+	}
+	return f, nil
+}
+
+// Columns returns the columns of f, in order.
+func (f *File) Columns() []Column {
+	return append([]Column(nil), f.columns...)
+}
+
+// NumRows returns the number of rows in f.
+func (f *File) NumRows() int64 {
+	return f.numRows
+}
+
+// NumRowGroups returns the number of row groups in f, the units in which rows
+// are decoded.
+func (f *File) NumRowGroups() int {
+	return len(f.rowGroups)
+}
+
+// ReadRowGroup decodes the rows of row group i. Each row has a value per
+// column, of the Go type for its physical and logical type, or nil for NULL:
+//
+//   - Boolean: bool
+//   - Int32 and Int64: int32 and int64
+//   - Float and Double: float32 and float64
+//   - Int96: time.Time, for legacy timestamps
+//   - ByteArray and FixedLenByteArray: []byte
+//   - LogicalString, LogicalJSON, LogicalEnum, LogicalUUID, and
+//     LogicalDecimal: string
+//   - LogicalDate and LogicalTimestamp: time.Time in UTC
+//   - LogicalTime: time.Duration
+func (f *File) ReadRowGroup(i int) ([][]interface{}, error) {
+	if i < 0 || i >= len(f.rowGroups) {
+		return nil, fmt.Errorf("row group %d out of range, file has %d", i, len(f.rowGroups))
+	}
+	rowGroup := f.rowGroups[i]
+	numRows := rowGroup.int(3)
+	// Rows are allocated once the columns are decoded, so that their number
+	// is bounded by the data rather than by the metadata.
+	columns := make([][]interface{}, len(f.columns))
+	for j, c := range rowGroup.list(1) {
+		chunk, _ := c.(thriftStruct)
+		values, err := f.readColumnChunk(&f.columns[j], chunk)
+		if err == nil && int64(len(values)) != numRows {
+			err = fmt.Errorf("has %d values, want %d", len(values), numRows)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read row group %d column %s: %w", i, f.columns[j].Name, err)
+		}
+		columns[j] = values
+	}
+	rows := make([][]interface{}, numRows)
+	for r := range rows {
+		rows[r] = make([]interface{}, len(f.columns))
+		for j, values := range columns {
+			rows[r][j] = values[r]
+		}
+	}
+	return rows, nil
+}
+
+// Rows returns an iterator over the rows of f, decoding a row group at a time.
+func (f *File) Rows() *Rows {
+	return &Rows{file: f}
+}
+
+// Rows is an iterator over the rows of a File, used like pgx.Rows:
+//
+//	rows := f.Rows()
+//	for rows.Next() {
+//		values := rows.Values()
+//		...
+//	}
+//	if err := rows.Err(); err != nil {
+//		...
+//	}
+type Rows struct {
+	file     *File
+	rowGroup int
+	rows     [][]interface{}
+	row      []interface{}
+	err      error
+}
+
+// Columns returns the columns of the rows, in order.
+func (r *Rows) Columns() []Column {
+	return r.file.Columns()
+}
+
+// Next advances to the next row, returning false at the end of the rows or on
+// an error, which Err returns.
+func (r *Rows) Next() bool {
+	for len(r.rows) == 0 {
+		if r.err != nil || r.rowGroup == len(r.file.rowGroups) {
+			r.row = nil
+			return false
+		}
+		r.rows, r.err = r.file.ReadRowGroup(r.rowGroup)
+		r.rowGroup++
+	}
+	r.row, r.rows = r.rows[0], r.rows[1:]
+	return true
+}
+
+// Values returns the values of the current row, as described by
+// File.ReadRowGroup. The slice is not reused by later rows.
+func (r *Rows) Values() []interface{} {
+	return r.row
+}
+
+// Err returns the error that stopped Next, if any.
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// readColumnChunk decodes the values of a column chunk, with nil for NULLs.
+func (f *File) readColumnChunk(column *Column, chunk thriftStruct) ([]interface{}, error) {
+	if chunk.string(1) != "" {
+		return nil, errors.New("columns in external files are not supported")
+	}
+	metadata := chunk.structField(3)
+	if metadata == nil {
+		return nil, errors.New("corrupt parquet metadata, column chunk has no metadata")
+	}
+	offset := metadata.int(9)
+	if dictionaryOffset := metadata.int(11); dictionaryOffset > 0 && dictionaryOffset < offset {
+		offset = dictionaryOffset
+	}
+	size := metadata.int(7)
+	if offset < 0 || size < 0 || size > maxPageSize || offset+size > f.size {
+		return nil, errors.New("corrupt parquet metadata, column chunk is outside the file")
+	}
+	data := make([]byte, size)
+	if _, err := f.r.ReadAt(data, offset); err != nil {
+		return nil, err
+	}
+
+	codec := int(metadata.int(4))
+	numValues := metadata.int(5)
+	var values []interface{}
+	var dictionary []interface{}
+	d := &thriftDecoder{data: data}
+	for int64(len(values)) < numValues {
+		header, err := d.readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("corrupt page header: %w", err)
+		}
+		compressedSize := header.int(3)
+		if compressedSize < 0 || compressedSize > int64(len(data)-d.pos) {
+			return nil, errors.New("corrupt page header, page is outside the column chunk")
+		}
+		page := data[d.pos : d.pos+int(compressedSize)]
+		d.pos += int(compressedSize)
+		uncompressedSize := int(header.int(2))
+		if uncompressedSize < 0 || uncompressedSize > maxPageSize {
+			return nil, errors.New("corrupt page header, page is too large")
+		}
+
+		switch header.int(1) {
+		case pageDictionary:
+			if page, err = decompress(codec, page, uncompressedSize); err != nil {
+				return nil, err
+			}
+			h := header.structField(7)
+			if h.int(2) != encodingPlain && h.int(2) != encodingPlainDictionary {
+				return nil, fmt.Errorf("unsupported dictionary encoding %d", h.int(2))
+			}
+			if h.int(1) < 0 || h.int(1) > maxPageValues {
+				return nil, errors.New("corrupt page header, invalid number of values")
+			}
+			if dictionary, err = readPlain(column, page, int(h.int(1))); err != nil {
+				return nil, err
+			}
+		case pageData:
+			h := header.structField(5)
+			if page, err = decompress(codec, page, uncompressedSize); err != nil {
+				return nil, err
+			}
+			var levels []byte
+			if column.Optional {
+				// Definition levels are prefixed with their length in v1 pages.
+				if len(page) < 4 || int64(binary.LittleEndian.Uint32(page)) > int64(len(page)-4) {
+					return nil, errValuesTruncated
+				}
+				length := binary.LittleEndian.Uint32(page)
+				levels, page = page[4:4+length], page[4+length:]
+			}
+			if values, err = readDataPage(column, values, levels, page, int(h.int(1)), int(h.int(2)), dictionary); err != nil {
+				return nil, err
+			}
+		case pageDataV2:
+			h := header.structField(8)
+			levelsSize, repetitionSize := h.int(5), h.int(6)
+			if levelsSize < 0 || repetitionSize != 0 || levelsSize > int64(len(page)) {
+				return nil, errors.New("corrupt page header, invalid level sizes")
+			}
+			// Levels are not compressed in v2 pages.
+			levels, page := page[:levelsSize], page[levelsSize:]
+			if !h.has(7) || h.bool(7) {
+				if page, err = decompress(codec, page, uncompressedSize-int(levelsSize)); err != nil {
+					return nil, err
+				}
+			}
+			if values, err = readDataPage(column, values, levels, page, int(h.int(1)), int(h.int(4)), dictionary); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// readDataPage appends the n values of a data page to values, given its
+// definition levels, if column is optional, and its encoded values.
+func readDataPage(column *Column, values []interface{}, levels, data []byte, n, encoding int, dictionary []interface{}) ([]interface{}, error) {
+	if n < 0 || n > maxPageValues {
+		return nil, errors.New("corrupt page header, invalid number of values")
+	}
+	defined := make([]bool, n)
+	nonNull := n
+	if column.Optional {
+		definitionLevels, err := readRLE(levels, 1, n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid definition levels: %w", err)
+		}
+		nonNull = 0
+		for i, level := range definitionLevels {
+			if defined[i] = level == 1; defined[i] {
+				nonNull++
+			}
+		}
+	} else {
+		for i := range defined {
+			defined[i] = true
+		}
+	}
+
+	var pageValues []interface{}
+	var err error
+	switch encoding {
+	case encodingPlain:
+		pageValues, err = readPlain(column, data, nonNull)
+	case encodingPlainDictionary, encodingRLEDictionary:
+		if dictionary == nil {
+			return nil, errors.New("dictionary encoded page without a dictionary page")
+		}
+		if len(data) == 0 {
+			if nonNull > 0 {
+				return nil, errValuesTruncated
+			}
+			break
+		}
+		var indices []uint32
+		if indices, err = readRLE(data[1:], int(data[0]), nonNull); err != nil {
+			return nil, fmt.Errorf("invalid dictionary indices: %w", err)
+		}
+		pageValues = make([]interface{}, nonNull)
+		for i, index := range indices {
+			if int(index) >= len(dictionary) {
+				return nil, fmt.Errorf("dictionary index %d out of range", index)
+			}
+			pageValues[i] = dictionary[index]
+		}
+	case encodingRLE:
+		if column.Type != Boolean {
+			return nil, fmt.Errorf("RLE encoding of %v values is not supported", column.Type)
+		}
+		if len(data) < 4 || int64(binary.LittleEndian.Uint32(data)) > int64(len(data)-4) {
+			return nil, errValuesTruncated
+		}
+		var bits []uint32
+		if bits, err = readRLE(data[4:4+binary.LittleEndian.Uint32(data)], 1, nonNull); err != nil {
+			return nil, err
+		}
+		pageValues = make([]interface{}, nonNull)
+		for i, bit := range bits {
+			pageValues[i] = bit == 1
+		}
+	default:
+		return nil, fmt.Errorf("unsupported encoding %d", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, isDefined := range defined {
+		if isDefined {
+			values = append(values, pageValues[0])
+			pageValues = pageValues[1:]
+		} else {
+			values = append(values, nil)
+		}
+	}
+	return values, nil
+}
+
+// decompress decompresses a page of size bytes compressed with codec.
+func decompress(codec int, data []byte, size int) ([]byte, error) {
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		return snappyDecode(data, size)
+	case codecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("corrupt gzip page: %w", err)
+		}
+		page := make([]byte, size)
+		if _, err = io.ReadFull(r, page); err != nil {
+			return nil, fmt.Errorf("corrupt gzip page: %w", err)
+		}
+		return page, nil
+	}
+	return nil, fmt.Errorf("unsupported compression codec %d", codec)
+}
@@ -0,0 +1,137 @@
+package parquet
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// exportRows are the rows of testdata/export.parquet, written by
+// testdata/make_export.py.
+var exportRows = [][]interface{}{
+	{int64(1), "alpha", 1.5, true, date(2023, 1, 2), time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC), "12.50", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", strings.Repeat("ok ", 30)},
+	{int64(2), nil, nil, false, nil, nil, "-0.07", "00000000-0000-0000-0000-000000000000", nil},
+	{int64(3), "beta", -2.25, true, date(1969, 12, 31), time.Unix(0, 0).UTC(), "100000.00", "ffffffff-ffff-ffff-ffff-ffffffffffff", "abcabcabcabcabcabcabcabcabcabcabc"},
+	{int64(4), "alpha", 0.0, false, date(2024, 2, 29), time.Date(1969, 12, 31, 23, 59, 59, 999999000, time.UTC), "0.00", "123e4567-e89b-12d3-a456-426614174000", ""},
+	{int64(5), "alpha", nil, true, nil, nil, "-12345678.90", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", strings.Repeat("ok ", 30)},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func openExport(t *testing.T) *File {
+	t.Helper()
+	data, err := os.ReadFile("testdata/export.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestOpen(t *testing.T) {
+	f := openExport(t)
+	want := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "name", Type: ByteArray, Logical: LogicalString, Optional: true},
+		{Name: "score", Type: Double, Optional: true},
+		{Name: "active", Type: Boolean},
+		{Name: "day", Type: Int32, Logical: LogicalDate, Optional: true},
+		{Name: "created_at", Type: Int64, Logical: LogicalTimestamp, Unit: Micros, UTC: true, Optional: true},
+		{Name: "price", Type: FixedLenByteArray, Logical: LogicalDecimal, Precision: 11, Scale: 2, Length: 5},
+		{Name: "uid", Type: FixedLenByteArray, Logical: LogicalUUID, Length: 16},
+		{Name: "note", Type: ByteArray, Logical: LogicalString, Optional: true},
+	}
+	if got := f.Columns(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns = %+v, want %+v", got, want)
+	}
+	if f.NumRows() != 5 || f.NumRowGroups() != 2 {
+		t.Errorf("NumRows, NumRowGroups = %d, %d, want 5, 2", f.NumRows(), f.NumRowGroups())
+	}
+}
+
+func TestRows(t *testing.T) {
+	f := openExport(t)
+	rows := f.Rows()
+	var got [][]interface{}
+	for rows.Next() {
+		got = append(got, rows.Values())
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(exportRows) {
+		t.Fatalf("read %d rows, want %d", len(got), len(exportRows))
+	}
+	for i := range got {
+		if !reflect.DeepEqual(got[i], exportRows[i]) {
+			t.Errorf("row %d = %#v, want %#v", i, got[i], exportRows[i])
+		}
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	data, err := os.ReadFile("testdata/export.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, corrupt := range map[string][]byte{
+		"empty":     nil,
+		"not magic": append([]byte("PAR0"), data[4:]...),
+		"truncated": data[:len(data)/2],
+	} {
+		if _, err := Open(bytes.NewReader(corrupt), int64(len(corrupt))); err == nil {
+			t.Errorf("Open(%s) succeeded, want an error", name)
+		}
+	}
+
+	// Damaging the pages must fail to read, rather than panic.
+	for i := range data {
+		damaged := append([]byte(nil), data...)
+		damaged[i] ^= 0xff
+		f, err := Open(bytes.NewReader(damaged), int64(len(damaged)))
+		if err != nil {
+			continue
+		}
+		rows := f.Rows()
+		for rows.Next() {
+		}
+	}
+}
+
+// TestPyarrowGolden reads testdata/pyarrow.parquet, written by pyarrow with
+// testdata/pyarrow_golden.py, which has the rows of exportRows without uid.
+func TestPyarrowGolden(t *testing.T) {
+	data, err := os.ReadFile("testdata/pyarrow.parquet")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/pyarrow.parquet is missing; run testdata/pyarrow_golden.py")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := f.Columns(), goldenColumns; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns = %+v, want %+v", got, want)
+	}
+	rows := f.Rows()
+	var got [][]interface{}
+	for rows.Next() {
+		got = append(got, rows.Values())
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := goldenRows(); !reflect.DeepEqual(got, want) {
+		t.Errorf("rows = %#v, want %#v", got, want)
+	}
+}
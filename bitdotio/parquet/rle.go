@@ -0,0 +1,71 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errRLECorrupt = errors.New("corrupt RLE data")
+
+// readRLE decodes n values of bitWidth bits in the RLE/bit-packing hybrid
+// encoding, used for definition levels, dictionary indices, and booleans.
+func readRLE(data []byte, bitWidth int, n int) ([]uint32, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, errRLECorrupt
+	}
+	values := make([]uint32, 0, n)
+	byteWidth := (bitWidth + 7) / 8
+	for len(values) < n {
+		header, k := binary.Uvarint(data)
+		if k <= 0 {
+			return nil, errRLECorrupt
+		}
+		data = data[k:]
+		if header&1 == 0 {
+			// A run of one value repeated, in byteWidth little-endian bytes.
+			count := header >> 1
+			if len(data) < byteWidth {
+				return nil, errRLECorrupt
+			}
+			var v uint32
+			for i := byteWidth - 1; i >= 0; i-- {
+				v = v<<8 | uint32(data[i])
+			}
+			data = data[byteWidth:]
+			for ; count > 0 && len(values) < n; count-- {
+				values = append(values, v)
+			}
+			continue
+		}
+		// Groups of 8 values packed in bitWidth bits each, least significant
+		// bit first. The last group may be padded beyond n values.
+		groups := header >> 1
+		if groups > uint64(len(data)) {
+			return nil, errRLECorrupt
+		}
+		size := int(groups) * bitWidth
+		if len(data) < size {
+			return nil, errRLECorrupt
+		}
+		mask := uint64(1)<<uint(bitWidth) - 1
+		for i := 0; i < int(groups)*8 && len(values) < n; i++ {
+			bit := i * bitWidth
+			var word uint64
+			for j := bit / 8; j < (bit+bitWidth+7)/8; j++ {
+				word |= uint64(data[j]) << uint((j-bit/8)*8)
+			}
+			values = append(values, uint32(word>>uint(bit%8)&mask))
+		}
+		data = data[size:]
+	}
+	return values, nil
+}
+
+// bitWidth returns the number of bits needed to store values up to max.
+func bitWidth(max int) int {
+	width := 0
+	for ; max > 0; max >>= 1 {
+		width++
+	}
+	return width
+}
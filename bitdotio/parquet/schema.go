@@ -0,0 +1,287 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Type is the physical type in which a Parquet column stores its values.
+type Type int
+
+const (
+	Boolean           Type = 0
+	Int32             Type = 1
+	Int64             Type = 2
+	Int96             Type = 3
+	Float             Type = 4
+	Double            Type = 5
+	ByteArray         Type = 6
+	FixedLenByteArray Type = 7
+)
+
+func (t Type) String() string {
+	switch t {
+	case Boolean:
+		return "BOOLEAN"
+	case Int32:
+		return "INT32"
+	case Int64:
+		return "INT64"
+	case Int96:
+		return "INT96"
+	case Float:
+		return "FLOAT"
+	case Double:
+		return "DOUBLE"
+	case ByteArray:
+		return "BYTE_ARRAY"
+	case FixedLenByteArray:
+		return "FIXED_LEN_BYTE_ARRAY"
+	}
+	return fmt.Sprintf("Type(%d)", int(t))
+}
+
+// Logical is the logical type of a Parquet column, which annotates how the
+// values of its physical type are interpreted.
+type Logical int
+
+const (
+	// LogicalNone reads values as their physical type.
+	LogicalNone Logical = iota
+	// LogicalString is UTF-8 text in a ByteArray, read as string.
+	LogicalString
+	// LogicalJSON is JSON text in a ByteArray, read as string.
+	LogicalJSON
+	// LogicalEnum is an enum label in a ByteArray, read as string.
+	LogicalEnum
+	// LogicalUUID is a UUID in a FixedLenByteArray of length 16, read as its
+	// canonical string form.
+	LogicalUUID
+	// LogicalDate is the number of days since the Unix epoch in an Int32, read
+	// as a time.Time at midnight UTC.
+	LogicalDate
+	// LogicalTime is the time of day in an Int32 or Int64 of Unit, read as a
+	// time.Duration since midnight.
+	LogicalTime
+	// LogicalTimestamp is a time since the Unix epoch in an Int64 of Unit,
+	// read as a time.Time in UTC.
+	LogicalTimestamp
+	// LogicalDecimal is a decimal with Precision and Scale, stored as an
+	// unscaled integer in an Int32, Int64, ByteArray, or FixedLenByteArray,
+	// and read exactly as its decimal string, e.g. "-12.50".
+	LogicalDecimal
+)
+
+func (l Logical) String() string {
+	switch l {
+	case LogicalNone:
+		return "NONE"
+	case LogicalString:
+		return "STRING"
+	case LogicalJSON:
+		return "JSON"
+	case LogicalEnum:
+		return "ENUM"
+	case LogicalUUID:
+		return "UUID"
+	case LogicalDate:
+		return "DATE"
+	case LogicalTime:
+		return "TIME"
+	case LogicalTimestamp:
+		return "TIMESTAMP"
+	case LogicalDecimal:
+		return "DECIMAL"
+	}
+	return fmt.Sprintf("Logical(%d)", int(l))
+}
+
+// TimeUnit is the precision of LogicalTime and LogicalTimestamp values.
+type TimeUnit int
+
+const (
+	Millis TimeUnit = iota
+	Micros
+	Nanos
+)
+
+func (u TimeUnit) String() string {
+	switch u {
+	case Millis:
+		return "MILLIS"
+	case Micros:
+		return "MICROS"
+	case Nanos:
+		return "NANOS"
+	}
+	return fmt.Sprintf("TimeUnit(%d)", int(u))
+}
+
+// Column describes a column of a Parquet file. Only flat schemas, of
+// required or optional primitive columns, are supported.
+type Column struct {
+	Name    string
+	Type    Type
+	Logical Logical
+	// Unit is the precision of LogicalTime and LogicalTimestamp columns.
+	Unit TimeUnit
+	// UTC reports whether LogicalTimestamp values are instants, rather than
+	// local date-times, as for timestamptz rather than timestamp.
+	UTC bool
+	// Precision and Scale are the digits of LogicalDecimal columns.
+	Precision int
+	Scale     int
+	// Length is the size of FixedLenByteArray values.
+	Length int
+	// Optional reports whether values may be NULL.
+	Optional bool
+}
+
+// Parquet converted types, the annotations that logical types superseded but
+// that writers still set alongside them.
+const (
+	convertedUTF8            = 0
+	convertedEnum            = 4
+	convertedDecimal         = 5
+	convertedDate            = 6
+	convertedTimeMillis      = 7
+	convertedTimeMicros      = 8
+	convertedTimestampMillis = 9
+	convertedTimestampMicros = 10
+	convertedJSON            = 19
+)
+
+// Field IDs of the LogicalType union.
+const (
+	logicalString    = 1
+	logicalEnum      = 4
+	logicalDecimal   = 5
+	logicalDate      = 6
+	logicalTime      = 7
+	logicalTimestamp = 8
+	logicalJSON      = 12
+	logicalUUID      = 14
+)
+
+// maxDecimalPrecision is the largest precision of decimal columns, that of
+// Postgres's numeric type.
+const maxDecimalPrecision = 1000
+
+// Repetition types of schema elements.
+const (
+	repetitionRequired = 0
+	repetitionOptional = 1
+	repetitionRepeated = 2
+)
+
+// parseSchema returns the columns described by the schema elements of a
+// file's metadata, the first of which is the root.
+func parseSchema(elements []interface{}) ([]Column, error) {
+	if len(elements) == 0 {
+		return nil, errors.New("schema is empty")
+	}
+	root, _ := elements[0].(thriftStruct)
+	if int(root.int(5)) != len(elements)-1 {
+		return nil, errors.New("nested schemas are not supported")
+	}
+	columns := make([]Column, len(elements)-1)
+	for i, e := range elements[1:] {
+		element, _ := e.(thriftStruct)
+		if element == nil || !element.has(1) || element.int(5) > 0 {
+			return nil, fmt.Errorf("column %d: nested schemas are not supported", i)
+		}
+		column := Column{
+			Name:   element.string(4),
+			Type:   Type(element.int(1)),
+			Length: int(element.int(2)),
+		}
+		switch element.int(3) {
+		case repetitionRequired:
+		case repetitionOptional:
+			column.Optional = true
+		default:
+			return nil, fmt.Errorf("column %s: repeated columns are not supported", column.Name)
+		}
+		if column.Type < Boolean || column.Type > FixedLenByteArray {
+			return nil, fmt.Errorf("column %s: unknown type %v", column.Name, column.Type)
+		}
+		if logical := element.structField(10); logical != nil {
+			parseLogicalType(&column, logical)
+		} else if element.has(6) {
+			parseConvertedType(&column, element)
+		}
+		if column.Logical == LogicalDecimal && (column.Scale < 0 || column.Scale > column.Precision || column.Precision > maxDecimalPrecision) {
+			return nil, fmt.Errorf("column %s: invalid decimal precision %d and scale %d", column.Name, column.Precision, column.Scale)
+		}
+		columns[i] = column
+	}
+	return columns, nil
+}
+
+// parseLogicalType sets the logical type of column from a LogicalType union.
+// Logical types that do not change how values are read, such as INTEGER, are
+// ignored.
+func parseLogicalType(column *Column, logical thriftStruct) {
+	switch {
+	case logical.has(logicalString):
+		column.Logical = LogicalString
+	case logical.has(logicalJSON):
+		column.Logical = LogicalJSON
+	case logical.has(logicalEnum):
+		column.Logical = LogicalEnum
+	case logical.has(logicalUUID):
+		column.Logical = LogicalUUID
+	case logical.has(logicalDate):
+		column.Logical = LogicalDate
+	case logical.has(logicalDecimal):
+		decimal := logical.structField(logicalDecimal)
+		column.Logical = LogicalDecimal
+		column.Scale, column.Precision = int(decimal.int(1)), int(decimal.int(2))
+	case logical.has(logicalTime):
+		t := logical.structField(logicalTime)
+		column.Logical = LogicalTime
+		column.UTC, column.Unit = t.bool(1), parseTimeUnit(t.structField(2))
+	case logical.has(logicalTimestamp):
+		t := logical.structField(logicalTimestamp)
+		column.Logical = LogicalTimestamp
+		column.UTC, column.Unit = t.bool(1), parseTimeUnit(t.structField(2))
+	}
+}
+
+func parseTimeUnit(unit thriftStruct) TimeUnit {
+	switch {
+	case unit.has(2):
+		return Micros
+	case unit.has(3):
+		return Nanos
+	}
+	return Millis
+}
+
+// parseConvertedType sets the logical type of column from the converted type
+// of a schema element, for files written before logical types.
+func parseConvertedType(column *Column, element thriftStruct) {
+	switch element.int(6) {
+	case convertedUTF8:
+		column.Logical = LogicalString
+	case convertedJSON:
+		column.Logical = LogicalJSON
+	case convertedEnum:
+		column.Logical = LogicalEnum
+	case convertedDate:
+		column.Logical = LogicalDate
+	case convertedDecimal:
+		column.Logical = LogicalDecimal
+		column.Scale, column.Precision = int(element.int(7)), int(element.int(8))
+	case convertedTimeMillis, convertedTimeMicros:
+		column.Logical, column.UTC = LogicalTime, true
+		if element.int(6) == convertedTimeMicros {
+			column.Unit = Micros
+		}
+	case convertedTimestampMillis, convertedTimestampMicros:
+		column.Logical, column.UTC = LogicalTimestamp, true
+		if element.int(6) == convertedTimestampMicros {
+			column.Unit = Micros
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var errSnappyCorrupt = errors.New("corrupt snappy data")
+
+// snappyDecode decodes a block in the Snappy format, as used for SNAPPY
+// compressed pages, which is the default codec of most Parquet writers.
+func snappyDecode(src []byte, maxLen int) ([]byte, error) {
+	n, k := binary.Uvarint(src)
+	if k <= 0 || n > uint64(maxLen) {
+		return nil, errSnappyCorrupt
+	}
+	src = src[k:]
+	dst := make([]byte, 0, n)
+	for len(src) > 0 {
+		tag := src[0]
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			// A literal, whose length-1 follows the tag if it does not fit in it.
+			length = int(tag >> 2)
+			src = src[1:]
+			if length >= 60 {
+				size := length - 59
+				if len(src) < size {
+					return nil, errSnappyCorrupt
+				}
+				length = 0
+				for i := size - 1; i >= 0; i-- {
+					length = length<<8 | int(src[i])
+				}
+				src = src[size:]
+			}
+			length++
+			if length <= 0 || length > len(src) || length > int(n)-len(dst) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[:length]...)
+			src = src[length:]
+			continue
+		case 1:
+			if len(src) < 2 {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2)&7
+			offset = int(tag>>5)<<8 | int(src[1])
+			src = src[2:]
+		case 2:
+			if len(src) < 3 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[1:]))
+			src = src[3:]
+		case 3:
+			if len(src) < 5 {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[1:]))
+			src = src[5:]
+		}
+		// Copies may overlap the bytes they produce, so copy byte by byte.
+		if offset <= 0 || offset > len(dst) || length > int(n)-len(dst) {
+			return nil, errSnappyCorrupt
+		}
+		start := len(dst) - offset
+		for i := 0; i < length; i++ {
+			dst = append(dst, dst[start+i])
+		}
+	}
+	if len(dst) != int(n) {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
+
+// snappyEncode encodes src as a Snappy block, finding repeated sequences of
+// 4 or more bytes with a hash table of recent positions.
+func snappyEncode(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)+len(src)/6+16), uint64(len(src)))
+	var table [1 << 14]int32
+	literalStart := 0
+	for i := 0; i+4 <= len(src); {
+		key := binary.LittleEndian.Uint32(src[i:])
+		h := key * 0x1e35a7bd >> 18
+		// Positions are stored plus one, so that 0 means none.
+		candidate := int(table[h]) - 1
+		table[h] = int32(i + 1)
+		if candidate < 0 || i-candidate > 0xffff || binary.LittleEndian.Uint32(src[candidate:]) != key {
+			i++
+			continue
+		}
+		length := 4
+		for i+length < len(src) && src[candidate+length] == src[i+length] {
+			length++
+		}
+		dst = snappyLiteral(dst, src[literalStart:i])
+		dst = snappyCopy(dst, i-candidate, length)
+		i += length
+		literalStart = i
+	}
+	return snappyLiteral(dst, src[literalStart:])
+}
+
+// snappyLiteral appends a literal of b to dst.
+func snappyLiteral(dst, b []byte) []byte {
+	if len(b) == 0 {
+		return dst
+	}
+	n := len(b) - 1
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2)
+	case n < 1<<8:
+		dst = append(dst, 60<<2, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(dst, b...)
+}
+
+// snappyCopy appends copies of length bytes from offset bytes back to dst,
+// in pieces of at most 64 bytes.
+func snappyCopy(dst []byte, offset, length int) []byte {
+	for length > 0 {
+		n := length
+		if n > 64 {
+			n = 64
+		}
+		if n >= 4 && n <= 11 && offset < 2048 {
+			dst = append(dst, byte(offset>>8)<<5|byte(n-4)<<2|1, byte(offset))
+		} else {
+			dst = append(dst, byte(n-1)<<2|2, byte(offset), byte(offset>>8))
+		}
+		length -= n
+	}
+	return dst
+}
@@ -0,0 +1,343 @@
+#!/usr/bin/env python3
+"""Writes export.parquet, the fixture for the parquet reader tests.
+
+The file imitates a bit.io parquet export, as written by pyarrow, with
+optional columns, dictionary encoding, SNAPPY and GZIP pages, a data page v2,
+and two row groups. It is written with the standard library only, independent
+of the Go package, so that the tests do not check the reader against itself:
+
+    python3 make_export.py
+"""
+
+import datetime
+import gzip
+import struct
+import uuid
+
+ROWS = [
+    # id, name, score, active, day, created_at, price, uid, note
+    (1, "alpha", 1.5, True, datetime.date(2023, 1, 2),
+     datetime.datetime(2023, 1, 2, 3, 4, 5, 678901), 1250,
+     "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "ok " * 30),
+    (2, None, None, False, None, None, -7,
+     "00000000-0000-0000-0000-000000000000", None),
+    (3, "beta", -2.25, True, datetime.date(1969, 12, 31),
+     datetime.datetime(1970, 1, 1), 10000000,
+     "ffffffff-ffff-ffff-ffff-ffffffffffff", "abcabcabcabcabcabcabcabcabcabcabc"),
+    (4, "alpha", 0.0, False, datetime.date(2024, 2, 29),
+     datetime.datetime(1969, 12, 31, 23, 59, 59, 999999), 0,
+     "123e4567-e89b-12d3-a456-426614174000", ""),
+    (5, "alpha", None, True, None, None, -1234567890,
+     "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "ok " * 30),
+]
+ROW_GROUPS = [ROWS[:3], ROWS[3:]]
+
+# Thrift compact protocol.
+
+I32, I64, BINARY, LIST, STRUCT = 5, 6, 8, 9, 12
+
+
+def varint(n):
+    out = bytearray()
+    while True:
+        b = n & 0x7F
+        n >>= 7
+        if n:
+            out.append(b | 0x80)
+        else:
+            out.append(b)
+            return bytes(out)
+
+
+def zigzag(n):
+    return varint((n << 1) ^ (n >> 63))
+
+
+def i32(n):
+    return (I32, zigzag(n))
+
+
+def i64(n):
+    return (I64, zigzag(n))
+
+
+def binary(s):
+    if isinstance(s, str):
+        s = s.encode()
+    return (BINARY, varint(len(s)) + s)
+
+
+def boolean(b):
+    return (1 if b else 2, b"")
+
+
+def struct_(fields):
+    """Encodes a struct from (field id, (type, payload)) pairs."""
+    out = bytearray()
+    last = 0
+    for fid, (typ, payload) in sorted(fields):
+        delta = fid - last
+        if 0 < delta <= 15:
+            out.append(delta << 4 | typ)
+        else:
+            out.append(typ)
+            out += zigzag(fid)
+        out += payload
+        last = fid
+    out.append(0)
+    return (STRUCT, bytes(out))
+
+
+def list_(elements):
+    typ = elements[0][0] if elements else STRUCT
+    out = bytearray()
+    if len(elements) < 15:
+        out.append(len(elements) << 4 | typ)
+    else:
+        out.append(0xF0 | typ)
+        out += varint(len(elements))
+    for _, payload in elements:
+        out += payload
+    return (LIST, bytes(out))
+
+
+# Snappy, with literals and both short and long copies, so that the reader's
+# decoder is exercised beyond literals.
+
+
+def snappy(data):
+    out = bytearray(varint(len(data)))
+    literal_start = 0
+    last = {}
+    i = 0
+
+    def literal(end):
+        chunk = data[literal_start:end]
+        while chunk:
+            piece, chunk = chunk[:256], chunk[256:]
+            n = len(piece) - 1
+            if n < 60:
+                out.append(n << 2)
+            else:
+                out.append(60 << 2)
+                out.append(n)
+            out.extend(piece)
+
+    while i + 4 <= len(data):
+        key = data[i:i + 4]
+        candidate = last.get(key)
+        last[key] = i
+        if candidate is None or i - candidate > 0xFFFF:
+            i += 1
+            continue
+        length = 4
+        while i + length < len(data) and data[candidate + length] == data[i + length] and length < 64:
+            length += 1
+        literal(i)
+        offset = i - candidate
+        if length <= 11 and offset < 2048:
+            out.append((offset >> 8) << 5 | (length - 4) << 2 | 1)
+            out.append(offset & 0xFF)
+        else:
+            out.append((length - 1) << 2 | 2)
+            out += struct.pack("<H", offset)
+        i += length
+        literal_start = i
+    literal(len(data))
+    return bytes(out)
+
+
+# RLE/bit-packing hybrid.
+
+
+def rle_run(value, count, width):
+    return varint(count << 1) + value.to_bytes((width + 7) // 8, "little")
+
+
+def bit_packed(values, width):
+    values = list(values) + [0] * (-len(values) % 8)
+    bits = 0
+    for i, v in enumerate(values):
+        bits |= v << (i * width)
+    return varint((len(values) // 8) << 1 | 1) + bits.to_bytes(len(values) * width // 8, "little")
+
+
+def hybrid(values, width):
+    if len(set(values)) == 1:
+        return rle_run(values[0], len(values), width)
+    return bit_packed(values, width)
+
+
+# Columns.
+
+EPOCH = datetime.datetime(1970, 1, 1)
+
+BOOLEAN, INT32, INT64, DOUBLE, BYTE_ARRAY, FLBA = 0, 1, 2, 5, 6, 7
+UNCOMPRESSED, SNAPPY, GZIP = 0, 1, 2
+PLAIN, PLAIN_DICTIONARY, RLE, RLE_DICTIONARY = 0, 2, 3, 8
+
+
+def plain(typ, values, length=0):
+    if typ == BOOLEAN:
+        bits = 0
+        for i, v in enumerate(values):
+            bits |= int(v) << i
+        return bits.to_bytes((len(values) + 7) // 8, "little")
+    if typ == INT32:
+        return b"".join(struct.pack("<i", v) for v in values)
+    if typ == INT64:
+        return b"".join(struct.pack("<q", v) for v in values)
+    if typ == DOUBLE:
+        return b"".join(struct.pack("<d", v) for v in values)
+    if typ == BYTE_ARRAY:
+        return b"".join(struct.pack("<I", len(v)) + v for v in values)
+    return b"".join(values)
+
+
+def micros(t):
+    delta = t - EPOCH
+    return (delta.days * 86400 + delta.seconds) * 1000000 + delta.microseconds
+
+
+COLUMNS = [
+    # name, type, optional, schema fields, to physical, codec, page kind
+    ("id", INT64, False, [], lambda v: v, UNCOMPRESSED, "v1"),
+    ("name", BYTE_ARRAY, True,
+     [(6, i32(0)), (10, struct_([(1, struct_([]))]))],
+     lambda v: v.encode(), SNAPPY, "dictionary"),
+    ("score", DOUBLE, True, [], lambda v: v, GZIP, "v2"),
+    ("active", BOOLEAN, False, [], lambda v: v, SNAPPY, "v1"),
+    ("day", INT32, True,
+     [(6, i32(6)), (10, struct_([(6, struct_([]))]))],
+     lambda v: (v - EPOCH.date()).days, UNCOMPRESSED, "v1"),
+    ("created_at", INT64, True,
+     [(10, struct_([(8, struct_([(1, boolean(True)), (2, struct_([(2, struct_([]))]))]))]))],
+     micros, SNAPPY, "v1"),
+    ("price", FLBA, False,
+     [(2, i32(5)), (6, i32(5)), (7, i32(2)), (8, i32(11)),
+      (10, struct_([(5, struct_([(1, i32(2)), (2, i32(11))]))]))],
+     lambda v: v.to_bytes(5, "big", signed=True), GZIP, "v1"),
+    ("uid", FLBA, False,
+     [(2, i32(16)), (10, struct_([(14, struct_([]))]))],
+     lambda v: uuid.UUID(v).bytes, UNCOMPRESSED, "v1"),
+    ("note", BYTE_ARRAY, True,
+     [(6, i32(0)), (10, struct_([(1, struct_([]))]))],
+     lambda v: v.encode(), SNAPPY, "v1"),
+]
+
+
+def compress(codec, data):
+    if codec == SNAPPY:
+        return snappy(data)
+    if codec == GZIP:
+        return gzip.compress(data, mtime=0)
+    return data
+
+
+def page(header_type, header_field, header, body, uncompressed_size):
+    fields = [
+        (1, i32(header_type)),
+        (2, i32(uncompressed_size)),
+        (3, i32(len(body))),
+        (header_field, struct_(header)),
+    ]
+    return struct_(fields)[1] + body
+
+
+def levels(column_values):
+    return [0 if v is None else 1 for v in column_values]
+
+
+def data_page_v1(typ, optional, codec, values, encoded):
+    body = b""
+    if optional:
+        defs = hybrid(levels(values), 1)
+        body += struct.pack("<I", len(defs)) + defs
+    body += encoded
+    return body
+
+
+def column_chunk(out, column, values):
+    name, typ, optional, _, physical, codec, kind = column
+    present = [physical(v) for v in values if v is not None]
+    start = len(out)
+    dictionary_offset = None
+    encodings = [PLAIN, RLE]
+    if kind == "dictionary":
+        dictionary = sorted(set(present))
+        dict_body = plain(typ, dictionary)
+        dictionary_offset = len(out)
+        out += page(2, 7, [(1, i32(len(dictionary))), (2, i32(PLAIN_DICTIONARY))],
+                    compress(codec, dict_body), len(dict_body))
+        data_offset = len(out)
+        # Split the rows across two data pages, to read a chunk of several.
+        half = (len(values) + 1) // 2
+        for page_values in (values[:half], values[half:]):
+            if not page_values:
+                continue
+            indices = [dictionary.index(physical(v)) for v in page_values if v is not None]
+            width = max(1, (len(dictionary) - 1).bit_length())
+            encoded = bytes([width]) + (hybrid(indices, width) if indices else b"")
+            body = data_page_v1(typ, optional, codec, page_values, encoded)
+            out += page(0, 5, [(1, i32(len(page_values))), (2, i32(RLE_DICTIONARY)), (3, i32(RLE)), (4, i32(RLE))],
+                        compress(codec, body), len(body))
+        encodings = [PLAIN_DICTIONARY, RLE, RLE_DICTIONARY]
+    elif kind == "v2":
+        data_offset = len(out)
+        defs = hybrid(levels(values), 1) if optional else b""
+        encoded = plain(typ, present)
+        compressed = compress(codec, encoded)
+        out += page(3, 8, [
+            (1, i32(len(values))), (2, i32(len(values) - len(present))), (3, i32(len(values))),
+            (4, i32(PLAIN)), (5, i32(len(defs))), (6, i32(0)), (7, boolean(True)),
+        ], defs + compressed, len(defs) + len(encoded))
+    else:
+        data_offset = len(out)
+        body = data_page_v1(typ, optional, codec, values, plain(typ, present))
+        out += page(0, 5, [(1, i32(len(values))), (2, i32(PLAIN)), (3, i32(RLE)), (4, i32(RLE))],
+                    compress(codec, body), len(body))
+    size = len(out) - start
+    metadata = [
+        (1, i32(typ)),
+        (2, list_([i32(e) for e in encodings])),
+        (3, list_([binary(name)])),
+        (4, i32(codec)),
+        (5, i64(len(values))),
+        (6, i64(size)),
+        (7, i64(size)),
+        (9, i64(data_offset)),
+    ]
+    if dictionary_offset is not None:
+        metadata.append((11, i64(dictionary_offset)))
+    return struct_([(2, i64(start)), (3, struct_(metadata))]), size
+
+
+def main():
+    out = bytearray(b"PAR1")
+    row_groups = []
+    for rows in ROW_GROUPS:
+        chunks = []
+        total = 0
+        for j, column in enumerate(COLUMNS):
+            chunk, size = column_chunk(out, column, [row[j] for row in rows])
+            chunks.append(chunk)
+            total += size
+        row_groups.append(struct_([(1, list_(chunks)), (2, i64(total)), (3, i64(len(rows)))]))
+
+    schema = [struct_([(4, binary("schema")), (5, i32(len(COLUMNS)))])]
+    for name, typ, optional, fields, *_ in COLUMNS:
+        schema.append(struct_([(1, i32(typ)), (3, i32(1 if optional else 0)), (4, binary(name))] + fields))
+    metadata = struct_([
+        (1, i32(1)),
+        (2, list_(schema)),
+        (3, i64(len(ROWS))),
+        (4, list_(row_groups)),
+        (6, binary("make_export.py")),
+    ])[1]
+    out += metadata + struct.pack("<I", len(metadata)) + b"PAR1"
+    with open("export.parquet", "wb") as f:
+        f.write(out)
+
+
+if __name__ == "__main__":
+    main()
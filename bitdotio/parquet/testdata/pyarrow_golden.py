@@ -0,0 +1,80 @@
+#!/usr/bin/env python3
+"""Checks the parquet package against pyarrow, the reference implementation.
+
+Writes pyarrow.parquet, which TestPyarrowGolden reads, with dictionary
+encoding, SNAPPY pages, data pages v2, and two row groups, and checks that
+pyarrow reads go.parquet, written by TestWriterGolden, as the same rows:
+
+    go test -run TestWriterGolden -update
+    python3 testdata/pyarrow_golden.py
+
+The rows are those of make_export.py without the uid column, which pyarrow
+only writes as a UUID in recent versions.
+"""
+
+import datetime
+import decimal
+import os
+import sys
+
+import pyarrow as pa
+import pyarrow.parquet as pq
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+UTC = datetime.timezone.utc
+
+SCHEMA = pa.schema([
+    pa.field("id", pa.int64(), nullable=False),
+    pa.field("name", pa.string()),
+    pa.field("score", pa.float64()),
+    pa.field("active", pa.bool_(), nullable=False),
+    pa.field("day", pa.date32()),
+    pa.field("created_at", pa.timestamp("us", tz="UTC")),
+    pa.field("price", pa.decimal128(11, 2), nullable=False),
+    pa.field("note", pa.string()),
+])
+
+ROWS = [
+    (1, "alpha", 1.5, True, datetime.date(2023, 1, 2),
+     datetime.datetime(2023, 1, 2, 3, 4, 5, 678901, tzinfo=UTC),
+     decimal.Decimal("12.50"), "ok " * 30),
+    (2, None, None, False, None, None, decimal.Decimal("-0.07"), None),
+    (3, "beta", -2.25, True, datetime.date(1969, 12, 31),
+     datetime.datetime(1970, 1, 1, tzinfo=UTC), decimal.Decimal("100000.00"),
+     "abcabcabcabcabcabcabcabcabcabcabc"),
+    (4, "alpha", 0.0, False, datetime.date(2024, 2, 29),
+     datetime.datetime(1969, 12, 31, 23, 59, 59, 999999, tzinfo=UTC),
+     decimal.Decimal("0.00"), ""),
+    (5, "alpha", None, True, None, None, decimal.Decimal("-12345678.90"),
+     "ok " * 30),
+]
+
+
+def table():
+    columns = list(zip(*ROWS))
+    return pa.Table.from_arrays(
+        [pa.array(values, type=field.type) for values, field in zip(columns, SCHEMA)],
+        schema=SCHEMA)
+
+
+def main():
+    pq.write_table(table(), os.path.join(HERE, "pyarrow.parquet"),
+                   row_group_size=3, compression="snappy",
+                   use_dictionary=True, data_page_version="2.0")
+
+    path = os.path.join(HERE, "go.parquet")
+    if not os.path.exists(path):
+        sys.exit("go.parquet is missing; run go test -run TestWriterGolden -update")
+    got = pq.read_table(path)
+    if got.schema.remove_metadata() != SCHEMA:
+        sys.exit("go.parquet schema is\n%s\nwant\n%s" % (got.schema, SCHEMA))
+    for i, (row, want) in enumerate(zip(got.to_pylist(), ROWS)):
+        if tuple(row[field.name] for field in SCHEMA) != want:
+            sys.exit("go.parquet row %d is %r, want %r" % (i, row, want))
+    if got.num_rows != len(ROWS):
+        sys.exit("go.parquet has %d rows, want %d" % (got.num_rows, len(ROWS)))
+    print("ok")
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,324 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Parquet metadata is serialized with the Thrift compact protocol. These are
+// the protocol's field type codes.
+const (
+	compactStop   = 0
+	compactTrue   = 1
+	compactFalse  = 2
+	compactByte   = 3
+	compactI16    = 4
+	compactI32    = 5
+	compactI64    = 6
+	compactDouble = 7
+	compactBinary = 8
+	compactList   = 9
+	compactSet    = 10
+	compactMap    = 11
+	compactStruct = 12
+)
+
+// thriftMaxDepth bounds the nesting of decoded structs, so that a corrupt
+// file cannot exhaust the stack.
+const thriftMaxDepth = 32
+
+// thriftStruct is a decoded Thrift struct, mapping field IDs to values. Values
+// are bool, int64 for all integer types, float64, []byte, []interface{} for
+// lists and sets, or thriftStruct. Maps, which Parquet metadata does not use,
+// are skipped.
+type thriftStruct map[int16]interface{}
+
+func (s thriftStruct) has(id int16) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s thriftStruct) int(id int16) int64 {
+	n, _ := s[id].(int64)
+	return n
+}
+
+func (s thriftStruct) bool(id int16) bool {
+	b, _ := s[id].(bool)
+	return b
+}
+
+func (s thriftStruct) string(id int16) string {
+	b, _ := s[id].([]byte)
+	return string(b)
+}
+
+func (s thriftStruct) structField(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// thriftDecoder decodes Thrift compact protocol structs from data.
+type thriftDecoder struct {
+	data []byte
+	pos  int
+}
+
+var errThriftTruncated = errors.New("truncated thrift data")
+
+// readStruct decodes a struct at the current position.
+func (d *thriftDecoder) readStruct() (thriftStruct, error) {
+	return d.readStructDepth(0)
+}
+
+func (d *thriftDecoder) readStructDepth(depth int) (thriftStruct, error) {
+	if depth > thriftMaxDepth {
+		return nil, errors.New("thrift structs nested too deeply")
+	}
+	s := thriftStruct{}
+	var id int16
+	for {
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		typ := header & 0x0f
+		if typ == compactStop {
+			return s, nil
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			n, err := d.readZigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(n)
+		}
+		var v interface{}
+		switch typ {
+		case compactTrue:
+			v = true
+		case compactFalse:
+			v = false
+		default:
+			if v, err = d.readValue(typ, depth); err != nil {
+				return nil, err
+			}
+		}
+		if v != nil {
+			s[id] = v
+		}
+	}
+}
+
+// readValue decodes a value of typ, other than a boolean struct field, whose
+// value is in its type code.
+func (d *thriftDecoder) readValue(typ byte, depth int) (interface{}, error) {
+	switch typ {
+	case compactTrue, compactFalse:
+		// Booleans in lists are a byte each.
+		b, err := d.readByte()
+		return b == compactTrue, err
+	case compactByte:
+		b, err := d.readByte()
+		return int64(int8(b)), err
+	case compactI16, compactI32, compactI64:
+		return d.readZigzag()
+	case compactDouble:
+		if len(d.data)-d.pos < 8 {
+			return nil, errThriftTruncated
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(d.data[d.pos:]))
+		d.pos += 8
+		return v, nil
+	case compactBinary:
+		n, err := d.readVarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data)-d.pos) {
+			return nil, errThriftTruncated
+		}
+		b := d.data[d.pos : d.pos+int(n)]
+		d.pos += int(n)
+		return b, nil
+	case compactList, compactSet:
+		header, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = d.readVarint(); err != nil {
+				return nil, err
+			}
+		}
+		// Every element takes at least a byte.
+		if size > uint64(len(d.data)-d.pos) {
+			return nil, errThriftTruncated
+		}
+		values := make([]interface{}, size)
+		for i := range values {
+			if values[i], err = d.readValue(header&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	case compactMap:
+		size, err := d.readVarint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(len(d.data)-d.pos) {
+			return nil, errThriftTruncated
+		}
+		for i := uint64(0); i < 2*size; i++ {
+			typ := types >> 4
+			if i%2 == 1 {
+				typ = types & 0x0f
+			}
+			if _, err = d.readValue(typ, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case compactStruct:
+		return d.readStructDepth(depth + 1)
+	}
+	return nil, fmt.Errorf("unknown thrift type %d", typ)
+}
+
+func (d *thriftDecoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errThriftTruncated
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *thriftDecoder) readVarint() (uint64, error) {
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *thriftDecoder) readZigzag() (int64, error) {
+	v, err := d.readVarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// thriftField is a field of a struct to encode.
+type thriftField struct {
+	id    int16
+	value interface{}
+}
+
+// thriftFields is a struct to encode, with its fields in ascending ID order.
+type thriftFields []thriftField
+
+// thriftListOf is a list to encode, of elements of Thrift type elem.
+type thriftListOf struct {
+	elem   byte
+	values []interface{}
+}
+
+// thriftEncoder encodes Thrift compact protocol structs. Values are bool,
+// int32, int64, string, []byte, thriftFields, or thriftListOf.
+type thriftEncoder struct {
+	buf []byte
+}
+
+func (e *thriftEncoder) writeStruct(fields thriftFields) {
+	var last int16
+	for _, f := range fields {
+		typ := thriftType(f.value)
+		if b, ok := f.value.(bool); ok && !b {
+			typ = compactFalse
+		}
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			e.buf = append(e.buf, byte(delta)<<4|typ)
+		} else {
+			e.buf = append(e.buf, typ)
+			e.writeZigzag(int64(f.id))
+		}
+		last = f.id
+		if _, ok := f.value.(bool); !ok {
+			e.writeValue(f.value)
+		}
+	}
+	e.buf = append(e.buf, compactStop)
+}
+
+func (e *thriftEncoder) writeValue(v interface{}) {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			e.buf = append(e.buf, compactTrue)
+		} else {
+			e.buf = append(e.buf, compactFalse)
+		}
+	case int32:
+		e.writeZigzag(int64(v))
+	case int64:
+		e.writeZigzag(v)
+	case string:
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	case []byte:
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	case thriftFields:
+		e.writeStruct(v)
+	case thriftListOf:
+		if len(v.values) < 15 {
+			e.buf = append(e.buf, byte(len(v.values))<<4|v.elem)
+		} else {
+			e.buf = append(e.buf, 0xf0|v.elem)
+			e.buf = binary.AppendUvarint(e.buf, uint64(len(v.values)))
+		}
+		for _, value := range v.values {
+			e.writeValue(value)
+		}
+	default:
+		panic(fmt.Sprintf("parquet: cannot encode %T as thrift", v))
+	}
+}
+
+func (e *thriftEncoder) writeZigzag(v int64) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(v<<1^v>>63))
+}
+
+// thriftType returns the Thrift type code of a value to encode.
+func thriftType(v interface{}) byte {
+	switch v.(type) {
+	case bool:
+		return compactTrue
+	case int32:
+		return compactI32
+	case int64:
+		return compactI64
+	case string, []byte:
+		return compactBinary
+	case thriftFields:
+		return compactStruct
+	case thriftListOf:
+		return compactList
+	}
+	panic(fmt.Sprintf("parquet: cannot encode %T as thrift", v))
+}
@@ -0,0 +1,178 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var errValuesTruncated = errors.New("truncated page values")
+
+// julianUnixEpoch is the Julian day of the Unix epoch, for INT96 timestamps.
+const julianUnixEpoch = 2440588
+
+// readPlain decodes n PLAIN-encoded values of column's type, converted to
+// their Go types by convert.
+func readPlain(column *Column, data []byte, n int) ([]interface{}, error) {
+	size := 0
+	switch column.Type {
+	case Boolean:
+		if len(data) < (n+7)/8 {
+			return nil, errValuesTruncated
+		}
+	case Int32, Float:
+		size = 4
+	case Int64, Double:
+		size = 8
+	case Int96:
+		size = 12
+	case FixedLenByteArray:
+		if size = column.Length; size <= 0 {
+			return nil, fmt.Errorf("invalid length %d of fixed length byte arrays", size)
+		}
+	case ByteArray:
+		// Each value is prefixed with its length.
+		if len(data)/4 < n {
+			return nil, errValuesTruncated
+		}
+	}
+	if size > 0 && len(data)/size < n {
+		return nil, errValuesTruncated
+	}
+
+	values := make([]interface{}, n)
+	for i := range values {
+		var physical interface{}
+		switch column.Type {
+		case Boolean:
+			values[i] = data[i/8]>>(uint(i)%8)&1 == 1
+			continue
+		case ByteArray:
+			if len(data) < 4 {
+				return nil, errValuesTruncated
+			}
+			length := binary.LittleEndian.Uint32(data)
+			if uint64(length) > uint64(len(data)-4) {
+				return nil, errValuesTruncated
+			}
+			physical, data = data[4:4+length], data[4+length:]
+		case Int32:
+			physical = int32(binary.LittleEndian.Uint32(data[i*size:]))
+		case Int64:
+			physical = int64(binary.LittleEndian.Uint64(data[i*size:]))
+		case Float:
+			physical = math.Float32frombits(binary.LittleEndian.Uint32(data[i*size:]))
+		case Double:
+			physical = math.Float64frombits(binary.LittleEndian.Uint64(data[i*size:]))
+		default:
+			physical = data[i*size : (i+1)*size]
+		}
+		v, err := convert(column, physical)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// convert converts a value of column's physical type, as int32, int64,
+// float32, float64, or []byte, to the Go type of its logical type. Byte
+// arrays that remain []byte are copied, so that they do not retain pages.
+func convert(column *Column, v interface{}) (interface{}, error) {
+	switch column.Logical {
+	case LogicalString, LogicalJSON, LogicalEnum:
+		if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+	case LogicalUUID:
+		if b, ok := v.([]byte); ok && len(b) == 16 {
+			s := hex.EncodeToString(b)
+			return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:], nil
+		}
+	case LogicalDate:
+		if n, ok := v.(int32); ok {
+			return time.Unix(int64(n)*86400, 0).UTC(), nil
+		}
+	case LogicalTime:
+		switch n := v.(type) {
+		case int32:
+			return time.Duration(n) * column.Unit.duration(), nil
+		case int64:
+			return time.Duration(n) * column.Unit.duration(), nil
+		}
+	case LogicalTimestamp:
+		if n, ok := v.(int64); ok {
+			switch column.Unit {
+			case Millis:
+				return time.UnixMilli(n).UTC(), nil
+			case Micros:
+				return time.UnixMicro(n).UTC(), nil
+			}
+			return time.Unix(0, n).UTC(), nil
+		}
+	case LogicalDecimal:
+		unscaled := new(big.Int)
+		switch n := v.(type) {
+		case int32:
+			unscaled.SetInt64(int64(n))
+		case int64:
+			unscaled.SetInt64(n)
+		case []byte:
+			// Big-endian two's complement.
+			unscaled.SetBytes(n)
+			if len(n) > 0 && n[0]&0x80 != 0 {
+				unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(n))))
+			}
+		default:
+			return nil, fmt.Errorf("column %s: DECIMAL cannot annotate %v", column.Name, column.Type)
+		}
+		return formatDecimal(unscaled, column.Scale), nil
+	}
+	if b, ok := v.([]byte); ok {
+		if column.Type == Int96 {
+			return int96Time(b), nil
+		}
+		return append([]byte{}, b...), nil
+	}
+	return v, nil
+}
+
+// duration returns the length of one u.
+func (u TimeUnit) duration() time.Duration {
+	switch u {
+	case Millis:
+		return time.Millisecond
+	case Micros:
+		return time.Microsecond
+	}
+	return time.Nanosecond
+}
+
+// int96Time converts a legacy INT96 timestamp, of the nanoseconds of the day
+// followed by the Julian day, to a time.Time in UTC.
+func int96Time(b []byte) time.Time {
+	nanos := int64(binary.LittleEndian.Uint64(b))
+	day := int64(binary.LittleEndian.Uint32(b[8:]))
+	return time.Unix((day-julianUnixEpoch)*86400, nanos).UTC()
+}
+
+// formatDecimal formats unscaled×10^-scale exactly.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	s := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(s) <= scale {
+			s = strings.Repeat("0", scale-len(s)+1) + s
+		}
+		s = s[:len(s)-scale] + "." + s[len(s)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
@@ -0,0 +1,636 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRowGroupSize is the default number of rows per row group.
+	defaultRowGroupSize = 64 << 10
+
+	// pageSize is the approximate size of the values of each data page.
+	pageSize = 1 << 20
+
+	// createdBy identifies the writer in the files it writes.
+	createdBy = "go-bitdotio"
+)
+
+// Compression is the codec with which a Writer compresses pages.
+type Compression int
+
+const (
+	// CompressionSnappy is the default, as for most Parquet writers.
+	CompressionSnappy Compression = iota
+	CompressionGzip
+	CompressionNone
+)
+
+// WriterOptions configures a Writer.
+type WriterOptions struct {
+	// RowGroupSize is the number of rows per row group, which readers decode
+	// at a time and writers buffer in memory. Defaults to 65536.
+	RowGroupSize int
+	// Compression is the codec of the pages. Defaults to CompressionSnappy.
+	Compression Compression
+}
+
+// Writer writes a Parquet file with a flat schema, row by row. Rows are
+// buffered and written a row group at a time, with PLAIN encoded data pages.
+type Writer struct {
+	w         io.Writer
+	columns   []Column
+	options   WriterOptions
+	offset    int64
+	buffered  [][]interface{}
+	rowGroups []interface{}
+	numRows   int64
+	err       error
+}
+
+// NewWriter returns a Writer that writes a Parquet file of columns to w.
+// options may be nil. Close must be called to finish the file.
+func NewWriter(w io.Writer, columns []Column, options *WriterOptions) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet files must have at least one column")
+	}
+	for i := range columns {
+		if err := columns[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	writer := &Writer{w: w, columns: append([]Column(nil), columns...), buffered: make([][]interface{}, len(columns))}
+	if options != nil {
+		writer.options = *options
+	}
+	if writer.options.RowGroupSize <= 0 {
+		writer.options.RowGroupSize = defaultRowGroupSize
+	}
+	switch writer.options.Compression {
+	case CompressionSnappy, CompressionGzip, CompressionNone:
+	default:
+		return nil, fmt.Errorf("unknown compression %d", writer.options.Compression)
+	}
+	if err := writer.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// validate reports whether values of c can be written.
+func (c *Column) validate() error {
+	if c.Name == "" {
+		return errors.New("column names must not be empty")
+	}
+	valid := false
+	switch c.Logical {
+	case LogicalNone:
+		valid = c.Type >= Boolean && c.Type <= FixedLenByteArray && c.Type != Int96
+	case LogicalString, LogicalJSON, LogicalEnum:
+		valid = c.Type == ByteArray
+	case LogicalUUID:
+		valid = c.Type == FixedLenByteArray && c.Length == 16
+	case LogicalDate:
+		valid = c.Type == Int32
+	case LogicalTime:
+		valid = c.Type == Int64 || c.Type == Int32 && c.Unit == Millis
+	case LogicalTimestamp:
+		valid = c.Type == Int64
+	case LogicalDecimal:
+		valid = (c.Type == Int32 && c.Precision <= 9 || c.Type == Int64 && c.Precision <= 18 ||
+			c.Type == ByteArray || c.Type == FixedLenByteArray) &&
+			c.Precision > 0 && c.Precision <= maxDecimalPrecision && c.Scale >= 0 && c.Scale <= c.Precision
+	}
+	if c.Type == FixedLenByteArray && c.Length <= 0 {
+		valid = false
+	}
+	if !valid {
+		return fmt.Errorf("column %s: %v cannot be written as %v", c.Name, c.Logical, c.Type)
+	}
+	return nil
+}
+
+// Write appends a row with a value per column, or nil for NULL in optional
+// columns. Values are converted to the columns' types:
+//
+//   - Boolean: bool
+//   - Int32 and Int64: any integer type that fits
+//   - Float and Double: float32 or float64
+//   - ByteArray and FixedLenByteArray: []byte, or string
+//   - LogicalDate and LogicalTimestamp: time.Time. The timestamps of columns
+//     that are not UTC are written from their wall clock.
+//   - LogicalTime: time.Duration since midnight
+//   - LogicalUUID: [16]byte, or string
+//   - LogicalDecimal: string, e.g. "-12.50", which must not have more digits
+//     than the column
+func (w *Writer) Write(row []interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("row %d has %d values, but the file has %d columns", w.numRows, len(row), len(w.columns))
+	}
+	values := make([]interface{}, len(row))
+	for j, v := range row {
+		physical, err := w.columns[j].physical(v)
+		if err != nil {
+			return fmt.Errorf("unable to write row %d column %s: %w", w.numRows, w.columns[j].Name, err)
+		}
+		values[j] = physical
+	}
+	for j, v := range values {
+		w.buffered[j] = append(w.buffered[j], v)
+	}
+	w.numRows++
+	if len(w.buffered[0]) >= w.options.RowGroupSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes the buffered rows as a row group. Write flushes every
+// RowGroupSize rows, so it need not be called otherwise.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	numRows := len(w.buffered[0])
+	if numRows == 0 {
+		return nil
+	}
+	var chunks []interface{}
+	var totalSize int64
+	for j := range w.columns {
+		chunk, size, err := w.writeColumnChunk(&w.columns[j], w.buffered[j])
+		if err != nil {
+			w.err = err
+			return err
+		}
+		chunks = append(chunks, chunk)
+		totalSize += size
+		w.buffered[j] = w.buffered[j][:0]
+	}
+	w.rowGroups = append(w.rowGroups, thriftFields{
+		{1, thriftListOf{compactStruct, chunks}},
+		{2, totalSize},
+		{3, int64(numRows)},
+	})
+	return nil
+}
+
+// Close flushes the buffered rows and writes the file's metadata. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	schema := []interface{}{thriftFields{
+		{4, "schema"},
+		{5, int32(len(w.columns))},
+	}}
+	for i := range w.columns {
+		schema = append(schema, w.columns[i].schemaElement())
+	}
+	e := &thriftEncoder{}
+	e.writeStruct(thriftFields{
+		{1, int32(1)},
+		{2, thriftListOf{compactStruct, schema}},
+		{3, w.numRows},
+		{4, thriftListOf{compactStruct, w.rowGroups}},
+		{6, createdBy},
+	})
+	footer := binary.LittleEndian.AppendUint32(e.buf, uint32(len(e.buf)))
+	if err := w.write(append(footer, magic...)); err != nil {
+		return err
+	}
+	w.err = errors.New("parquet writer is closed")
+	return nil
+}
+
+func (w *Writer) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	if err != nil {
+		w.err = err
+	}
+	return err
+}
+
+// writeColumnChunk writes the values of a column in a row group as data pages
+// of about pageSize bytes, and returns the chunk's metadata and uncompressed
+// size.
+func (w *Writer) writeColumnChunk(column *Column, values []interface{}) (thriftFields, int64, error) {
+	start := w.offset
+	numValues := len(values)
+	var uncompressedSize int64
+	for len(values) > 0 {
+		n, data := encodePlain(column, values)
+		page := data
+		if column.Optional {
+			levels := encodeLevels(values[:n])
+			page = binary.LittleEndian.AppendUint32(make([]byte, 0, 4+len(levels)+len(data)), uint32(len(levels)))
+			page = append(append(page, levels...), data...)
+		}
+		compressed, err := w.compress(page)
+		if err != nil {
+			return nil, 0, err
+		}
+		e := &thriftEncoder{}
+		e.writeStruct(thriftFields{
+			{1, int32(pageData)},
+			{2, int32(len(page))},
+			{3, int32(len(compressed))},
+			{5, thriftFields{
+				{1, int32(n)},
+				{2, int32(encodingPlain)},
+				{3, int32(encodingRLE)},
+				{4, int32(encodingRLE)},
+			}},
+		})
+		if err = w.write(e.buf); err != nil {
+			return nil, 0, err
+		}
+		if err = w.write(compressed); err != nil {
+			return nil, 0, err
+		}
+		uncompressedSize += int64(len(e.buf) + len(page))
+		values = values[n:]
+	}
+	metadata := thriftFields{
+		{1, int32(column.Type)},
+		{2, thriftListOf{compactI32, []interface{}{int32(encodingPlain), int32(encodingRLE)}}},
+		{3, thriftListOf{compactBinary, []interface{}{column.Name}}},
+		{4, int32(w.codec())},
+		{5, int64(numValues)},
+		{6, uncompressedSize},
+		{7, w.offset - start},
+		{9, start},
+	}
+	return thriftFields{{2, start}, {3, metadata}}, uncompressedSize, nil
+}
+
+func (w *Writer) codec() int {
+	switch w.options.Compression {
+	case CompressionGzip:
+		return codecGzip
+	case CompressionNone:
+		return codecUncompressed
+	}
+	return codecSnappy
+}
+
+func (w *Writer) compress(page []byte) ([]byte, error) {
+	switch w.options.Compression {
+	case CompressionGzip:
+		var b bytes.Buffer
+		zw := gzip.NewWriter(&b)
+		if _, err := zw.Write(page); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	case CompressionNone:
+		return page, nil
+	}
+	return snappyEncode(page), nil
+}
+
+// encodePlain PLAIN-encodes the non-NULL values of a prefix of values, of
+// about pageSize bytes, and returns the length of the prefix and the encoded
+// values.
+func encodePlain(column *Column, values []interface{}) (int, []byte) {
+	var data []byte
+	var bits byte
+	nbits := 0
+	n := 0
+	for ; n < len(values) && len(data) < pageSize; n++ {
+		switch v := values[n].(type) {
+		case nil:
+		case bool:
+			if v {
+				bits |= 1 << uint(nbits)
+			}
+			if nbits++; nbits == 8 {
+				data, bits, nbits = append(data, bits), 0, 0
+			}
+		case int32:
+			data = binary.LittleEndian.AppendUint32(data, uint32(v))
+		case int64:
+			data = binary.LittleEndian.AppendUint64(data, uint64(v))
+		case float32:
+			data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+		case float64:
+			data = binary.LittleEndian.AppendUint64(data, math.Float64bits(v))
+		case []byte:
+			if column.Type == ByteArray {
+				data = binary.LittleEndian.AppendUint32(data, uint32(len(v)))
+			}
+			data = append(data, v...)
+		}
+	}
+	if nbits > 0 {
+		data = append(data, bits)
+	}
+	return n, data
+}
+
+// encodeLevels encodes the definition levels of values, 0 for NULL and 1
+// otherwise, as runs of the RLE/bit-packing hybrid encoding.
+func encodeLevels(values []interface{}) []byte {
+	var levels []byte
+	for i := 0; i < len(values); {
+		defined := values[i] != nil
+		run := 1
+		for i+run < len(values) && (values[i+run] != nil) == defined {
+			run++
+		}
+		levels = binary.AppendUvarint(levels, uint64(run)<<1)
+		if defined {
+			levels = append(levels, 1)
+		} else {
+			levels = append(levels, 0)
+		}
+		i += run
+	}
+	return levels
+}
+
+// schemaElement returns the schema element describing c.
+func (c *Column) schemaElement() thriftFields {
+	repetition := int32(repetitionRequired)
+	if c.Optional {
+		repetition = repetitionOptional
+	}
+	element := thriftFields{{1, int32(c.Type)}}
+	if c.Type == FixedLenByteArray {
+		element = append(element, thriftField{2, int32(c.Length)})
+	}
+	element = append(element, thriftField{3, repetition}, thriftField{4, c.Name})
+
+	var logical thriftFields
+	converted := int32(-1)
+	switch c.Logical {
+	case LogicalString:
+		logical, converted = thriftFields{{logicalString, thriftFields{}}}, convertedUTF8
+	case LogicalJSON:
+		logical, converted = thriftFields{{logicalJSON, thriftFields{}}}, convertedJSON
+	case LogicalEnum:
+		logical, converted = thriftFields{{logicalEnum, thriftFields{}}}, convertedEnum
+	case LogicalUUID:
+		logical = thriftFields{{logicalUUID, thriftFields{}}}
+	case LogicalDate:
+		logical, converted = thriftFields{{logicalDate, thriftFields{}}}, convertedDate
+	case LogicalDecimal:
+		logical, converted = thriftFields{{logicalDecimal, thriftFields{{1, int32(c.Scale)}, {2, int32(c.Precision)}}}}, convertedDecimal
+	case LogicalTime, LogicalTimestamp:
+		unit := thriftFields{{int16(c.Unit) + 1, thriftFields{}}}
+		id := int16(logicalTime)
+		if c.Logical == LogicalTimestamp {
+			id = logicalTimestamp
+		}
+		logical = thriftFields{{id, thriftFields{{1, c.UTC}, {2, unit}}}}
+		// The converted types of times are always UTC, and have no NANOS.
+		if c.UTC && c.Unit != Nanos {
+			converted = convertedTimeMillis + int32(c.Unit)
+			if c.Logical == LogicalTimestamp {
+				converted = convertedTimestampMillis + int32(c.Unit)
+			}
+		}
+	}
+	if converted >= 0 {
+		element = append(element, thriftField{6, converted})
+	}
+	if c.Logical == LogicalDecimal {
+		element = append(element, thriftField{7, int32(c.Scale)}, thriftField{8, int32(c.Precision)})
+	}
+	if logical != nil {
+		element = append(element, thriftField{10, logical})
+	}
+	return element
+}
+
+// physical converts v to c's physical type, as described by Writer.Write.
+func (c *Column) physical(v interface{}) (interface{}, error) {
+	if v == nil {
+		if !c.Optional {
+			return nil, errors.New("NULL in a required column")
+		}
+		return nil, nil
+	}
+	switch c.Logical {
+	case LogicalDate:
+		if t, ok := v.(time.Time); ok {
+			days := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Unix() / 86400
+			if days < math.MinInt32 || days > math.MaxInt32 {
+				return nil, fmt.Errorf("date %s out of range", t.Format("2006-01-02"))
+			}
+			return int32(days), nil
+		}
+	case LogicalTimestamp:
+		if t, ok := v.(time.Time); ok {
+			if !c.UTC {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+			}
+			switch c.Unit {
+			case Millis:
+				return t.UnixMilli(), nil
+			case Micros:
+				return t.UnixMicro(), nil
+			}
+			return t.UnixNano(), nil
+		}
+	case LogicalTime:
+		if d, ok := v.(time.Duration); ok {
+			n := int64(d / c.Unit.duration())
+			if c.Type == Int32 {
+				return int32(n), nil
+			}
+			return n, nil
+		}
+	case LogicalUUID:
+		switch u := v.(type) {
+		case [16]byte:
+			return u[:], nil
+		case string:
+			b, err := parseUUID(u)
+			if err != nil {
+				return nil, err
+			}
+			return b, nil
+		}
+	case LogicalDecimal:
+		if s, ok := v.(string); ok {
+			return c.decimal(s)
+		}
+	}
+
+	switch c.Type {
+	case Boolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case Int32, Int64:
+		n, ok := toInt64(v)
+		if !ok {
+			break
+		}
+		if c.Type == Int64 {
+			return n, nil
+		}
+		if n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, fmt.Errorf("%d overflows INT32", n)
+		}
+		return int32(n), nil
+	case Float:
+		switch f := v.(type) {
+		case float32:
+			return f, nil
+		case float64:
+			return float32(f), nil
+		}
+	case Double:
+		switch f := v.(type) {
+		case float32:
+			return float64(f), nil
+		case float64:
+			return f, nil
+		}
+	case ByteArray, FixedLenByteArray:
+		var b []byte
+		switch s := v.(type) {
+		case []byte:
+			b = s
+		case string:
+			b = []byte(s)
+		default:
+			return nil, fmt.Errorf("cannot write %T as %v %v", v, c.Logical, c.Type)
+		}
+		if c.Type == FixedLenByteArray && len(b) != c.Length {
+			return nil, fmt.Errorf("%d bytes do not fit FIXED_LEN_BYTE_ARRAY of length %d", len(b), c.Length)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot write %T as %v %v", v, c.Logical, c.Type)
+}
+
+// toInt64 converts the integer types to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint:
+		if uint64(n) <= math.MaxInt64 {
+			return int64(n), true
+		}
+	case uint64:
+		if n <= math.MaxInt64 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// decimal converts a decimal string to the unscaled integer of c's type.
+func (c *Column) decimal(s string) (interface{}, error) {
+	unscaled, ok := parseDecimal(s, c.Scale)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q for scale %d", s, c.Scale)
+	}
+	if len(new(big.Int).Abs(unscaled).String()) > c.Precision {
+		return nil, fmt.Errorf("decimal %s overflows precision %d", s, c.Precision)
+	}
+	switch c.Type {
+	case Int32:
+		return int32(unscaled.Int64()), nil
+	case Int64:
+		return unscaled.Int64(), nil
+	}
+	// Big-endian two's complement, sign extended to FixedLenByteArray's
+	// length or to the minimal length for ByteArray.
+	length := c.Length
+	if c.Type == ByteArray {
+		length = unscaled.BitLen()/8 + 1
+	}
+	b := make([]byte, length)
+	magnitude := new(big.Int).Abs(unscaled).Bytes()
+	if len(magnitude) > length {
+		return nil, fmt.Errorf("decimal %s overflows FIXED_LEN_BYTE_ARRAY of length %d", s, length)
+	}
+	copy(b[length-len(magnitude):], magnitude)
+	if unscaled.Sign() < 0 {
+		for i := range b {
+			b[i] = ^b[i]
+		}
+		for i := length - 1; i >= 0; i-- {
+			if b[i]++; b[i] != 0 {
+				break
+			}
+		}
+	}
+	if (b[0]&0x80 != 0) != (unscaled.Sign() < 0) {
+		return nil, fmt.Errorf("decimal %s overflows FIXED_LEN_BYTE_ARRAY of length %d", s, length)
+	}
+	return b, nil
+}
+
+// parseDecimal parses a decimal string without an exponent, such as
+// Postgres's text of numeric values, to an integer of units of 10^-scale. It
+// fails for strings with more fractional digits than scale, and for NaN and
+// infinities.
+func parseDecimal(s string, scale int) (*big.Int, bool) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	whole, fraction := digits, ""
+	if i := strings.IndexByte(digits, '.'); i >= 0 {
+		whole, fraction = digits[:i], digits[i+1:]
+	}
+	if whole == "" && fraction == "" {
+		return nil, false
+	}
+	trimmed := strings.TrimRight(fraction, "0")
+	if len(trimmed) > scale {
+		return nil, false
+	}
+	unscaled, ok := new(big.Int).SetString("0"+whole+trimmed+strings.Repeat("0", scale-len(trimmed)), 10)
+	if !ok || strings.ContainsAny(whole+fraction, "+-_") {
+		return nil, false
+	}
+	if strings.HasPrefix(s, "-") {
+		unscaled.Neg(unscaled)
+	}
+	return unscaled, true
+}
+
+// parseUUID parses a UUID in its canonical form, with or without hyphens or
+// braces.
+func parseUUID(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.Trim(strings.ReplaceAll(s, "-", ""), "{}"))
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid UUID %q", s)
+	}
+	return b, nil
+}
@@ -0,0 +1,241 @@
+package parquet
+
+import (
+	"bytes"
+	"flag"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/go.parquet")
+
+// goldenColumns are the columns of exportRows without uid, which pyarrow
+// writes in the golden files, see testdata/pyarrow_golden.py.
+var goldenColumns = []Column{
+	{Name: "id", Type: Int64},
+	{Name: "name", Type: ByteArray, Logical: LogicalString, Optional: true},
+	{Name: "score", Type: Double, Optional: true},
+	{Name: "active", Type: Boolean},
+	{Name: "day", Type: Int32, Logical: LogicalDate, Optional: true},
+	{Name: "created_at", Type: Int64, Logical: LogicalTimestamp, Unit: Micros, UTC: true, Optional: true},
+	{Name: "price", Type: FixedLenByteArray, Logical: LogicalDecimal, Precision: 11, Scale: 2, Length: 5},
+	{Name: "note", Type: ByteArray, Logical: LogicalString, Optional: true},
+}
+
+// goldenRows returns the rows of exportRows without uid.
+func goldenRows() [][]interface{} {
+	rows := make([][]interface{}, len(exportRows))
+	for i, row := range exportRows {
+		rows[i] = append(append([]interface{}(nil), row[:7]...), row[8:]...)
+	}
+	return rows
+}
+
+// TestWriterGolden writes goldenRows as testdata/go.parquet, which
+// testdata/pyarrow_golden.py checks that pyarrow reads, and fails if the file
+// has changed, unless run with -update.
+func TestWriterGolden(t *testing.T) {
+	var b bytes.Buffer
+	w, err := NewWriter(&b, goldenColumns, &WriterOptions{RowGroupSize: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range goldenRows() {
+		if err = w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err = os.WriteFile("testdata/go.parquet", b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile("testdata/go.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Error("written file differs from testdata/go.parquet; check it with testdata/pyarrow_golden.py and run with -update")
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	columns := []Column{
+		{Name: "id", Type: Int64},
+		{Name: "small", Type: Int32, Optional: true},
+		{Name: "name", Type: ByteArray, Logical: LogicalString, Optional: true},
+		{Name: "score", Type: Double, Optional: true},
+		{Name: "ratio", Type: Float},
+		{Name: "active", Type: Boolean, Optional: true},
+		{Name: "day", Type: Int32, Logical: LogicalDate, Optional: true},
+		{Name: "at", Type: Int64, Logical: LogicalTimestamp, Unit: Micros, UTC: true, Optional: true},
+		{Name: "local", Type: Int64, Logical: LogicalTimestamp, Unit: Millis},
+		{Name: "clock", Type: Int64, Logical: LogicalTime, Unit: Micros, Optional: true},
+		{Name: "price", Type: Int64, Logical: LogicalDecimal, Precision: 12, Scale: 2, Optional: true},
+		{Name: "big", Type: FixedLenByteArray, Logical: LogicalDecimal, Precision: 30, Scale: 5, Length: 13, Optional: true},
+		{Name: "huge", Type: ByteArray, Logical: LogicalDecimal, Precision: 40, Scale: 0, Optional: true},
+		{Name: "uid", Type: FixedLenByteArray, Logical: LogicalUUID, Length: 16, Optional: true},
+		{Name: "doc", Type: ByteArray, Logical: LogicalJSON, Optional: true},
+		{Name: "raw", Type: ByteArray, Optional: true},
+	}
+	at := time.Date(2023, 1, 2, 3, 4, 5, 678901000, time.UTC)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	rows := [][]interface{}{
+		{int64(1), int32(7), "alpha", 1.5, float32(0.25), true, at, at, at.In(newYork), 90 * time.Minute, "12.50", "-123456789.12345", "1234567890123456789012345678901234567890", [16]byte{1, 2, 3}, `{"a": [1, 2]}`, []byte{0, 1, 2}},
+		{int64(2), nil, nil, nil, float32(-1), nil, nil, nil, at, nil, nil, nil, nil, nil, nil, nil},
+		{int64(3), 5, "", -2.25, float32(3), false, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC), time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC(), time.Duration(0), "-0.07", "0", "-1", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "null", []byte{}},
+	}
+	want := [][]interface{}{
+		{int64(1), int32(7), "alpha", 1.5, float32(0.25), true, date(2023, 1, 2), at, time.Date(2023, 1, 1, 22, 4, 5, 678000000, time.UTC), 90 * time.Minute, "12.50", "-123456789.12345", "1234567890123456789012345678901234567890", "01020300-0000-0000-0000-000000000000", `{"a": [1, 2]}`, []byte{0, 1, 2}},
+		{int64(2), nil, nil, nil, float32(-1), nil, nil, nil, at.Truncate(time.Millisecond), nil, nil, nil, nil, nil, nil, nil},
+		{int64(3), int32(5), "", -2.25, float32(3), false, date(1969, 12, 31), time.Unix(0, 0).UTC(), time.Unix(0, 0).UTC(), time.Duration(0), "-0.07", "0.00000", "-1", "6ba7b810-9dad-11d1-80b4-00c04fd430c8", "null", []byte{}},
+	}
+
+	for _, compression := range []Compression{CompressionSnappy, CompressionGzip, CompressionNone} {
+		var b bytes.Buffer
+		w, err := NewWriter(&b, columns, &WriterOptions{RowGroupSize: 2, Compression: compression})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if err = w.Write(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := Open(bytes.NewReader(b.Bytes()), int64(b.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(f.Columns(), columns) {
+			t.Errorf("Columns = %+v, want %+v", f.Columns(), columns)
+		}
+		if f.NumRows() != 3 || f.NumRowGroups() != 2 {
+			t.Errorf("NumRows, NumRowGroups = %d, %d, want 3, 2", f.NumRows(), f.NumRowGroups())
+		}
+		var got [][]interface{}
+		r := f.Rows()
+		for r.Next() {
+			got = append(got, r.Values())
+		}
+		if err = r.Err(); err != nil {
+			t.Fatal(err)
+		}
+		for i := range want {
+			if i >= len(got) || !reflect.DeepEqual(got[i], want[i]) {
+				t.Errorf("compression %d: row %d = %#v, want %#v", compression, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestWriterLargePages(t *testing.T) {
+	// Values of several pageSize pages, with runs of NULLs.
+	columns := []Column{{Name: "text", Type: ByteArray, Logical: LogicalString, Optional: true}}
+	var b bytes.Buffer
+	w, err := NewWriter(&b, columns, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []interface{}
+	for i := 0; i < 5000; i++ {
+		var v interface{}
+		if i%100 > 10 {
+			v = strings.Repeat(string(rune('a'+i%26)), 1000)
+		}
+		want = append(want, v)
+		if err = w.Write([]interface{}{v}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := Open(bytes.NewReader(b.Bytes()), int64(b.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := f.ReadRowGroup(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row[0] != want[i] {
+			t.Fatalf("row %d = %v, want %v", i, row[0], want[i])
+		}
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	for _, column := range []Column{
+		{Type: Int64},
+		{Name: "s", Type: Int32, Logical: LogicalString},
+		{Name: "d", Type: Int32, Logical: LogicalDecimal, Precision: 10, Scale: 2},
+		{Name: "u", Type: FixedLenByteArray, Logical: LogicalUUID, Length: 15},
+		{Name: "f", Type: FixedLenByteArray},
+	} {
+		if _, err := NewWriter(&bytes.Buffer{}, []Column{column}, nil); err == nil {
+			t.Errorf("NewWriter(%+v) succeeded, want an error", column)
+		}
+	}
+
+	w, err := NewWriter(&bytes.Buffer{}, []Column{
+		{Name: "id", Type: Int32},
+		{Name: "price", Type: Int64, Logical: LogicalDecimal, Precision: 4, Scale: 2, Optional: true},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]interface{}{
+		{nil, nil},
+		{int64(1) << 40, nil},
+		{"1", nil},
+		{1, "123.45"},
+		{1, "1.234"},
+		{1, "NaN"},
+		{1, 1.5},
+		{1},
+	} {
+		if err = w.Write(row); err == nil {
+			t.Errorf("Write(%v) succeeded, want an error", row)
+		}
+	}
+}
+
+func TestSnappy(t *testing.T) {
+	random := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(random)
+	for _, data := range [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte(strings.Repeat("ab", 1000)),
+		[]byte(strings.Repeat("hello, world ", 10000)),
+		random,
+		append(random[:70000:70000], random[:70000]...),
+	} {
+		encoded := snappyEncode(data)
+		decoded, err := snappyDecode(encoded, len(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("snappy round trip of %d bytes failed", len(data))
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AcquireInfo describes an attempt by Connect to acquire a connection, as
+// reported to PoolConfig.OnAcquire.
+type AcquireInfo struct {
+	DBName string
+	// Wait is the time spent acquiring the connection, including establishing
+	// a new connection and retries while the database wakes.
+	Wait time.Duration
+	// Saturated reports whether the pool had no idle connections and was at
+	// its maximum size when the attempt started, so that it waited for another
+	// caller to release a connection. Long waits of saturated attempts show
+	// that MaxConns, and so the database's connection limit, is the
+	// bottleneck, rather than connection setup.
+	Saturated bool
+	// Err is the error if no connection was acquired, e.g. wrapping
+	// ErrAcquireTimeout.
+	Err error
+}
+
+// isSaturated reports whether a pool with stat has no idle connections and
+// cannot open more.
+func isSaturated(stat *pgxpool.Stat) bool {
+	return stat.IdleConns() == 0 && stat.TotalConns() >= stat.MaxConns()
+}
@@ -0,0 +1,50 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CancelQuery cancels the query in progress on conn, a connection acquired from
+// the pool for a bit.io database, and returns the backend PID of conn. It is
+// safe to call while another goroutine is running a query on conn.
+//
+// Cancellation is first attempted with a Postgres cancel request. If that
+// fails, for example because a proxy does not forward cancel requests,
+// CancelQuery falls back to calling pg_cancel_backend from another connection
+// in the pool. Queries run with a context are also cancelled when the context
+// is done; CancelQuery is intended for cancelling from elsewhere, such as admin
+// tooling.
+func (m *Manager) CancelQuery(ctx context.Context, dbName string, conn *pgxpool.Conn) (uint32, error) {
+	pgConn := conn.Conn().PgConn()
+	pid := pgConn.PID()
+	if err := pgConn.CancelRequest(ctx); err == nil {
+		return pid, nil
+	}
+	cancelled, err := m.CancelBackend(ctx, dbName, pid)
+	if err != nil {
+		return pid, err
+	}
+	if !cancelled {
+		return pid, fmt.Errorf("unable to cancel query for backend %d on db %s", pid, dbName)
+	}
+	return pid, nil
+}
+
+// CancelBackend cancels the query in progress on the Postgres backend with PID
+// pid using pg_cancel_backend, and reports whether a cancel signal was sent.
+// The PID of any session visible to the connecting user can be found in
+// pg_stat_activity.
+func (m *Manager) CancelBackend(ctx context.Context, dbName string, pid uint32) (bool, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return false, fmt.Errorf("unable to cancel backend %d on db %s: %w", pid, dbName, err)
+	}
+	var cancelled bool
+	if err = pool.QueryRow(ctx, "SELECT pg_cancel_backend($1)", int64(pid)).Scan(&cancelled); err != nil {
+		return false, fmt.Errorf("unable to cancel backend %d on db %s: %w", pid, dbName, err)
+	}
+	return cancelled, nil
+}
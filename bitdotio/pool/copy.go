@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// CopyFormat is a data format for COPY.
+type CopyFormat string
+
+const (
+	// CopyFormatCSV is CSV with a header row.
+	CopyFormatCSV CopyFormat = "csv"
+	// CopyFormatText is Postgres's tab-delimited text format.
+	CopyFormatText CopyFormat = "text"
+	// CopyFormatBinary is Postgres's binary COPY format.
+	CopyFormatBinary CopyFormat = "binary"
+)
+
+// ExportTableCopy streams a table of a bit.io database with an existing pool
+// to w in format, using COPY TO STDOUT over a pooled connection, and returns
+// the number of rows exported. table may be schema-qualified, e.g.
+// `public.users`. Unlike an export job, the data streams as soon as the query
+// starts, which suits connected applications.
+func (m *Manager) ExportTableCopy(ctx context.Context, dbName, table string, w io.Writer, format CopyFormat) (int64, error) {
+	return m.ExportQueryCopy(ctx, dbName, "TABLE "+tableIdentifier(table).Sanitize(), w, format)
+}
+
+// ExportQueryCopy streams the result of a query, which takes no parameters,
+// to w in format, as for ExportTableCopy.
+func (m *Manager) ExportQueryCopy(ctx context.Context, dbName, query string, w io.Writer, format CopyFormat) (int64, error) {
+	var options string
+	switch format {
+	case CopyFormatCSV:
+		options = "FORMAT csv, HEADER true"
+	case CopyFormatText, CopyFormatBinary:
+		options = "FORMAT " + string(format)
+	default:
+		return 0, fmt.Errorf("unsupported COPY format %q", format)
+	}
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	defer conn.Release()
+	tag, err := conn.Conn().PgConn().CopyTo(ctx, w, fmt.Sprintf("COPY (%s) TO STDOUT WITH (%s)", query, options))
+	if err != nil {
+		return 0, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,138 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultCursorBatchSize is the default number of rows a Cursor fetches at a
+// time.
+const defaultCursorBatchSize = 1000
+
+// Cursor reads the result of a query in batches from a server-side cursor,
+// so that huge results, such as exports of very large tables, are never held
+// in memory at once. A Cursor holds a pooled connection and a read-only
+// transaction until it is closed, and is not safe for concurrent use.
+type Cursor struct {
+	dbName    string
+	conn      *pgxpool.Conn
+	tx        pgx.Tx
+	batchSize int
+	fields    []pgconn.FieldDescription
+	columns   []string
+	done      bool
+}
+
+// OpenCursor declares a server-side cursor for a query on a bit.io database
+// with an existing pool, from which Next fetches batchSize rows at a time. A
+// batchSize of 0 fetches 1000 rows at a time. The cursor must be closed with
+// Close to release its connection.
+func (m *Manager) OpenCursor(ctx context.Context, dbName string, batchSize int, query string, args ...any) (*Cursor, error) {
+	if batchSize <= 0 {
+		batchSize = defaultCursorBatchSize
+	}
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open cursor on db %s: %w", dbName, err)
+	}
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("unable to open cursor on db %s: %w", dbName, err)
+	}
+	if _, err = tx.Exec(ctx, "DECLARE bitdotio_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		tx.Rollback(context.Background())
+		conn.Release()
+		return nil, fmt.Errorf("unable to open cursor on db %s: %w", dbName, err)
+	}
+	return &Cursor{dbName: dbName, conn: conn, tx: tx, batchSize: batchSize}, nil
+}
+
+// Next fetches the next batch of up to the cursor's batch size rows, decoded
+// as by pgx.Rows.Values. It returns io.EOF once all rows have been fetched.
+func (c *Cursor) Next(ctx context.Context) ([][]any, error) {
+	var batch [][]any
+	err := c.fetch(ctx, func(rows pgx.Rows) error {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		batch = append(batch, values)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// fetch fetches the next batch of rows and calls fn for each, returning io.EOF
+// once all rows have been fetched.
+func (c *Cursor) fetch(ctx context.Context, fn func(rows pgx.Rows) error) error {
+	if c.done {
+		return io.EOF
+	}
+	rows, err := c.tx.Query(ctx, fmt.Sprintf("FETCH FORWARD %d FROM bitdotio_cursor", c.batchSize))
+	if err != nil {
+		return fmt.Errorf("unable to fetch from cursor on db %s: %w", c.dbName, err)
+	}
+	defer rows.Close()
+	if c.fields == nil {
+		c.fields = rows.FieldDescriptions()
+		c.columns = make([]string, len(c.fields))
+		for i, field := range c.fields {
+			c.columns[i] = field.Name
+		}
+	}
+	var n int
+	for rows.Next() {
+		n++
+		if err = fn(rows); err != nil {
+			return fmt.Errorf("unable to fetch from cursor on db %s: %w", c.dbName, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("unable to fetch from cursor on db %s: %w", c.dbName, err)
+	}
+	if n < c.batchSize {
+		c.done = true
+	}
+	if n == 0 {
+		return io.EOF
+	}
+	return nil
+}
+
+// Columns returns the names of the result's columns, once Next has been
+// called.
+func (c *Cursor) Columns() []string {
+	return c.columns
+}
+
+// FieldDescriptions returns the descriptions of the result's columns, once
+// Next has been called.
+func (c *Cursor) FieldDescriptions() []pgconn.FieldDescription {
+	return c.fields
+}
+
+// Close closes the cursor and releases its connection. It is safe to call more
+// than once.
+func (c *Cursor) Close() error {
+	if c.conn == nil {
+		return nil
+	}
+	// The transaction only reads, so rolling back closes the cursor whether
+	// or not all rows were fetched.
+	err := c.tx.Rollback(context.Background())
+	c.conn.Release()
+	c.conn, c.done = nil, true
+	if err != nil {
+		return fmt.Errorf("unable to close cursor on db %s: %w", c.dbName, err)
+	}
+	return nil
+}
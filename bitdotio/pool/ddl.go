@@ -0,0 +1,150 @@
+package pool
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// sqlTypes maps Go types with a fixed Postgres representation to that type,
+// and whether the type is nullable.
+var sqlTypes = map[reflect.Type]struct {
+	name     string
+	nullable bool
+}{
+	reflect.TypeOf(time.Time{}):           {"timestamptz", false},
+	reflect.TypeOf(json.RawMessage{}):     {"jsonb", true},
+	reflect.TypeOf([]byte{}):              {"bytea", true},
+	reflect.TypeOf(netip.Addr{}):          {"inet", false},
+	reflect.TypeOf(netip.Prefix{}):        {"cidr", false},
+	reflect.TypeOf(sql.NullBool{}):        {"boolean", true},
+	reflect.TypeOf(sql.NullByte{}):        {"smallint", true},
+	reflect.TypeOf(sql.NullInt16{}):       {"smallint", true},
+	reflect.TypeOf(sql.NullInt32{}):       {"integer", true},
+	reflect.TypeOf(sql.NullInt64{}):       {"bigint", true},
+	reflect.TypeOf(sql.NullFloat64{}):     {"double precision", true},
+	reflect.TypeOf(sql.NullString{}):      {"text", true},
+	reflect.TypeOf(sql.NullTime{}):        {"timestamptz", true},
+	reflect.TypeOf(map[string]any(nil)):   {"jsonb", true},
+	reflect.TypeOf([]map[string]any(nil)): {"jsonb", true},
+}
+
+// identityTypes are the column types that may be tagged auto without an
+// explicit type.
+var identityTypes = map[string]bool{"smallint": true, "integer": true, "bigint": true}
+
+// sqlType returns the Postgres type for Go type t, and whether a column of the
+// type is nullable. Pointers are nullable, slices of scalar types map to
+// arrays, and other maps, slices, and structs map to jsonb.
+func sqlType(t reflect.Type) (string, bool, error) {
+	if known, ok := sqlTypes[t]; ok {
+		return known.name, known.nullable, nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		name, _, err := sqlType(t.Elem())
+		return name, true, err
+	case reflect.Bool:
+		return "boolean", false, nil
+	case reflect.Int8, reflect.Int16, reflect.Uint8:
+		return "smallint", false, nil
+	case reflect.Int32, reflect.Uint16:
+		return "integer", false, nil
+	case reflect.Int, reflect.Int64, reflect.Uint32:
+		return "bigint", false, nil
+	case reflect.Uint, reflect.Uint64:
+		return "numeric", false, nil
+	case reflect.Float32:
+		return "real", false, nil
+	case reflect.Float64:
+		return "double precision", false, nil
+	case reflect.String:
+		return "text", false, nil
+	case reflect.Slice, reflect.Array:
+		name, _, err := sqlType(t.Elem())
+		if err == nil && name != "jsonb" {
+			return name + "[]", true, nil
+		}
+		return "jsonb", true, nil
+	case reflect.Map, reflect.Struct:
+		return "jsonb", t.Kind() == reflect.Map, nil
+	}
+	return "", false, fmt.Errorf("no Postgres type for Go type %s", t)
+}
+
+// CreateTableSQL returns the CREATE TABLE statement for table, optionally
+// qualified by a schema name, with columns mapped from the fields of v, a
+// struct or pointer to struct, as described for Repository.
+//
+// Column types are inferred from field types: e.g. int64 maps to bigint,
+// string to text, time.Time to timestamptz, pointers and sql.Null types to
+// nullable columns, slices to arrays, and maps and structs to jsonb. Other
+// columns are NOT NULL. A field's type may be set explicitly with a tag option,
+// e.g. `db:"price,type=numeric"`. Fields tagged pk form the primary key, and
+// integer fields tagged auto become identity columns.
+func CreateTableSQL(table string, v any) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return "", fmt.Errorf("unable to generate DDL for table %s: nil struct", table)
+	}
+	columns, err := structColumns(t)
+	if err != nil {
+		return "", fmt.Errorf("unable to generate DDL for table %s: %w", table, err)
+	}
+
+	var definitions, keys []string
+	for _, column := range columns {
+		name, nullable, err := sqlType(column.fieldType)
+		if column.sqlType != "" {
+			name, err = column.sqlType, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("unable to generate DDL for column %s of table %s: %w", column.name, table, err)
+		}
+		definition := pgx.Identifier{column.name}.Sanitize() + " " + name
+		if column.auto {
+			if !identityTypes[name] {
+				return "", fmt.Errorf("unable to generate DDL for column %s of table %s: auto requires an integer column", column.name, table)
+			}
+			definition += " GENERATED BY DEFAULT AS IDENTITY"
+		} else if !nullable || column.primaryKey {
+			definition += " NOT NULL"
+		}
+		definitions = append(definitions, definition)
+		if column.primaryKey {
+			keys = append(keys, pgx.Identifier{column.name}.Sanitize())
+		}
+	}
+	if len(keys) > 0 {
+		definitions = append(definitions, "PRIMARY KEY ("+strings.Join(keys, ", ")+")")
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", tableIdentifier(table).Sanitize(), strings.Join(definitions, ",\n\t")), nil
+}
+
+// CreateTableFromStruct creates table in a bit.io database with an existing
+// pool, with columns mapped from the fields of v as described for
+// CreateTableSQL. It fails if the table already exists.
+func (m *Manager) CreateTableFromStruct(ctx context.Context, dbName, table string, v any) error {
+	ddl, err := CreateTableSQL(table, v)
+	if err != nil {
+		return err
+	}
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return fmt.Errorf("unable to create table %s on db %s: %w", table, dbName, err)
+	}
+	if _, err = pool.Exec(ctx, ddl); err != nil {
+		return fmt.Errorf("unable to create table %s on db %s: %w", table, dbName, err)
+	}
+	return nil
+}
@@ -0,0 +1,302 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// defaultDiffMaxRows is the default number of keys a TableDiff records for
+	// each of its added, removed, and changed rows.
+	defaultDiffMaxRows = 100
+
+	// diffBatchSize is the number of row hashes DiffTables fetches from each
+	// table at a time.
+	diffBatchSize = 5000
+)
+
+// DiffConfig configures DiffTablesWithConfig.
+type DiffConfig struct {
+	// KeyColumns identify rows, so that rows with the same key but different
+	// values are reported as changed. Defaults to the primary key of the first
+	// table, if all of its columns are in both tables. Without a key, rows are
+	// compared whole, so a changed row is reported as one removed and one
+	// added row.
+	KeyColumns []string
+	// MaxRows is the number of keys recorded for each of the added, removed,
+	// and changed rows; all differences are counted regardless. Defaults to
+	// 100; a negative MaxRows records none.
+	MaxRows int
+}
+
+// ColumnChange describes a column whose type differs between two tables.
+type ColumnChange struct {
+	Name  string
+	TypeA string
+	TypeB string
+}
+
+// TableDiff describes the differences between two tables, from the first
+// table (A) to the second (B).
+type TableDiff struct {
+	// AddedColumns are the columns only in B, and RemovedColumns the columns
+	// only in A. Rows are compared on the columns in both tables.
+	AddedColumns   []string
+	RemovedColumns []string
+	// ChangedColumns are the columns whose types differ. Their values are
+	// compared as text.
+	ChangedColumns []ColumnChange
+	// KeyColumns are the columns that identify rows, or nil if rows were
+	// compared whole.
+	KeyColumns []string
+	// Added, Removed, and Changed hold the keys of up to MaxRows rows that are
+	// only in B, only in A, or in both with different values, as Postgres
+	// record text, e.g. (1) or (1,"a b"). Without KeyColumns, they hold
+	// the MD5 hashes of whole rows as text.
+	Added   []string
+	Removed []string
+	Changed []string
+	// NumAdded, NumRemoved, NumChanged, and NumEqual count all rows.
+	NumAdded   int64
+	NumRemoved int64
+	NumChanged int64
+	NumEqual   int64
+}
+
+// Equal reports whether the tables have the same columns and rows.
+func (d *TableDiff) Equal() bool {
+	return len(d.AddedColumns) == 0 && len(d.RemovedColumns) == 0 && len(d.ChangedColumns) == 0 &&
+		d.NumAdded == 0 && d.NumRemoved == 0 && d.NumChanged == 0
+}
+
+// DiffTables compares tableA of dbA with tableB of dbB, both bit.io
+// databases with existing pools, and reports the differences of their
+// columns and rows, e.g. to verify an import or a copy between databases.
+// Tables may be schema-qualified, e.g. `public.users`. See
+// DiffTablesWithConfig.
+func (m *Manager) DiffTables(ctx context.Context, dbA, tableA, dbB, tableB string) (*TableDiff, error) {
+	return m.DiffTablesWithConfig(ctx, dbA, tableA, dbB, tableB, &DiffConfig{})
+}
+
+// DiffTablesWithConfig compares two tables as for DiffTables, with config.
+//
+// Each table's rows are hashed by Postgres and streamed, sorted by key, from
+// a Cursor, so neither table is held in memory and only keys and hashes are
+// transferred. The comparison holds a pooled connection to each database
+// until it finishes, so when both tables are in the same database its pool
+// must allow two connections. Each table is read in its own transaction, so
+// to compare consistently, neither table should be written meanwhile.
+func (m *Manager) DiffTablesWithConfig(ctx context.Context, dbA, tableA, dbB, tableB string, config *DiffConfig) (*TableDiff, error) {
+	columnsA, keyA, err := m.tableColumns(ctx, dbA, tableA)
+	if err != nil {
+		return nil, fmt.Errorf("unable to diff table %s on db %s: %w", tableA, dbA, err)
+	}
+	columnsB, _, err := m.tableColumns(ctx, dbB, tableB)
+	if err != nil {
+		return nil, fmt.Errorf("unable to diff table %s on db %s: %w", tableB, dbB, err)
+	}
+
+	diff := &TableDiff{}
+	typesB := make(map[string]string, len(columnsB))
+	for _, column := range columnsB {
+		typesB[column[0]] = column[1]
+	}
+	var common []string
+	for _, column := range columnsA {
+		typeB, ok := typesB[column[0]]
+		if !ok {
+			diff.RemovedColumns = append(diff.RemovedColumns, column[0])
+			continue
+		}
+		delete(typesB, column[0])
+		common = append(common, column[0])
+		if typeB != column[1] {
+			diff.ChangedColumns = append(diff.ChangedColumns, ColumnChange{Name: column[0], TypeA: column[1], TypeB: typeB})
+		}
+	}
+	for _, column := range columnsB {
+		if _, ok := typesB[column[0]]; ok {
+			diff.AddedColumns = append(diff.AddedColumns, column[0])
+		}
+	}
+	if len(common) == 0 {
+		return nil, fmt.Errorf("unable to diff tables %s and %s: no columns in common", tableA, tableB)
+	}
+
+	isCommon := make(map[string]bool, len(common))
+	for _, column := range common {
+		isCommon[column] = true
+	}
+	diff.KeyColumns = config.KeyColumns
+	if diff.KeyColumns == nil {
+		diff.KeyColumns = keyA
+	}
+	for _, column := range diff.KeyColumns {
+		if !isCommon[column] {
+			if config.KeyColumns != nil {
+				return nil, fmt.Errorf("unable to diff tables %s and %s: key column %s is not in both tables", tableA, tableB, column)
+			}
+			diff.KeyColumns = nil
+			break
+		}
+	}
+
+	query := diffQuery(common, diff.KeyColumns)
+	cursorA, err := m.OpenCursor(ctx, dbA, diffBatchSize, fmt.Sprintf(query, tableIdentifier(tableA).Sanitize()))
+	if err != nil {
+		return nil, err
+	}
+	defer cursorA.Close()
+	cursorB, err := m.OpenCursor(ctx, dbB, diffBatchSize, fmt.Sprintf(query, tableIdentifier(tableB).Sanitize()))
+	if err != nil {
+		return nil, err
+	}
+	defer cursorB.Close()
+
+	maxRows := config.MaxRows
+	if maxRows == 0 {
+		maxRows = defaultDiffMaxRows
+	}
+	record := func(keys *[]string, key string) {
+		if len(*keys) < maxRows {
+			*keys = append(*keys, key)
+		}
+	}
+	a, b := &hashIterator{cursor: cursorA}, &hashIterator{cursor: cursorB}
+	rowA, err := a.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rowB, err := b.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Merge the two streams, which are sorted by key in byte order.
+	for rowA != nil || rowB != nil {
+		switch {
+		case rowB == nil || (rowA != nil && rowA.key < rowB.key):
+			diff.NumRemoved++
+			record(&diff.Removed, rowA.key)
+			rowA, err = a.next(ctx)
+		case rowA == nil || rowB.key < rowA.key:
+			diff.NumAdded++
+			record(&diff.Added, rowB.key)
+			rowB, err = b.next(ctx)
+		default:
+			if rowA.hash == rowB.hash {
+				diff.NumEqual++
+			} else {
+				diff.NumChanged++
+				record(&diff.Changed, rowA.key)
+			}
+			if rowA, err = a.next(ctx); err == nil {
+				rowB, err = b.next(ctx)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+// tableColumns returns the names and types of the columns of table, in order,
+// and the names of its primary key columns.
+func (m *Manager) tableColumns(ctx context.Context, dbName, table string) ([][2]string, []string, error) {
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Release()
+	name := tableIdentifier(table).Sanitize()
+	rows, err := conn.Query(ctx, `SELECT a.attname, format_type(a.atttypid, a.atttypmod)
+		FROM pg_attribute a
+		WHERE a.attrelid = $1::text::regclass AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	columns, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) ([2]string, error) {
+		var column [2]string
+		err := row.Scan(&column[0], &column[1])
+		return column, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err = conn.Query(ctx, `SELECT a.attname
+		FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::text::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, nil, err
+	}
+	return columns, key, nil
+}
+
+// diffQuery returns a query, with a %s verb for the table name, selecting the
+// key and an MD5 hash of the columns of each row, sorted by key in byte
+// order. Without keyColumns, the hash is the key.
+func diffQuery(columns, keyColumns []string) string {
+	sanitize := func(names []string) string {
+		sanitized := make([]string, len(names))
+		for i, name := range names {
+			sanitized[i] = pgx.Identifier{name}.Sanitize()
+		}
+		return strings.Join(sanitized, ", ")
+	}
+	hash := fmt.Sprintf("md5(ROW(%s)::text)", sanitize(columns))
+	key := hash
+	if len(keyColumns) > 0 {
+		key = fmt.Sprintf("ROW(%s)::text", sanitize(keyColumns))
+	}
+	return fmt.Sprintf(`SELECT %s COLLATE "C" AS key, %s AS hash FROM %%s ORDER BY 1`, key, hash)
+}
+
+// rowHash is the key and hash of a row.
+type rowHash struct {
+	key  string
+	hash string
+}
+
+// hashIterator reads the rows of a diffQuery from a Cursor one at a time.
+type hashIterator struct {
+	cursor *Cursor
+	batch  []rowHash
+	pos    int
+	done   bool
+}
+
+// next returns the next row, or nil after the last row.
+func (it *hashIterator) next(ctx context.Context) (*rowHash, error) {
+	if it.pos == len(it.batch) {
+		if it.done {
+			return nil, nil
+		}
+		it.batch, it.pos = it.batch[:0], 0
+		err := it.cursor.fetch(ctx, func(rows pgx.Rows) error {
+			var row rowHash
+			if err := rows.Scan(&row.key, &row.hash); err != nil {
+				return err
+			}
+			it.batch = append(it.batch, row)
+			return nil
+		})
+		if err == io.EOF {
+			it.done = true
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	it.pos++
+	return &it.batch[it.pos-1], nil
+}
@@ -0,0 +1,162 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrAcquireTimeout indicates that no pool connection became available within
+// the pool's AcquireTimeout.
+var ErrAcquireTimeout = errors.New("timed out waiting to acquire a connection")
+
+// ErrPoolExists indicates that CreatePool was called for a database that
+// already has an open pool, e.g. after losing a race with another goroutine
+// creating it.
+var ErrPoolExists = errors.New("pool already exists")
+
+// PingErrorKind classifies the cause of a failed Ping.
+type PingErrorKind int
+
+const (
+	// PingErrorUnknown indicates a failure that could not be classified.
+	PingErrorUnknown PingErrorKind = iota
+	// PingErrorAuth indicates that the database rejected the access token.
+	PingErrorAuth
+	// PingErrorSleeping indicates that the database is asleep and not yet
+	// accepting connections.
+	PingErrorSleeping
+	// PingErrorNetwork indicates that the database could not be reached.
+	PingErrorNetwork
+)
+
+func (k PingErrorKind) String() string {
+	switch k {
+	case PingErrorAuth:
+		return "auth"
+	case PingErrorSleeping:
+		return "sleeping"
+	case PingErrorNetwork:
+		return "network"
+	default:
+		return "unknown"
+	}
+}
+
+// PingError indicates a failed Ping of a bit.io database.
+type PingError struct {
+	DBName string
+	Kind   PingErrorKind
+	Err    error
+}
+
+func (e *PingError) Error() string {
+	return fmt.Sprintf("ping failed for db %s (%s): %v", e.DBName, e.Kind, e.Err)
+}
+
+func (e *PingError) Unwrap() error {
+	return e.Err
+}
+
+// newPingError wraps err in a *PingError with a classified Kind.
+func newPingError(dbName string, err error) *PingError {
+	return &PingError{DBName: dbName, Kind: classifyPingError(err), Err: err}
+}
+
+// classifyPingError maps connection and query errors to a PingErrorKind.
+func classifyPingError(err error) PingErrorKind {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		// invalid_authorization_specification, invalid_password
+		case "28000", "28P01":
+			return PingErrorAuth
+		// cannot_connect_now, returned while a database is waking up
+		case "57P03":
+			return PingErrorSleeping
+		}
+		return PingErrorUnknown
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return PingErrorNetwork
+	}
+	return PingErrorUnknown
+}
+
+// IsNetworkError reports whether err is a network error, such as a refused or
+// timed out connection, e.g. when outbound connections to the database port
+// are blocked.
+func IsNetworkError(err error) bool {
+	return classifyPingError(err) == PingErrorNetwork
+}
+
+// Errors returned by ClassifyError for common Postgres error conditions.
+var (
+	// ErrUniqueViolation indicates that a statement violated a unique
+	// constraint (SQLSTATE 23505).
+	ErrUniqueViolation = errors.New("unique violation")
+	// ErrForeignKeyViolation indicates that a statement violated a foreign key
+	// constraint (SQLSTATE 23503).
+	ErrForeignKeyViolation = errors.New("foreign key violation")
+	// ErrNotNullViolation indicates that a statement violated a not-null
+	// constraint (SQLSTATE 23502).
+	ErrNotNullViolation = errors.New("not null violation")
+	// ErrInsufficientPrivilege indicates that the user lacks permission for a
+	// statement, e.g. a read-only token writing to a database (SQLSTATE 42501).
+	ErrInsufficientPrivilege = errors.New("insufficient privilege")
+	// ErrQueryCanceled indicates that a query was cancelled, e.g. by a
+	// statement timeout or CancelQuery (SQLSTATE 57014).
+	ErrQueryCanceled = errors.New("query canceled")
+	// ErrTooManyConnections indicates that the database's connection limit was
+	// reached, e.g. because other clients hold the plan's connections (SQLSTATE
+	// 53300).
+	ErrTooManyConnections = errors.New("too many connections")
+)
+
+// pgErrorKinds maps SQLSTATE codes to the errors returned by ClassifyError.
+var pgErrorKinds = map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"42501": ErrInsufficientPrivilege,
+	"57014": ErrQueryCanceled,
+	"53300": ErrTooManyConnections,
+}
+
+// DBError is a Postgres error classified by ClassifyError. errors.Is reports
+// whether it matches its Kind, and errors.As can still extract the underlying
+// *pgconn.PgError.
+type DBError struct {
+	Kind error
+	Err  error
+}
+
+func (e *DBError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DBError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// ClassifyError wraps err in a *DBError if it wraps a *pgconn.PgError with a
+// SQLSTATE known to the SDK, so that callers can test for conditions such as
+// errors.Is(err, ErrUniqueViolation). Other errors are returned unchanged.
+func ClassifyError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	if kind, ok := pgErrorKinds[pgErr.Code]; ok {
+		return &DBError{Kind: kind, Err: err}
+	}
+	return err
+}
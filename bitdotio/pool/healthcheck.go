@@ -0,0 +1,179 @@
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DatabaseHealth contains the result of the most recent health check for a
+// bit.io database.
+type DatabaseHealth struct {
+	DBName      string        `json:"db_name"`
+	Up          bool          `json:"up"`
+	LastError   string        `json:"last_error,omitempty"`
+	Latency     time.Duration `json:"latency"`
+	LastChecked time.Time     `json:"last_checked"`
+}
+
+// HealthStatus contains aggregated health check results for all managed pools.
+type HealthStatus struct {
+	Healthy   bool                       `json:"healthy"`
+	Databases map[string]*DatabaseHealth `json:"databases"`
+}
+
+// HealthChecker periodically pings all connection pools managed by a Manager
+// and records per-database status. HealthChecker implements http.Handler, so
+// it can be registered directly as a /healthz endpoint.
+type HealthChecker struct {
+	m        *Manager
+	interval time.Duration
+	timeout  time.Duration
+
+	lock   sync.RWMutex
+	status map[string]*DatabaseHealth
+
+	// runLock guards started and stopped, so that Stop closes done itself
+	// for a checker that never started.
+	runLock sync.Mutex
+	started bool
+	stopped bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewHealthChecker constructs a HealthChecker that pings every managed pool once
+// per interval, allowing each ping up to timeout to complete. The checker does
+// not run until Start is called.
+func (m *Manager) NewHealthChecker(interval, timeout time.Duration) *HealthChecker {
+	return &HealthChecker{
+		m:        m,
+		interval: interval,
+		timeout:  timeout,
+		status:   make(map[string]*DatabaseHealth),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs an initial check and then begins checking in the background until
+// Stop is called. Calls to Start after the first, or after Stop, do nothing.
+func (h *HealthChecker) Start() {
+	h.runLock.Lock()
+	if h.started || h.stopped {
+		h.runLock.Unlock()
+		return
+	}
+	h.started = true
+	h.m.lock.Lock()
+	h.m.checkers[h] = true
+	h.m.lock.Unlock()
+	h.runLock.Unlock()
+	h.CheckNow(context.Background())
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				h.CheckNow(context.Background())
+			case <-h.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop halts background checking and waits for any in-progress check to finish.
+// Stop returns at once if the checker was never started.
+func (h *HealthChecker) Stop() {
+	h.runLock.Lock()
+	if !h.stopped {
+		h.stopped = true
+		close(h.stop)
+		if !h.started {
+			close(h.done)
+		}
+		h.m.lock.Lock()
+		delete(h.m.checkers, h)
+		h.m.lock.Unlock()
+	}
+	h.runLock.Unlock()
+	<-h.done
+}
+
+// CheckNow pings every managed pool concurrently and records the results.
+func (h *HealthChecker) CheckNow(ctx context.Context) {
+	dbNames := h.m.poolNames()
+	results := make([]*DatabaseHealth, len(dbNames))
+	var wg sync.WaitGroup
+	for i, dbName := range dbNames {
+		wg.Add(1)
+		go func(i int, dbName string) {
+			defer wg.Done()
+			results[i] = h.check(ctx, dbName)
+		}(i, dbName)
+	}
+	wg.Wait()
+
+	status := make(map[string]*DatabaseHealth, len(results))
+	for _, result := range results {
+		status[result.DBName] = result
+	}
+	h.lock.Lock()
+	h.status = status
+	h.lock.Unlock()
+}
+
+// check pings a single database and records latency and any error.
+func (h *HealthChecker) check(ctx context.Context, dbName string) *DatabaseHealth {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	start := time.Now()
+	err := h.m.Ping(ctx, dbName)
+	result := &DatabaseHealth{
+		DBName:      dbName,
+		Up:          err == nil,
+		Latency:     time.Since(start),
+		LastChecked: start,
+	}
+	if err != nil {
+		result.LastError = err.Error()
+	}
+	return result
+}
+
+// Status returns a snapshot of the most recent health check results. The status
+// is healthy only if every managed database was up during the last check.
+func (h *HealthChecker) Status() *HealthStatus {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	status := &HealthStatus{
+		Healthy:   true,
+		Databases: make(map[string]*DatabaseHealth, len(h.status)),
+	}
+	for dbName, dbHealth := range h.status {
+		result := *dbHealth
+		status.Databases[dbName] = &result
+		if !result.Up {
+			status.Healthy = false
+		}
+	}
+	return status
+}
+
+// ServeHTTP writes the current HealthStatus as JSON, with a 503 status code if
+// any managed database is down.
+func (h *HealthChecker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		h.m.logfCtx(r.Context(), "bitdotio: unable to write health status: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package pool
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ImportRows copies rows from any database/sql source, such as a local
+// Postgres or MySQL table, into an existing table of a bit.io database with an
+// existing pool, and returns the number of rows copied. table may be
+// schema-qualified, e.g. `public.users`, and must have a column for each
+// column of rows, matched by name. The rows stream through COPY FROM STDIN
+// without being buffered, and are closed when ImportRows returns.
+//
+// Values are copied as scanned by the source driver, so they must be
+// compatible with the destination column types; e.g. some MySQL drivers
+// return text as []byte, which suits text and bytea columns but not numbers.
+func (m *Manager) ImportRows(ctx context.Context, dbName, table string, rows *sql.Rows) (int64, error) {
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, fmt.Errorf("unable to import rows into %s on db %s: %w", table, dbName, err)
+	}
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to import rows into %s on db %s: %w", table, dbName, err)
+	}
+	n, err := pool.CopyFrom(ctx, tableIdentifier(table), columns, &sqlRowsSource{rows: rows, values: make([]any, len(columns))})
+	if err != nil {
+		return n, fmt.Errorf("unable to import rows into %s on db %s: %w", table, dbName, err)
+	}
+	return n, nil
+}
+
+// sqlRowsSource adapts *sql.Rows to pgx.CopyFromSource.
+type sqlRowsSource struct {
+	rows   *sql.Rows
+	values []any
+	err    error
+}
+
+var _ pgx.CopyFromSource = (*sqlRowsSource)(nil)
+
+func (s *sqlRowsSource) Next() bool {
+	return s.err == nil && s.rows.Next()
+}
+
+func (s *sqlRowsSource) Values() ([]any, error) {
+	dest := make([]any, len(s.values))
+	for i := range s.values {
+		s.values[i] = nil
+		dest[i] = &s.values[i]
+	}
+	if err := s.rows.Scan(dest...); err != nil {
+		s.err = err
+		return nil, err
+	}
+	return s.values, nil
+}
+
+func (s *sqlRowsSource) Err() error {
+	if s.err != nil {
+		return s.err
+	}
+	return s.rows.Err()
+}
@@ -0,0 +1,108 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	// listenMinBackoff is the initial delay before reconnecting a listener.
+	listenMinBackoff = 500 * time.Millisecond
+
+	// listenMaxBackoff is the maximum delay between listener reconnect attempts.
+	listenMaxBackoff = 30 * time.Second
+)
+
+// Notification is a message received from a Postgres NOTIFY.
+type Notification = pgconn.Notification
+
+// Listen subscribes to a Postgres notification channel on a bit.io database
+// with an existing pool. Notifications are delivered on the returned Go channel
+// until ctx is done, at which point the Go channel is closed.
+//
+// Listen uses a dedicated connection, configured like the pool's connections
+// but not counted against the pool's size. If the connection drops, it is
+// reestablished with exponential backoff; notifications sent while
+// disconnected are not delivered. An error is returned only if the initial
+// connection fails.
+func (m *Manager) Listen(ctx context.Context, dbName, channel string) (<-chan *Notification, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on db %s: %w", dbName, err)
+	}
+	poolConfig := pool.Config()
+	connect := func(ctx context.Context) (*pgx.Conn, error) {
+		return listenConn(ctx, poolConfig.ConnConfig, poolConfig.BeforeConnect, channel)
+	}
+	conn, err := connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on db %s: %w", dbName, err)
+	}
+
+	notifications := make(chan *Notification)
+	go func() {
+		defer close(notifications)
+		for {
+			notification, err := conn.WaitForNotification(ctx)
+			if err == nil {
+				select {
+				case notifications <- notification:
+					continue
+				case <-ctx.Done():
+				}
+			}
+			conn.Close(context.Background())
+			if ctx.Err() != nil {
+				return
+			}
+			m.logfCtx(ctx, "bitdotio: listener for channel %s on db %s disconnected, reconnecting: %v", channel, dbName, err)
+			if conn = reconnectListener(ctx, connect); conn == nil {
+				return
+			}
+		}
+	}()
+	return notifications, nil
+}
+
+// listenConn opens a new connection, calling beforeConnect with a copy of
+// connConfig if set, and subscribes it to channel.
+func listenConn(ctx context.Context, connConfig *pgx.ConnConfig, beforeConnect func(context.Context, *pgx.ConnConfig) error, channel string) (*pgx.Conn, error) {
+	if beforeConnect != nil {
+		connConfig = connConfig.Copy()
+		if err := beforeConnect(ctx, connConfig); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+// reconnectListener retries connect with exponential backoff until it
+// succeeds or ctx is done, in which case it returns nil.
+func reconnectListener(ctx context.Context, connect func(context.Context) (*pgx.Conn, error)) *pgx.Conn {
+	backoff := listenMinBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		if conn, err := connect(ctx); err == nil {
+			return conn
+		}
+		if backoff *= 2; backoff > listenMaxBackoff {
+			backoff = listenMaxBackoff
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package pool
+
+import (
+	"context"
+	"math"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// defaultHeadroom is the default fraction of a database's connection limit
+// that AutoMaxConns leaves for other clients.
+const defaultHeadroom = 0.2
+
+// AutoMaxConns sizes a pool from the connection limit of the database's plan,
+// instead of a guessed MaxConns that can fail with "too many connections" once
+// other clients, such as other processes or psql sessions, hold connections.
+//
+// The limit is read from ManagerConfig.ConnLimit, which BitDotIO sets to read
+// it from the developer API, and otherwise from Postgres: the smallest of the
+// connecting role's and the database's connection limits and the server's
+// max_connections, less its superuser_reserved_connections. The pool's
+// maximum size is the limit less Headroom and Reserve, and at least 1.
+type AutoMaxConns struct {
+	// Headroom is the fraction of the limit left for other clients, e.g. 0.25.
+	// Defaults to 0.2; a negative Headroom leaves none.
+	Headroom float64
+	// Reserve is a number of connections left for other clients in addition to
+	// Headroom.
+	Reserve int32
+}
+
+// size returns the maximum pool size for a connection limit.
+func (a *AutoMaxConns) size(limit int32) int32 {
+	headroom := a.Headroom
+	if headroom == 0 {
+		headroom = defaultHeadroom
+	} else if headroom < 0 {
+		headroom = 0
+	}
+	size := int32(math.Floor(float64(limit)*(1-headroom))) - a.Reserve
+	if size < 1 {
+		return 1
+	}
+	return size
+}
+
+// autoMaxConns returns the maximum size of a pool for dbName configured with
+// config.AutoMaxConns, reading the connection limit with the Manager's
+// ConnLimit or else over a connection with poolConfig's connection config.
+func (m *Manager) autoMaxConns(ctx context.Context, dbName string, config *PoolConfig, poolConfig *pgxpool.Config) (int32, error) {
+	var limit int32
+	if m.config.ConnLimit != nil {
+		var err error
+		if limit, err = m.config.ConnLimit(ctx, dbName); err != nil {
+			return 0, err
+		}
+	}
+	if limit <= 0 {
+		var err error
+		if limit, err = postgresConnLimit(ctx, poolConfig.ConnConfig.Copy(), config.BeforeConnect); err != nil {
+			return 0, err
+		}
+	}
+	size := config.AutoMaxConns.size(limit)
+	m.logfCtx(ctx, "bitdotio: sizing pool for db %s to %d connections for a limit of %d", dbName, size, limit)
+	return size, nil
+}
+
+// postgresConnLimit connects with connConfig and returns the number of
+// connections the connecting role can open to the database.
+func postgresConnLimit(ctx context.Context, connConfig *pgx.ConnConfig, beforeConnect func(context.Context, *pgx.ConnConfig) error) (int32, error) {
+	if beforeConnect != nil {
+		if err := beforeConnect(ctx, connConfig); err != nil {
+			return 0, err
+		}
+	}
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close(context.Background())
+	var serverLimit, roleLimit, databaseLimit int32
+	err = conn.QueryRow(ctx, `SELECT
+		current_setting('max_connections')::int - current_setting('superuser_reserved_connections')::int,
+		COALESCE((SELECT rolconnlimit FROM pg_roles WHERE rolname = current_user), -1),
+		COALESCE((SELECT datconnlimit FROM pg_database WHERE datname = current_database()), -1)`,
+	).Scan(&serverLimit, &roleLimit, &databaseLimit)
+	if err != nil {
+		return 0, err
+	}
+	// Role and database limits of -1 mean no limit.
+	limit := serverLimit
+	for _, l := range []int32{roleLimit, databaseLimit} {
+		if l >= 0 && l < limit {
+			limit = l
+		}
+	}
+	return limit, nil
+}
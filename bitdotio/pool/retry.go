@@ -0,0 +1,86 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// retryMaxAttempts is the maximum number of attempts made by WithRetry.
+	retryMaxAttempts = 3
+
+	// retryMinBackoff is the delay before the first retry made by WithRetry.
+	retryMinBackoff = 100 * time.Millisecond
+)
+
+// WithRetry acquires a connection from an existing pool for a bit.io database
+// and calls fn with it, retrying with backoff if fn or connection acquisition
+// fails with an error classified as retryable by IsRetryable. Connections to
+// bit.io drop more often than connections to a local Postgres server, so this
+// is recommended for short units of work.
+//
+// A dropped connection can hide whether a statement took effect, so fn must be
+// idempotent or run its statements in a single transaction that it commits
+// last. The connection is released when fn returns.
+func (m *Manager) WithRetry(ctx context.Context, dbName string, fn func(conn *pgxpool.Conn) error) error {
+	backoff := retryMinBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		err = m.tryOnce(ctx, dbName, fn)
+		if err == nil || attempt == retryMaxAttempts || !IsRetryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// tryOnce acquires a connection and calls fn with it.
+func (m *Manager) tryOnce(ctx context.Context, dbName string, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.Connect(ctx, dbName)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	return fn(conn)
+}
+
+// IsRetryable reports whether err indicates a failure that may succeed if
+// retried: a transaction serialization failure or deadlock, or a connection
+// level failure such as a dropped connection or a server restart. Errors in the
+// query itself, such as syntax errors or constraint violations, are not
+// retryable, nor is context cancellation.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		// serialization_failure, deadlock_detected
+		case "40001", "40P01":
+			return true
+		// admin_shutdown, crash_shutdown, cannot_connect_now
+		case "57P01", "57P02", "57P03":
+			return true
+		}
+		// Class 08: connection exceptions
+		return strings.HasPrefix(pgErr.Code, "08")
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,146 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// savedQueriesTable is the table, in each database's public schema, that
+// stores saved queries.
+const savedQueriesTable = "bitdotio_saved_queries"
+
+// Note for reviewers: the developer API has no saved query endpoints, so saved
+// queries are stored in a table in the database itself. This keeps them
+// versioned with the data they query and readable by any client.
+
+// SavedQuery is a named query stored in a bit.io database.
+type SavedQuery struct {
+	Name        string
+	Query       string
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ErrSavedQueryNotFound is returned when no saved query has a requested name.
+var ErrSavedQueryNotFound = errors.New("saved query not found")
+
+// SaveQuery creates or replaces a named query in a bit.io database with an
+// existing pool. The saved queries table is created on first use.
+func (m *Manager) SaveQuery(ctx context.Context, dbName, name, query, description string) error {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return fmt.Errorf("unable to save query %s on db %s: %w", name, dbName, err)
+	}
+	_, err = pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+savedQueriesTable+` (
+		name text PRIMARY KEY,
+		query text NOT NULL,
+		description text NOT NULL DEFAULT '',
+		created_at timestamptz NOT NULL DEFAULT now(),
+		updated_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("unable to create saved queries table on db %s: %w", dbName, err)
+	}
+	_, err = pool.Exec(ctx, `INSERT INTO `+savedQueriesTable+` (name, query, description) VALUES ($1, $2, $3)
+		ON CONFLICT (name) DO UPDATE SET query = excluded.query, description = excluded.description, updated_at = now()`,
+		name, query, description)
+	if err != nil {
+		return fmt.Errorf("unable to save query %s on db %s: %w", name, dbName, err)
+	}
+	return nil
+}
+
+// GetSavedQuery gets a named query from a bit.io database with an existing
+// pool, returning ErrSavedQueryNotFound if there is no such query.
+func (m *Manager) GetSavedQuery(ctx context.Context, dbName, name string) (*SavedQuery, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get saved query %s on db %s: %w", name, dbName, err)
+	}
+	rows, err := pool.Query(ctx, `SELECT name, query, description, created_at, updated_at FROM `+savedQueriesTable+` WHERE name = $1`, name)
+	if err != nil {
+		return nil, savedQueryError(name, dbName, err)
+	}
+	savedQuery, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByPos[SavedQuery])
+	if err != nil {
+		return nil, savedQueryError(name, dbName, err)
+	}
+	return savedQuery, nil
+}
+
+// ListSavedQueries lists the named queries in a bit.io database with an
+// existing pool, ordered by name.
+func (m *Manager) ListSavedQueries(ctx context.Context, dbName string) ([]*SavedQuery, error) {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list saved queries on db %s: %w", dbName, err)
+	}
+	rows, err := pool.Query(ctx, `SELECT name, query, description, created_at, updated_at FROM `+savedQueriesTable+` ORDER BY name`)
+	if err == nil {
+		var savedQueries []*SavedQuery
+		if savedQueries, err = pgx.CollectRows(rows, pgx.RowToAddrOfStructByPos[SavedQuery]); err == nil {
+			return savedQueries, nil
+		}
+	}
+	if isUndefinedTable(err) {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unable to list saved queries on db %s: %w", dbName, err)
+}
+
+// DeleteSavedQuery deletes a named query from a bit.io database with an
+// existing pool, returning ErrSavedQueryNotFound if there is no such query.
+func (m *Manager) DeleteSavedQuery(ctx context.Context, dbName, name string) error {
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return fmt.Errorf("unable to delete saved query %s on db %s: %w", name, dbName, err)
+	}
+	tag, err := pool.Exec(ctx, `DELETE FROM `+savedQueriesTable+` WHERE name = $1`, name)
+	if err == nil && tag.RowsAffected() == 0 {
+		err = pgx.ErrNoRows
+	}
+	if err != nil {
+		return savedQueryError(name, dbName, err)
+	}
+	return nil
+}
+
+// ExecuteSavedQuery runs a named query from a bit.io database with an existing
+// pool, passing args as its query parameters. The caller must close the
+// returned rows.
+func (m *Manager) ExecuteSavedQuery(ctx context.Context, dbName, name string, args ...any) (pgx.Rows, error) {
+	savedQuery, err := m.GetSavedQuery(ctx, dbName, name)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute saved query %s on db %s: %w", name, dbName, err)
+	}
+	rows, err := pool.Query(ctx, savedQuery.Query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to execute saved query %s on db %s: %w", name, dbName, err)
+	}
+	return rows, nil
+}
+
+// savedQueryError wraps err for a saved query lookup, mapping a missing row or
+// missing table to ErrSavedQueryNotFound.
+func savedQueryError(name, dbName string, err error) error {
+	if errors.Is(err, pgx.ErrNoRows) || isUndefinedTable(err) {
+		err = ErrSavedQueryNotFound
+	}
+	return fmt.Errorf("unable to get saved query %s on db %s: %w", name, dbName, err)
+}
+
+// isUndefinedTable reports whether err is a Postgres undefined_table error.
+func isUndefinedTable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "42P01"
+}
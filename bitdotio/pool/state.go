@@ -0,0 +1,64 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// DatabaseState indicates whether a bit.io database is accepting connections.
+type DatabaseState int
+
+const (
+	// DatabaseStateUnknown indicates that the state could not be determined.
+	DatabaseStateUnknown DatabaseState = iota
+	// DatabaseStateActive indicates that the database is accepting queries.
+	DatabaseStateActive
+	// DatabaseStateSleeping indicates that the database is hibernated or still
+	// waking up.
+	DatabaseStateSleeping
+)
+
+func (s DatabaseState) String() string {
+	switch s {
+	case DatabaseStateActive:
+		return "active"
+	case DatabaseStateSleeping:
+		return "sleeping"
+	default:
+		return "unknown"
+	}
+}
+
+// State probes a bit.io database with an existing pool and reports whether it is
+// active or sleeping. Probing connects to the database, which also begins
+// waking a sleeping database. An error is returned only if the state could not
+// be determined, in which case the state is DatabaseStateUnknown.
+func (m *Manager) State(ctx context.Context, dbName string) (DatabaseState, error) {
+	err := m.Ping(ctx, dbName)
+	if err == nil {
+		return DatabaseStateActive, nil
+	}
+	var pingErr *PingError
+	if errors.As(err, &pingErr) && pingErr.Kind == PingErrorSleeping {
+		return DatabaseStateSleeping, nil
+	}
+	return DatabaseStateUnknown, err
+}
+
+// Wake wakes a bit.io database with an existing pool and waits until it is
+// active or ctx is done, so that schedulers can wake a database ahead of a
+// batch job rather than on its critical path.
+func (m *Manager) Wake(ctx context.Context, dbName string) error {
+	for {
+		state, err := m.State(ctx, dbName)
+		if err != nil || state == DatabaseStateActive {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wakeRetryInterval):
+		}
+	}
+}
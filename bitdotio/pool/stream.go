@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"context"
+	"io"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// streamFetchSize is the number of rows StreamQuery fetches from its cursor at
+// a time.
+const streamFetchSize = 1000
+
+// StreamedRow is a row delivered by StreamQuery, or the error that ended the
+// stream.
+type StreamedRow struct {
+	// Columns names the columns of Values, and is shared by all rows of a
+	// stream.
+	Columns []string
+	Values  []any
+	Err     error
+}
+
+// StreamQuery runs a query on a bit.io database with an existing pool and
+// delivers its rows, decoded as by pgx.Rows.Values, on the returned channel,
+// which is closed after the last row. If the query fails after it starts, a
+// final StreamedRow with Err set is delivered before the channel is closed.
+//
+// Rows are fetched from a Cursor, streamFetchSize at a time, on a
+// pooled connection held until the stream ends, so memory use is bounded and
+// a slow consumer holds back the query rather than buffering its result.
+// Cancel ctx to stop reading early. The query runs in a read-only
+// transaction.
+func (m *Manager) StreamQuery(ctx context.Context, dbName, query string, args ...any) (<-chan *StreamedRow, error) {
+	cursor, err := m.OpenCursor(ctx, dbName, streamFetchSize, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make(chan *StreamedRow)
+	go func() {
+		defer close(rows)
+		defer cursor.Close()
+		if err := streamCursor(ctx, cursor, rows); err != nil && ctx.Err() == nil {
+			select {
+			case rows <- &StreamedRow{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return rows, nil
+}
+
+// streamCursor fetches the rows of cursor and sends them on rows until the
+// cursor is exhausted or ctx is done.
+func streamCursor(ctx context.Context, cursor *Cursor, rows chan<- *StreamedRow) error {
+	for {
+		err := cursor.fetch(ctx, func(batch pgx.Rows) error {
+			values, err := batch.Values()
+			if err != nil {
+				return err
+			}
+			select {
+			case rows <- &StreamedRow{Columns: cursor.Columns(), Values: values}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package pool
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// structTagKey is the struct tag that maps fields to columns, matching the tag
+// used by pgx.RowToStructByName.
+const structTagKey = "db"
+
+// structColumn maps a struct field to a table column. Columns are named by the
+// first element of a field's db tag, or the lowercased field name if there is
+// no tag. Further comma-separated tag options are:
+//
+//   - pk: the column is part of the table's primary key
+//   - auto: the column is generated by the database, e.g. a serial or identity
+//     column, so it is omitted from inserts and read back afterwards
+//   - type=<sql type>: the column's Postgres type, overriding the type inferred
+//     from the field by CreateTableFromStruct. The type may not contain a
+//     comma, so use e.g. numeric rather than numeric(10,2).
+type structColumn struct {
+	name       string
+	index      []int
+	fieldType  reflect.Type
+	primaryKey bool
+	auto       bool
+	sqlType    string
+}
+
+// structColumns maps the exported fields of struct type t to columns. Fields
+// tagged `db:"-"` are skipped and embedded structs are flattened, as in
+// pgx.RowToStructByName.
+func structColumns(t reflect.Type) ([]structColumn, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s is not a struct type", t)
+	}
+	var columns []structColumn
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			embedded, err := structColumns(sf.Type)
+			if err != nil {
+				return nil, err
+			}
+			for _, column := range embedded {
+				column.index = append([]int{i}, column.index...)
+				columns = append(columns, column)
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		tag, hasTag := sf.Tag.Lookup(structTagKey)
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if !hasTag || name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		column := structColumn{name: name, index: sf.Index, fieldType: sf.Type}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "pk":
+				column.primaryKey = true
+			case "auto":
+				column.auto = true
+			case "":
+			default:
+				if strings.HasPrefix(option, "type=") && len(option) > len("type=") {
+					column.sqlType = strings.TrimPrefix(option, "type=")
+					continue
+				}
+				return nil, fmt.Errorf("unknown db tag option %q on field %s.%s", option, t, sf.Name)
+			}
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%s has no columns", t)
+	}
+	return columns, nil
+}
+
+// columnNames returns the sanitized, comma-separated names of columns.
+func columnNames(columns []structColumn) string {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = pgx.Identifier{column.name}.Sanitize()
+	}
+	return strings.Join(names, ", ")
+}
+
+// columnValues returns the values of columns in struct value v.
+func columnValues(v reflect.Value, columns []structColumn) []any {
+	values := make([]any, len(columns))
+	for i, column := range columns {
+		values[i] = v.FieldByIndex(column.index).Interface()
+	}
+	return values
+}
+
+// columnTargets returns pointers to the fields of columns in struct value v,
+// which must be addressable.
+func columnTargets(v reflect.Value, columns []structColumn) []any {
+	targets := make([]any, len(columns))
+	for i, column := range columns {
+		targets[i] = v.FieldByIndex(column.index).Addr().Interface()
+	}
+	return targets
+}
+
+// tableIdentifier parses a table name, optionally qualified by a schema name,
+// e.g. `my_schema.my_table`.
+func tableIdentifier(table string) pgx.Identifier {
+	return pgx.Identifier(strings.Split(table, "."))
+}
+
+// placeholders returns n comma-separated query parameter placeholders,
+// starting at $start.
+func placeholders(start, n int) string {
+	params := make([]string, n)
+	for i := range params {
+		params[i] = fmt.Sprintf("$%d", start+i)
+	}
+	return strings.Join(params, ", ")
+}
+
+// columnConditions returns equality conditions on columns joined by sep, with
+// query parameters starting at $start.
+func columnConditions(columns []structColumn, start int, sep string) string {
+	conditions := make([]string, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf("%s = $%d", pgx.Identifier{column.name}.Sanitize(), start+i)
+	}
+	return strings.Join(conditions, sep)
+}
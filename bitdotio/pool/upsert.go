@@ -0,0 +1,131 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UpsertSQL returns an INSERT ... ON CONFLICT DO UPDATE statement for table,
+// optionally qualified by a schema name, taking one query parameter per column.
+// conflictColumns must match a unique index or constraint, such as the primary
+// key, and the remaining columns are updated when a row conflicts. If every
+// column is a conflict column, conflicting rows are left unchanged.
+func UpsertSQL(table string, columns, conflictColumns []string) (string, error) {
+	if len(columns) == 0 || len(conflictColumns) == 0 {
+		return "", fmt.Errorf("unable to build upsert for table %s: columns and conflict columns are required", table)
+	}
+	return upsertSQL(tableIdentifier(table).Sanitize(), columns, conflictColumns), nil
+}
+
+// upsertSQL builds the statement for UpsertSQL, for an already sanitized table
+// name.
+func upsertSQL(table string, columns, conflictColumns []string) string {
+	isConflict := make(map[string]bool, len(conflictColumns))
+	for _, column := range conflictColumns {
+		isConflict[column] = true
+	}
+	names := make([]string, len(columns))
+	var updates []string
+	for i, column := range columns {
+		names[i] = pgx.Identifier{column}.Sanitize()
+		if !isConflict[column] {
+			updates = append(updates, names[i]+" = excluded."+names[i])
+		}
+	}
+	conflicts := make([]string, len(conflictColumns))
+	for i, column := range conflictColumns {
+		conflicts[i] = pgx.Identifier{column}.Sanitize()
+	}
+
+	action := "DO NOTHING"
+	if len(updates) > 0 {
+		action = "DO UPDATE SET " + strings.Join(updates, ", ")
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) %s",
+		table, strings.Join(names, ", "), placeholders(1, len(columns)), strings.Join(conflicts, ", "), action)
+}
+
+// Upsert inserts rows into table in a bit.io database with an existing pool,
+// updating existing rows that conflict on conflictColumns, and returns the
+// number of rows inserted or updated. Each row holds one value per column. The
+// rows are sent as a single batch, which Postgres runs in one transaction, so
+// a failed upsert can be safely rerun.
+func (m *Manager) Upsert(ctx context.Context, dbName, table string, columns, conflictColumns []string, rows [][]any) (int64, error) {
+	sql, err := UpsertSQL(table, columns, conflictColumns)
+	if err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	pool, err := m.GetPool(dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to upsert into %s on db %s: %w", table, dbName, err)
+	}
+	batch := &pgx.Batch{}
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("unable to upsert into %s on db %s: row %d has %d values for %d columns", table, dbName, i, len(row), len(columns))
+		}
+		batch.Queue(sql, row...)
+	}
+	return execBatch(pool.SendBatch(ctx, batch), batch.Len(), table, dbName)
+}
+
+// Upsert inserts rows into the table, updating all non-key columns of existing
+// rows with the same primary key, and returns the number of rows inserted or
+// updated. As with Manager.Upsert, the rows are upserted in one transaction.
+// The pk fields of T must not be tagged auto.
+func (r *Repository[T]) Upsert(ctx context.Context, rows ...*T) (int64, error) {
+	if len(r.keys) == 0 {
+		return 0, fmt.Errorf("unable to upsert into %s: no pk fields", r.table)
+	}
+	for _, key := range r.keys {
+		if key.auto {
+			return 0, fmt.Errorf("unable to upsert into %s: pk field %s is auto", r.table, key.name)
+		}
+	}
+	columns := make([]string, len(r.inserts))
+	for i, column := range r.inserts {
+		columns[i] = column.name
+	}
+	keys := make([]string, len(r.keys))
+	for i, column := range r.keys {
+		keys[i] = column.name
+	}
+	sql := upsertSQL(r.table, columns, keys)
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	pool, err := r.manager.GetPool(r.dbName)
+	if err != nil {
+		return 0, fmt.Errorf("unable to upsert into %s: %w", r.table, err)
+	}
+	batch := &pgx.Batch{}
+	for _, row := range rows {
+		batch.Queue(sql, columnValues(reflect.ValueOf(row).Elem(), r.inserts)...)
+	}
+	return execBatch(pool.SendBatch(ctx, batch), batch.Len(), r.table, r.dbName)
+}
+
+// execBatch reads the results of n statements from results, closes it, and
+// returns the total number of rows affected.
+func execBatch(results pgx.BatchResults, n int, table, dbName string) (int64, error) {
+	var affected int64
+	for i := 0; i < n; i++ {
+		tag, err := results.Exec()
+		if err != nil {
+			results.Close()
+			return 0, fmt.Errorf("unable to upsert into %s on db %s: %w", table, dbName, err)
+		}
+		affected += tag.RowsAffected()
+	}
+	if err := results.Close(); err != nil {
+		return 0, fmt.Errorf("unable to upsert into %s on db %s: %w", table, dbName, err)
+	}
+	return affected, nil
+}
@@ -0,0 +1,361 @@
+package bitdotio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/arrow"
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/pool"
+)
+
+const (
+	// defaultProbeInterval is the default interval between health probes of
+	// the Postgres port of a database that failed over to the HTTP API.
+	defaultProbeInterval = 30 * time.Second
+
+	// defaultProbeTimeout is the default deadline for each health probe.
+	defaultProbeTimeout = 5 * time.Second
+)
+
+// Transport selects how QueryContext reaches a database. It can be set for all
+// databases with WithTransport, or per database with WithDatabaseTransport.
+type Transport int
+
+const (
+	// TransportAuto prefers a pooled Postgres connection and falls back to the
+	// HTTP query API when the database's Postgres port is unreachable, as is
+	// common on restricted corporate networks.
+	TransportAuto Transport = iota
+	// TransportPostgres always uses a pooled Postgres connection.
+	TransportPostgres
+	// TransportHTTP always uses the HTTP query API.
+	TransportHTTP
+)
+
+func (t Transport) String() string {
+	switch t {
+	case TransportAuto:
+		return "auto"
+	case TransportPostgres:
+		return "postgres"
+	case TransportHTTP:
+		return "http"
+	}
+	return fmt.Sprintf("Transport(%d)", int(t))
+}
+
+// failovers records databases whose Postgres port was found unreachable, so
+// that QueryContext uses the HTTP API for them until a health probe finds the
+// port reachable again.
+type failovers struct {
+	lock sync.Mutex
+	dbs  map[string]bool
+}
+
+func newFailovers() *failovers {
+	return &failovers{dbs: make(map[string]bool)}
+}
+
+// active reports whether dbName has failed over to the HTTP API.
+func (f *failovers) active(dbName string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.dbs[dbName]
+}
+
+// start fails dbName over to the HTTP API, and reports whether it had not
+// already failed over.
+func (f *failovers) start(dbName string) bool {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.dbs[dbName] {
+		return false
+	}
+	f.dbs[dbName] = true
+	return true
+}
+
+// end switches dbName back to Postgres.
+func (f *failovers) end(dbName string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.dbs, dbName)
+}
+
+// QueryContext executes a query on a bit.io database over the transport
+// selected with WithTransport or WithDatabaseTransport, and returns the result
+// in the same form as the HTTP API's Query. dbName must be a full,
+// user-qualified database name (e.g. `username/dbname`).
+//
+// With TransportAuto, the default, the query runs on the database's pool,
+// which is created with the pool defaults if it does not exist. If the pool
+// cannot reach the database's Postgres port, the query is retried with the
+// HTTP API, and the database fails over to the HTTP API. A background health
+// probe then pings the database's pool, see WithFailoverProbe, and switches
+// the database back to Postgres once its port is reachable again. Only errors
+// from before the query was sent, while connecting, fail over: a network
+// error after it was sent is returned, since the query may have run.
+//
+// Result values from Postgres are the Go values decoded by pgx, e.g. time.Time
+// for timestamps, whereas values from the HTTP API are decoded from JSON.
+func (b *BitDotIO) QueryContext(ctx context.Context, dbName, queryString string) (*QueryResult, error) {
+	return queryTransport(ctx, b, dbName,
+		func() (*QueryResult, error) { return b.queryHTTP(ctx, dbName, queryString) },
+		func() (*QueryResult, error) { return b.queryPostgres(ctx, dbName, queryString) },
+	)
+}
+
+// QueryColumnarContext is like QueryContext, but returns the result stored
+// column-wise, see ColumnarResult. Rows are not materialized: HTTP responses
+// are decoded directly into columns, and Postgres rows are appended to columns
+// as they are read.
+func (b *BitDotIO) QueryColumnarContext(ctx context.Context, dbName, queryString string) (*ColumnarResult, error) {
+	return queryTransport(ctx, b, dbName,
+		func() (*ColumnarResult, error) {
+			return b.Client.QueryColumnar(dbName, queryString, api.WithContext(ctx))
+		},
+		func() (*ColumnarResult, error) { return b.queryPostgresColumnar(ctx, dbName, queryString) },
+	)
+}
+
+// QueryArrowContext is like QueryColumnarContext, but returns the result as
+// Arrow record batches of at most batchSize rows each, or of all rows if
+// batchSize is not positive, see ColumnarResult.ArrowRecords. Write them to
+// an IPC stream with arrow.NewWriter, or use ColumnarResult.WriteArrow.
+func (b *BitDotIO) QueryArrowContext(ctx context.Context, dbName, queryString string, batchSize int) (*arrow.Schema, []*arrow.Record, error) {
+	result, err := b.QueryColumnarContext(ctx, dbName, queryString)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, records, err := result.ArrowRecords(batchSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	return schema, records, nil
+}
+
+// queryTransport runs a query on dbName with viaHTTP or viaPostgres, according
+// to the transport for dbName, failing over from Postgres to HTTP as described
+// for QueryContext.
+func queryTransport[T any](ctx context.Context, b *BitDotIO, dbName string, viaHTTP, viaPostgres func() (T, error)) (T, error) {
+	switch b.config.transportFor(dbName) {
+	case TransportHTTP:
+		return viaHTTP()
+	case TransportPostgres:
+		return viaPostgres()
+	}
+	if b.failovers.active(dbName) {
+		return viaHTTP()
+	}
+	result, err := viaPostgres()
+	if err != nil && pool.IsNetworkError(err) && unsent(err) && ctx.Err() == nil {
+		if b.failovers.start(dbName) {
+			b.logfCtx(ctx, "bitdotio: failing over to the HTTP API for db %s after error: %v", dbName, err)
+			b.lifecycle.goProbe(func(stop <-chan struct{}) { b.probe(dbName, stop) })
+		}
+		return viaHTTP()
+	}
+	return result, err
+}
+
+// connectError is an error creating the pool for a database or acquiring a
+// connection from it, before a statement was sent.
+type connectError struct {
+	err error
+}
+
+func (e *connectError) Error() string { return e.err.Error() }
+
+func (e *connectError) Unwrap() error { return e.err }
+
+// unsent reports whether err occurred before the statement of a query was
+// sent to Postgres, while connecting, so that the query can be retried with
+// the HTTP API without running a write twice.
+func unsent(err error) bool {
+	var connectErr *connectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+	var retry interface{ SafeToRetry() bool }
+	return errors.As(err, &retry) && retry.SafeToRetry()
+}
+
+// probe pings the pool for dbName, which has failed over to the HTTP API, once
+// per probe interval until its Postgres port is reachable, and then switches
+// it back to Postgres, or until stop is closed. Errors other than network
+// errors, such as a sleeping database, show that the port is reachable.
+func (b *BitDotIO) probe(dbName string, stop <-chan struct{}) {
+	interval, timeout := b.config.probeInterval, b.config.probeTimeout
+	if interval <= 0 {
+		interval = defaultProbeInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := b.Manager.Ping(ctx, dbName)
+		cancel()
+		if err == nil || !pool.IsNetworkError(err) {
+			b.logf("bitdotio: switching db %s back to Postgres", dbName)
+			b.failovers.end(dbName)
+			return
+		}
+	}
+}
+
+// logf logs a diagnostic message if a logger is configured.
+func (b *BitDotIO) logf(format string, v ...interface{}) {
+	b.logfCtx(context.Background(), format, v...)
+}
+
+// logfCtx logs a diagnostic message about an operation with ctx if a logger is
+// configured, passing ctx to a ContextLogger.
+func (b *BitDotIO) logfCtx(ctx context.Context, format string, v ...interface{}) {
+	switch logger := b.config.client.Logger.(type) {
+	case nil:
+	case ContextLogger:
+		logger.LogfCtx(ctx, format, v...)
+	default:
+		logger.Printf(format, v...)
+	}
+}
+
+// queryHTTP executes a query with the HTTP API, bounded by ctx.
+func (b *BitDotIO) queryHTTP(ctx context.Context, dbName, queryString string) (*QueryResult, error) {
+	return b.Client.Query(dbName, queryString, api.WithContext(ctx))
+}
+
+// queryPostgres executes a query on the pool for dbName, creating it if needed.
+func (b *BitDotIO) queryPostgres(ctx context.Context, dbName, queryString string) (*QueryResult, error) {
+	if _, err := b.queryPool(ctx, dbName); err != nil {
+		return nil, &connectError{err}
+	}
+	// Connect applies the pool's AcquireTimeout, WakeTimeout, and OnAcquire.
+	conn, err := b.Manager.Connect(ctx, dbName)
+	if err != nil {
+		return nil, &connectError{err}
+	}
+	defer conn.Release()
+	rows, err := conn.Query(ctx, queryString)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	defer rows.Close()
+
+	session := sessionLocation(rows.Conn().PgConn().ParameterStatus("TimeZone"))
+	result := &QueryResult{QueryString: queryString, Data: [][]interface{}{}}
+	result.Columns, result.Metadata = fieldTypes(rows)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+		}
+		result.Data = append(result.Data, values)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	if err = result.ConvertTimes(b.config.client.TimeMode, session); err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	return result, nil
+}
+
+// queryPostgresColumnar executes a query on the pool for dbName, creating it
+// if needed, and returns the result column-wise.
+func (b *BitDotIO) queryPostgresColumnar(ctx context.Context, dbName, queryString string) (*ColumnarResult, error) {
+	if _, err := b.queryPool(ctx, dbName); err != nil {
+		return nil, &connectError{err}
+	}
+	// Connect applies the pool's AcquireTimeout, WakeTimeout, and OnAcquire.
+	conn, err := b.Manager.Connect(ctx, dbName)
+	if err != nil {
+		return nil, &connectError{err}
+	}
+	defer conn.Release()
+	rows, err := conn.Query(ctx, queryString)
+	if err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	defer rows.Close()
+
+	session := sessionLocation(rows.Conn().PgConn().ParameterStatus("TimeZone"))
+	columns, metadata := fieldTypes(rows)
+	result := api.NewColumnarResult(queryString, columns, metadata)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+		}
+		if err = result.AppendRow(values); err != nil {
+			return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	if err = result.ConvertTimes(b.config.client.TimeMode, session); err != nil {
+		return nil, fmt.Errorf("unable to query db %s: %w", dbName, err)
+	}
+	return result, nil
+}
+
+// fieldTypes returns the column names of rows, in order, and a map from column
+// names to Postgres type names.
+func fieldTypes(rows pgx.Rows) ([]string, map[string]string) {
+	typeMap := rows.Conn().TypeMap()
+	fields := rows.FieldDescriptions()
+	columns := make([]string, 0, len(fields))
+	metadata := make(map[string]string, len(fields))
+	for _, field := range fields {
+		typeName := fmt.Sprint(field.DataTypeOID)
+		if t, ok := typeMap.TypeForOID(field.DataTypeOID); ok {
+			typeName = t.Name
+		}
+		metadata[field.Name] = typeName
+		columns = append(columns, field.Name)
+	}
+	return columns, metadata
+}
+
+// sessionLocation returns the location for a Postgres TimeZone setting, or nil
+// if it is not a known IANA time zone name.
+func sessionLocation(timeZone string) *time.Location {
+	if timeZone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// queryPool returns the pool for dbName, creating it with the pool defaults if
+// it does not exist.
+func (b *BitDotIO) queryPool(ctx context.Context, dbName string) (*pgxpool.Pool, error) {
+	if p, err := b.Manager.GetPool(dbName); err == nil {
+		return p, nil
+	}
+	p, err := b.Manager.CreatePool(ctx, dbName)
+	if errors.Is(err, pool.ErrPoolExists) {
+		// Another goroutine created the pool first.
+		return b.Manager.GetPool(dbName)
+	}
+	return p, err
+}
@@ -0,0 +1,72 @@
+package bitdotio
+
+import (
+	"fmt"
+	"time"
+)
+
+// Schedule determines when a recurring task, such as a sync or a backup, runs.
+// Next returns the first run time after t. Schedule has the same method as
+// github.com/robfig/cron's Schedule, so cron expressions parsed with that
+// package can be used directly.
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// everySchedule runs at a fixed interval.
+type everySchedule time.Duration
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// Every returns a Schedule that runs every interval, starting one interval
+// after it is scheduled. interval must be positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic(fmt.Sprintf("bitdotio: Every interval must be positive, got %s", interval))
+	}
+	return everySchedule(interval)
+}
+
+// dailySchedule runs once a day at a time of day in loc.
+type dailySchedule struct {
+	hour, minute int
+	loc          *time.Location
+}
+
+func (s dailySchedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	next := time.Date(t.Year(), t.Month(), t.Day(), s.hour, s.minute, 0, 0, s.loc)
+	if !next.After(t) {
+		next = time.Date(t.Year(), t.Month(), t.Day()+1, s.hour, s.minute, 0, 0, s.loc)
+	}
+	return next
+}
+
+// DailyAt returns a Schedule that runs once a day at hour:minute in loc, or in
+// UTC if loc is nil, e.g. DailyAt(2, 30, nil) for a nightly refresh.
+func DailyAt(hour, minute int, loc *time.Location) Schedule {
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		panic(fmt.Sprintf("bitdotio: invalid DailyAt time %02d:%02d", hour, minute))
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return dailySchedule{hour: hour, minute: minute, loc: loc}
+}
+
+// runScheduled calls run at each time of schedule until stop is closed. Runs
+// never overlap; a run that overruns the next scheduled time delays it.
+func runScheduled(schedule Schedule, stop <-chan struct{}, run func()) {
+	for {
+		timer := time.NewTimer(time.Until(schedule.Next(time.Now())))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		run()
+	}
+}
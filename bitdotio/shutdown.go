@@ -0,0 +1,104 @@
+package bitdotio
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// lifecycle tracks the background subsystems started through a BitDotIO and
+// the clients derived from it with With, so that Shutdown can stop them.
+type lifecycle struct {
+	lock     sync.Mutex
+	shutdown bool
+	stoppers []func()
+	// stop is closed by Shutdown to stop failover probes, which probes tracks.
+	stop   chan struct{}
+	probes sync.WaitGroup
+}
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{stop: make(chan struct{})}
+}
+
+// register records stop to be called by Shutdown, or calls it at once if
+// Shutdown has been called.
+func (l *lifecycle) register(stop func()) {
+	l.lock.Lock()
+	if !l.shutdown {
+		l.stoppers = append(l.stoppers, stop)
+		l.lock.Unlock()
+		return
+	}
+	l.lock.Unlock()
+	stop()
+}
+
+// goProbe runs probe in a goroutine that Shutdown stops by closing the
+// channel passed to it and waits for, unless Shutdown has been called.
+func (l *lifecycle) goProbe(probe func(stop <-chan struct{})) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.shutdown {
+		return
+	}
+	l.probes.Add(1)
+	go func() {
+		defer l.probes.Done()
+		probe(l.stop)
+	}()
+}
+
+// Shutdown stops b's background subsystems and then closes its pools, as the
+// lifecycle counterpart of NewBitDotIO for a program that is terminating,
+// e.g. on SIGTERM. It stops, in order:
+//
+//   - failover probes, see QueryContext
+//   - SyncManagers and BackupSchedulers constructed through b, or through
+//     clients derived from it with With, waiting for runs in progress
+//   - HealthCheckers started on b's pools, before closing all pools and
+//     waiting for connections in use to be released, see
+//     pool.Manager.Shutdown
+//
+// If ctx is done first, Shutdown returns an error wrapping ctx.Err(), and the
+// remaining steps finish in the background. Job polling, such as by
+// ImportAndWait, and listeners are bounded by their callers' contexts and are
+// not stopped. Shutdown should be the last call on b and its derived clients:
+// queries afterwards create new pools. Clients constructed with
+// AsServiceAccount manage their own pools and must be shut down separately.
+func (b *BitDotIO) Shutdown(ctx context.Context) error {
+	l := b.lifecycle
+	l.lock.Lock()
+	if !l.shutdown {
+		l.shutdown = true
+		close(l.stop)
+	}
+	stoppers := l.stoppers
+	l.stoppers = nil
+	l.lock.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		l.probes.Wait()
+		var wg sync.WaitGroup
+		for _, stop := range stoppers {
+			wg.Add(1)
+			go func(stop func()) {
+				defer wg.Done()
+				stop()
+			}(stop)
+		}
+		wg.Wait()
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		go func() {
+			<-stopped
+			b.Manager.Shutdown(context.Background())
+		}()
+		return fmt.Errorf("unable to stop background subsystems: %w", ctx.Err())
+	}
+	return b.Manager.Shutdown(ctx)
+}
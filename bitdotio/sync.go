@@ -0,0 +1,273 @@
+package bitdotio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+)
+
+// SyncMode controls how a sync loads data into its target table.
+type SyncMode int
+
+const (
+	// SyncAppend appends the source's rows to the target table.
+	SyncAppend SyncMode = iota
+	// SyncReplace replaces the target table's rows with the source's rows in
+	// one transaction, so readers see either the old or the new data.
+	SyncReplace
+)
+
+// SyncSource is the source of a sync: either a file at FileURL, loaded with an
+// import job, or the result of Query on another bit.io database, DBName.
+type SyncSource struct {
+	FileURL string
+	// Format is the format of the file at FileURL. Defaults to detection by
+	// bit.io.
+	Format ImportFormat
+	DBName string
+	Query  string
+}
+
+// SyncJob describes a dataset that is refreshed on a schedule.
+type SyncJob struct {
+	// Name identifies the job in its SyncManager.
+	Name   string
+	Source SyncSource
+	// TargetDB is the full name of the bit.io database to load, e.g.
+	// `username/dbname`, and TargetTable the table in it, which may be
+	// schema-qualified. For query sources, the table must already exist.
+	TargetDB    string
+	TargetTable string
+	Mode        SyncMode
+	Schedule    Schedule
+	// Timeout bounds each run. Defaults to no deadline.
+	Timeout time.Duration
+	// OnSuccess, if set, is called after each successful run.
+	OnSuccess func(job *SyncJob)
+	// OnFailure, if set, is called after each failed run with its error.
+	OnFailure func(job *SyncJob, err error)
+}
+
+// validate returns an error if the job is incomplete.
+func (j *SyncJob) validate() error {
+	switch {
+	case j.Name == "":
+		return errors.New("sync job has no name")
+	case (j.Source.FileURL == "") == (j.Source.Query == ""):
+		return fmt.Errorf("sync job %s must have exactly one of Source.FileURL and Source.Query", j.Name)
+	case j.Source.Query != "" && j.Source.DBName == "":
+		return fmt.Errorf("sync job %s has a query source without a DBName", j.Name)
+	case j.TargetDB == "" || j.TargetTable == "":
+		return fmt.Errorf("sync job %s has no target", j.Name)
+	case j.Schedule == nil:
+		return fmt.Errorf("sync job %s has no schedule", j.Name)
+	}
+	return j.Source.Format.Validate()
+}
+
+// SyncManager runs SyncJobs on their schedules, e.g. to refresh a dataset
+// nightly from a published CSV or from another database. Construct one with
+// NewSyncManager.
+type SyncManager struct {
+	b *BitDotIO
+	// ctx is the context of scheduled runs, which Stop cancels.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.Mutex
+	jobs    map[string]*syncEntry
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// syncEntry is a scheduled job. running serializes scheduled and manual runs.
+type syncEntry struct {
+	job     *SyncJob
+	stop    chan struct{}
+	running sync.Mutex
+}
+
+// NewSyncManager constructs a SyncManager that syncs through b. It is stopped
+// by b's Shutdown.
+func (b *BitDotIO) NewSyncManager() *SyncManager {
+	s := &SyncManager{b: b, jobs: make(map[string]*syncEntry)}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	b.lifecycle.register(s.Stop)
+	return s
+}
+
+// Add schedules job. Its first run is at the first time of its schedule after
+// now; use RunNow to run it immediately.
+func (s *SyncManager) Add(job *SyncJob) error {
+	if err := job.validate(); err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stopped {
+		return errors.New("sync manager is stopped")
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("sync job %s already exists", job.Name)
+	}
+	entry := &syncEntry{job: job, stop: make(chan struct{})}
+	s.jobs[job.Name] = entry
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		runScheduled(job.Schedule, entry.stop, func() { s.run(s.ctx, entry) })
+	}()
+	return nil
+}
+
+// Remove unschedules the job with name, if any. A run in progress finishes.
+func (s *SyncManager) Remove(name string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if entry, ok := s.jobs[name]; ok {
+		close(entry.stop)
+		delete(s.jobs, name)
+	}
+}
+
+// RunNow runs the job with name immediately, after any run in progress, and
+// returns its error. The job's callbacks are called as for a scheduled run.
+func (s *SyncManager) RunNow(ctx context.Context, name string) error {
+	s.lock.Lock()
+	entry, ok := s.jobs[name]
+	s.lock.Unlock()
+	if !ok {
+		return fmt.Errorf("sync job %s does not exist", name)
+	}
+	return s.run(ctx, entry)
+}
+
+// Stop unschedules all jobs, cancels the contexts of scheduled runs in
+// progress, and waits for them to finish. The SyncManager cannot be reused.
+func (s *SyncManager) Stop() {
+	s.lock.Lock()
+	s.stopped = true
+	for name, entry := range s.jobs {
+		close(entry.stop)
+		delete(s.jobs, name)
+	}
+	s.lock.Unlock()
+	s.cancel()
+	s.wg.Wait()
+}
+
+// run runs entry's job once and reports the outcome to its callbacks.
+func (s *SyncManager) run(ctx context.Context, entry *syncEntry) error {
+	entry.running.Lock()
+	defer entry.running.Unlock()
+	job := entry.job
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+	var err error
+	if job.Source.FileURL != "" {
+		err = s.syncFile(ctx, job)
+	} else {
+		err = s.syncQuery(ctx, job)
+	}
+	if err != nil {
+		err = fmt.Errorf("sync job %s failed: %w", job.Name, err)
+		s.b.logfCtx(ctx, "bitdotio: %v", err)
+		if job.OnFailure != nil {
+			job.OnFailure(job, err)
+		}
+		return err
+	}
+	if job.OnSuccess != nil {
+		job.OnSuccess(job)
+	}
+	return nil
+}
+
+// syncFile loads a job's file with an import job. In replace mode, the file is
+// imported into a staging table, whose rows then replace the target's in one
+// transaction.
+func (s *SyncManager) syncFile(ctx context.Context, job *SyncJob) error {
+	config := &api.ImportJobConfig{FileURL: job.Source.FileURL, Format: job.Source.Format}
+	table := job.TargetTable
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		config.SchemaName, table = schema, name
+	}
+	if job.Mode == SyncAppend {
+		_, err := s.b.ImportAndWait(ctx, job.TargetDB, table, config, nil)
+		return err
+	}
+
+	p, err := s.b.queryPool(ctx, job.TargetDB)
+	if err != nil {
+		return err
+	}
+	staging := job.TargetTable + "_bitdotio_sync"
+	stagingName := tableName(staging)
+	if _, err = p.Exec(ctx, "DROP TABLE IF EXISTS "+stagingName); err != nil {
+		return err
+	}
+	defer p.Exec(context.Background(), "DROP TABLE IF EXISTS "+stagingName)
+	if _, err = s.b.ImportAndWait(ctx, job.TargetDB, table+"_bitdotio_sync", config, nil); err != nil {
+		return err
+	}
+	target := tableName(job.TargetTable)
+	return pgx.BeginFunc(ctx, p, func(tx pgx.Tx) error {
+		statements := []string{
+			fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (LIKE %s)", target, stagingName),
+			"TRUNCATE " + target,
+			fmt.Sprintf("INSERT INTO %s SELECT * FROM %s", target, stagingName),
+		}
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// syncQuery copies the result of a job's query on the source database into
+// the target table in one transaction, truncating it first in replace mode.
+func (s *SyncManager) syncQuery(ctx context.Context, job *SyncJob) error {
+	source, err := s.b.queryPool(ctx, job.Source.DBName)
+	if err != nil {
+		return err
+	}
+	target, err := s.b.queryPool(ctx, job.TargetDB)
+	if err != nil {
+		return err
+	}
+	rows, err := source.Query(ctx, job.Source.Query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns := make([]string, len(rows.FieldDescriptions()))
+	for i, field := range rows.FieldDescriptions() {
+		columns[i] = field.Name
+	}
+	return pgx.BeginFunc(ctx, target, func(tx pgx.Tx) error {
+		if job.Mode == SyncReplace {
+			if _, err := tx.Exec(ctx, "TRUNCATE "+tableName(job.TargetTable)); err != nil {
+				return err
+			}
+		}
+		_, err := tx.CopyFrom(ctx, pgx.Identifier(strings.Split(job.TargetTable, ".")), columns, rows)
+		return err
+	})
+}
+
+// tableName quotes a table name, which may be schema-qualified, for SQL.
+func tableName(table string) string {
+	return api.QuoteIdentifier(strings.Split(table, ".")...)
+}
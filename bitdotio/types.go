@@ -0,0 +1,323 @@
+package bitdotio
+
+import (
+	"context"
+	"encoding/json"
+	"io/fs"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/api"
+	"github.com/bitdotioinc/go-bitdotio/bitdotio/pool"
+)
+
+// The developer API and connection pool types are defined in the api and pool
+// packages and aliased here so that programs using BitDotIO do not need to
+// import either subpackage.
+type (
+	APIClient              = api.APIClient
+	ContextAPIClient       = api.ContextAPIClient
+	APIResponse            = api.APIResponse
+	APIError               = api.APIError
+	AuditEvent             = api.AuditEvent
+	AuditHook              = api.AuditHook
+	ClientConfig           = api.ClientConfig
+	Column                 = api.Column
+	ColumnKind             = api.ColumnKind
+	ColumnarResult         = api.ColumnarResult
+	CallOption             = api.CallOption
+	CallStats              = api.CallStats
+	ContextLogger          = api.ContextLogger
+	Credentials            = api.Credentials
+	Database               = api.Database
+	DatabaseConfig         = api.DatabaseConfig
+	DatabaseID             = api.DatabaseID
+	DatabaseList           = api.DatabaseList
+	DatabaseUpdate         = api.DatabaseUpdate
+	DecodeOptions          = api.DecodeOptions
+	DefaultAPIClient       = api.DefaultAPIClient
+	DeprecationHook        = api.DeprecationHook
+	DeprecationNotice      = api.DeprecationNotice
+	ChunkImportResult      = api.ChunkImportResult
+	ChunkedImportOptions   = api.ChunkedImportOptions
+	ChunkedImportResult    = api.ChunkedImportResult
+	DirectoryImportOptions = api.DirectoryImportOptions
+	DownloadOptions        = api.DownloadOptions
+	Event                  = api.Event
+	EventBus               = api.EventBus
+	EventType              = api.EventType
+	ExportJob              = api.ExportJob
+	ExportJobConfig        = api.ExportJobConfig
+	FileFormat             = api.FileFormat
+	FileImportResult       = api.FileImportResult
+	GoogleSheet            = api.GoogleSheet
+	ImportFormat           = api.ImportFormat
+	ImportJob              = api.ImportJob
+	ImportJobConfig        = api.ImportJobConfig
+	ImportOptions          = api.ImportOptions
+	ImportReport           = api.ImportReport
+	InferHeader            = api.InferHeader
+	IntegrityError         = api.IntegrityError
+	Job                    = api.Job
+	JobError               = api.JobError
+	JSONB                  = api.JSONB
+	Logger                 = api.Logger
+	Middleware             = api.Middleware
+	Query                  = api.Query
+	QueryResult            = api.QueryResult
+	RequestHandler         = api.RequestHandler
+	ResponseSummary        = api.ResponseSummary
+	RetryPolicy            = api.RetryPolicy
+	ServiceAccount         = api.ServiceAccount
+	ServiceAccountList     = api.ServiceAccountList
+	StatsHook              = api.StatsHook
+	TimeMode               = api.TimeMode
+	TransferJob            = api.TransferJob
+	TransportConfig        = api.TransportConfig
+	Usage                  = api.Usage
+	Visibility             = api.Visibility
+
+	AcquireInfo    = pool.AcquireInfo
+	AutoMaxConns   = pool.AutoMaxConns
+	DBError        = pool.DBError
+	DatabaseHealth = pool.DatabaseHealth
+	DatabaseState  = pool.DatabaseState
+	CopyFormat     = pool.CopyFormat
+	Cursor         = pool.Cursor
+	ColumnChange   = pool.ColumnChange
+	DiffConfig     = pool.DiffConfig
+	ManagerConfig  = pool.ManagerConfig
+	HealthChecker  = pool.HealthChecker
+	HealthStatus   = pool.HealthStatus
+	Notification   = pool.Notification
+	PingError      = pool.PingError
+	PingErrorKind  = pool.PingErrorKind
+	Migration      = pool.Migration
+	PoolConfig     = pool.PoolConfig
+	SavedQuery     = pool.SavedQuery
+	StreamedRow    = pool.StreamedRow
+	TableDiff      = pool.TableDiff
+)
+
+var (
+	// ErrAcquireTimeout indicates that no pool connection became available
+	// within the pool's AcquireTimeout, see pool.ErrAcquireTimeout.
+	ErrAcquireTimeout = pool.ErrAcquireTimeout
+	// ErrSavedQueryNotFound indicates that no saved query has a requested name.
+	ErrSavedQueryNotFound = pool.ErrSavedQueryNotFound
+	// ErrStopPages stops QueryPages without error, see api.ErrStopPages.
+	ErrStopPages = api.ErrStopPages
+	// ErrResultTooLarge indicates a query result over the WithMaxRows limit.
+	ErrResultTooLarge = api.ErrResultTooLarge
+	// ErrNotConfirmed indicates an unconfirmed destructive call in protected mode.
+	ErrNotConfirmed = api.ErrNotConfirmed
+	// ErrInvalidName indicates a name rejected before making a request.
+	ErrInvalidName = api.ErrInvalidName
+
+	// Postgres error conditions returned by ClassifyError, see pool.ErrUniqueViolation etc.
+	ErrUniqueViolation       = pool.ErrUniqueViolation
+	ErrForeignKeyViolation   = pool.ErrForeignKeyViolation
+	ErrNotNullViolation      = pool.ErrNotNullViolation
+	ErrInsufficientPrivilege = pool.ErrInsufficientPrivilege
+	ErrQueryCanceled         = pool.ErrQueryCanceled
+	ErrTooManyConnections    = pool.ErrTooManyConnections
+)
+
+// Database states, see pool.DatabaseState.
+const (
+	DatabaseStateUnknown  = pool.DatabaseStateUnknown
+	DatabaseStateActive   = pool.DatabaseStateActive
+	DatabaseStateSleeping = pool.DatabaseStateSleeping
+)
+
+// Ping error kinds, see pool.PingErrorKind.
+const (
+	PingErrorUnknown  = pool.PingErrorUnknown
+	PingErrorAuth     = pool.PingErrorAuth
+	PingErrorSleeping = pool.PingErrorSleeping
+	PingErrorNetwork  = pool.PingErrorNetwork
+)
+
+// NewDefaultAPIClient constructs a default client for making API HTTP requests.
+func NewDefaultAPIClient(accessToken string) *DefaultAPIClient {
+	return api.NewDefaultAPIClient(accessToken)
+}
+
+// SDK event types, see api.EventType.
+const (
+	EventPoolCreated    = api.EventPoolCreated
+	EventPoolClosed     = api.EventPoolClosed
+	EventJobSubmitted   = api.EventJobSubmitted
+	EventJobFinished    = api.EventJobFinished
+	EventRequestRetried = api.EventRequestRetried
+	EventRateLimited    = api.EventRateLimited
+)
+
+// COPY formats, see pool.CopyFormat.
+const (
+	CopyFormatCSV    = pool.CopyFormatCSV
+	CopyFormatText   = pool.CopyFormatText
+	CopyFormatBinary = pool.CopyFormatBinary
+)
+
+// Database visibilities, see api.Visibility.
+const (
+	VisibilityPrivate = api.VisibilityPrivate
+	VisibilityPublic  = api.VisibilityPublic
+)
+
+// Import header inference modes, see api.InferHeader.
+const (
+	InferHeaderAuto     = api.InferHeaderAuto
+	InferHeaderFirstRow = api.InferHeaderFirstRow
+	InferHeaderNone     = api.InferHeaderNone
+)
+
+// Import formats, see api.ImportFormat.
+const (
+	ImportFormatCSV    = api.ImportFormatCSV
+	ImportFormatJSON   = api.ImportFormatJSON
+	ImportFormatNDJSON = api.ImportFormatNDJSON
+)
+
+// Column kinds of columnar query results, see api.ColumnKind.
+const (
+	ColumnAny     = api.ColumnAny
+	ColumnInt64   = api.ColumnInt64
+	ColumnFloat64 = api.ColumnFloat64
+	ColumnBool    = api.ColumnBool
+	ColumnString  = api.ColumnString
+	ColumnTime    = api.ColumnTime
+)
+
+// Time modes for query results, see api.TimeMode.
+const (
+	TimeModeDefault = api.TimeModeDefault
+	TimeModeUTC     = api.TimeModeUTC
+	TimeModeSession = api.TimeModeSession
+	TimeModeString  = api.TimeModeString
+)
+
+// Job states, see api.TransferJob.
+const (
+	JobStatePending = api.JobStatePending
+	JobStateRunning = api.JobStateRunning
+	JobStateDone    = api.JobStateDone
+	JobStateFailed  = api.JobStateFailed
+)
+
+// NewDatabaseConfig constructs a DatabaseConfig for a new database with an
+// explicit visibility.
+func NewDatabaseConfig(name string, visibility Visibility) *DatabaseConfig {
+	return api.NewDatabaseConfig(name, visibility)
+}
+
+// NewColumnarResult constructs an empty ColumnarResult for filling with
+// AppendRow, see api.NewColumnarResult.
+func NewColumnarResult(queryString string, columns []string, metadata map[string]string) *ColumnarResult {
+	return api.NewColumnarResult(queryString, columns, metadata)
+}
+
+// ValidateImport checks the local file of an import for parse and schema
+// problems without submitting it, see api.ValidateImport.
+func ValidateImport(config *ImportJobConfig) (*ImportReport, error) {
+	return api.ValidateImport(config)
+}
+
+// ClassifyError wraps Postgres errors with SDK error conditions such as
+// ErrUniqueViolation, see pool.ClassifyError.
+func ClassifyError(err error) error { return pool.ClassifyError(err) }
+
+// IsRetryable reports whether a database error may succeed if retried, see
+// pool.IsRetryable.
+func IsRetryable(err error) bool { return pool.IsRetryable(err) }
+
+// String returns a pointer to a string value, for use in optional fields.
+func String(v string) *string { return api.String(v) }
+
+// Bool returns a pointer to a bool value, for use in optional fields.
+func Bool(v bool) *bool { return api.Bool(v) }
+
+// Int64 returns a pointer to an int64 value, for use in optional fields.
+func Int64(v int64) *int64 { return api.Int64(v) }
+
+// ConvertValue converts a query result cell to a Go type, see
+// api.ConvertValue.
+func ConvertValue(typeName string, v interface{}) (interface{}, error) {
+	return api.ConvertValue(typeName, v)
+}
+
+// DecodeJSON decodes a json or jsonb cell into dest, see api.DecodeJSON.
+func DecodeJSON(cell interface{}, dest interface{}) error { return api.DecodeJSON(cell, dest) }
+
+// DecodeJSONColumn decodes a cell of a column of Postgres type typeName into
+// dest, see api.DecodeJSONColumn.
+func DecodeJSONColumn(cell interface{}, typeName string, dest interface{}) error {
+	return api.DecodeJSONColumn(cell, typeName, dest)
+}
+
+// QuoteIdentifier quotes an identifier for use in SQL, see api.QuoteIdentifier.
+func QuoteIdentifier(parts ...string) string { return api.QuoteIdentifier(parts...) }
+
+// QuoteLiteral quotes a string as a SQL literal, see api.QuoteLiteral.
+func QuoteLiteral(s string) string { return api.QuoteLiteral(s) }
+
+// RawField decodes a field of an API object's raw JSON, see api.RawField.
+func RawField(raw json.RawMessage, name string, dest interface{}) (bool, error) {
+	return api.RawField(raw, name, dest)
+}
+
+// ValidateName checks a bare database, schema, or table name, see
+// api.ValidateName.
+func ValidateName(kind, name string) error { return api.ValidateName(kind, name) }
+
+// ValidateFullDatabaseName checks a `username/dbname` database name, see
+// api.ValidateFullDatabaseName.
+func ValidateFullDatabaseName(fullDBName string) error {
+	return api.ValidateFullDatabaseName(fullDBName)
+}
+
+// WithConfirm confirms a destructive call in protected mode, see
+// api.WithConfirm.
+func WithConfirm(name string) CallOption { return api.WithConfirm(name) }
+
+// WithRowLimit truncates a Query result to n rows, see api.WithRowLimit.
+func WithRowLimit(n int) CallOption { return api.WithRowLimit(n) }
+
+// WithMaxRows fails a Query returning over n rows, see api.WithMaxRows.
+func WithMaxRows(n int) CallOption { return api.WithMaxRows(n) }
+
+// SplitStatements splits a SQL script into statements, see
+// api.SplitStatements.
+func SplitStatements(script string) []string { return api.SplitStatements(script) }
+
+// WithResponse records the HTTP status and headers of an API call's response,
+// see api.WithResponse.
+func WithResponse(resp *APIResponse) CallOption { return api.WithResponse(resp) }
+
+// WithContext makes a single API request with ctx, see api.WithContext.
+func WithContext(ctx context.Context) CallOption { return api.WithContext(ctx) }
+
+// WithTimeout applies a deadline to a single API request, see api.WithTimeout.
+func WithTimeout(timeout time.Duration) CallOption { return api.WithTimeout(timeout) }
+
+// NewRepository constructs a typed repository for a table in a bit.io database
+// with a pool managed by b, see pool.NewRepository.
+func NewRepository[T any](b *BitDotIO, dbName, table string) (*pool.Repository[T], error) {
+	return pool.NewRepository[T](b.Manager, dbName, table)
+}
+
+// CreateTableSQL returns the CREATE TABLE statement for a table with columns
+// mapped from the fields of struct v, see pool.CreateTableSQL.
+func CreateTableSQL(table string, v any) (string, error) { return pool.CreateTableSQL(table, v) }
+
+// UpsertSQL returns an INSERT ... ON CONFLICT DO UPDATE statement for a table,
+// see pool.UpsertSQL.
+func UpsertSQL(table string, columns, conflictColumns []string) (string, error) {
+	return pool.UpsertSQL(table, columns, conflictColumns)
+}
+
+// LoadMigrations reads SQL migration files from a directory of fsys, see
+// pool.LoadMigrations.
+func LoadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	return pool.LoadMigrations(fsys, dir)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// newFlagSet constructs a FlagSet for a subcommand that reports parse errors
+// to the caller instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// databaseColumns are the columns printed for databases.
+var databaseColumns = []string{"name", "private", "storage_usage_bytes", "storage_limit_bytes", "date_created"}
+
+// databaseResult constructs a result listing databases.
+func databaseResult(databases []*bitdotio.Database, value interface{}) *result {
+	rows := make([][]string, len(databases))
+	for i, db := range databases {
+		rows[i] = []string{
+			db.Name,
+			strconv.FormatBool(db.IsPrivate),
+			strconv.FormatInt(db.StorageUsageBytes, 10),
+			strconv.FormatInt(db.StorageLimitBytes, 10),
+			db.DateCreated.Format(time.RFC3339),
+		}
+	}
+	return &result{columns: databaseColumns, rows: rows, value: value, id: 0}
+}
+
+func runDBList(b *bitdotio.BitDotIO, out *output, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	databases, err := b.ListDatabases()
+	if err != nil {
+		return err
+	}
+	return out.print(databaseResult(databases, databases))
+}
+
+func runDBCreate(b *bitdotio.BitDotIO, out *output, args []string) error {
+	flags := newFlagSet("db create")
+	public := flags.Bool("public", false, "make the database public")
+	storageLimit := flags.Int64("storage-limit", 0, "storage limit in bytes")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	visibility := bitdotio.VisibilityPrivate
+	if *public {
+		visibility = bitdotio.VisibilityPublic
+	}
+	config := bitdotio.NewDatabaseConfig(flags.Arg(0), visibility).WithStorageLimit(*storageLimit)
+	database, err := b.CreateDatabase(config)
+	if err != nil {
+		return err
+	}
+	return out.print(databaseResult([]*bitdotio.Database{database}, database))
+}
+
+func runDBDelete(b *bitdotio.BitDotIO, out *output, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	username, dbName, err := splitDBName(args[0])
+	if err != nil {
+		return err
+	}
+	return b.DeleteDatabase(username, dbName)
+}
+
+func runKeyCreate(b *bitdotio.BitDotIO, out *output, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	credentials, err := b.CreateKey()
+	if err != nil {
+		return err
+	}
+	return out.print(&result{
+		columns: []string{"username", "api_key"},
+		rows:    [][]string{{credentials.Username, credentials.APIKEY}},
+		value:   credentials,
+		id:      1,
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// jobResult constructs a result describing an import or export job.
+func jobResult(job *bitdotio.TransferJob, value interface{}) *result {
+	return &result{
+		columns: []string{"id", "state", "status_url"},
+		rows:    [][]string{{job.ID, job.State, job.StatusURL}},
+		value:   value,
+		id:      0,
+	}
+}
+
+func runImport(b *bitdotio.BitDotIO, out *output, args []string) error {
+	flags := newFlagSet("import")
+	filePath := flags.String("file", "", "path of a local file to import")
+	fileURL := flags.String("url", "", "URL of a file to import")
+	sheetURL := flags.String("sheet", "", "URL of a Google Sheets spreadsheet to import")
+	worksheet := flags.String("worksheet", "", "name of the worksheet to import from -sheet")
+	cellRange := flags.String("range", "", "range of cells to import from -sheet, in A1 notation")
+	schema := flags.String("schema", "", "schema of the destination table")
+	inferHeader := flags.String("infer-header", "", "header inference mode: auto, first_row, or no_header")
+	dryRun := flags.Bool("dry-run", false, "check -file for problems without importing it")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		return errUsage
+	}
+	sources := 0
+	for _, source := range []string{*filePath, *fileURL, *sheetURL} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources != 1 || (*sheetURL == "" && (*worksheet != "" || *cellRange != "")) || (*dryRun && *filePath == "") {
+		return errUsage
+	}
+
+	config := &bitdotio.ImportJobConfig{
+		SchemaName:  *schema,
+		InferHeader: bitdotio.InferHeader(*inferHeader),
+		FileURL:     *fileURL,
+	}
+	if *sheetURL != "" {
+		config.GoogleSheet = &bitdotio.GoogleSheet{URL: *sheetURL, Worksheet: *worksheet, Range: *cellRange}
+	}
+	if *dryRun {
+		config.FilePath = *filePath
+		report, err := bitdotio.ValidateImport(config)
+		if err != nil {
+			return err
+		}
+		return out.print(&result{
+			columns: []string{"format", "header", "columns", "rows", "warnings"},
+			rows: [][]string{{
+				string(report.Format),
+				strconv.FormatBool(report.Header),
+				strings.Join(report.Columns, ","),
+				strconv.FormatInt(report.Rows, 10),
+				strings.Join(report.Warnings, "; "),
+			}},
+			value: report,
+			id:    -1,
+		})
+	}
+	if *filePath != "" {
+		f, err := os.Open(*filePath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		config.File = f
+	}
+	importJob, err := b.CreateImportJob(flags.Arg(0), flags.Arg(1), config)
+	if err != nil {
+		return err
+	}
+	return out.print(jobResult(&importJob.TransferJob, importJob))
+}
+
+func runExport(b *bitdotio.BitDotIO, out *output, args []string) error {
+	flags := newFlagSet("export")
+	table := flags.String("table", "", "name of a table to export")
+	query := flags.String("query", "", "query whose results to export")
+	schema := flags.String("schema", "", "schema of the table to export")
+	format := flags.String("format", "", "export format: csv, json, xls, or parquet (default csv)")
+	fileName := flags.String("file-name", "", "name of the exported file")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	exportJob, err := b.CreateExportJob(flags.Arg(0), &bitdotio.ExportJobConfig{
+		QueryString:  *query,
+		TableName:    *table,
+		SchemaName:   *schema,
+		FileName:     *fileName,
+		ExportFormat: bitdotio.FileFormat(*format),
+	})
+	if err != nil {
+		return err
+	}
+	return out.print(jobResult(&exportJob.TransferJob, exportJob))
+}
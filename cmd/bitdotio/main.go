@@ -0,0 +1,118 @@
+// Command bitdotio is a command line interface for bit.io built on the
+// go-bitdotio SDK.
+//
+// Usage:
+//
+//	bitdotio [-token TOKEN] [-output table|json|csv] [-quiet] <command> [arguments]
+//
+// The access token defaults to the BITDOTIO_TOKEN environment variable. Results
+// are printed as a table by default, or as JSON or CSV for use in scripts.
+// With -quiet, only IDs (such as database names or job IDs) are printed.
+// Run `bitdotio help` for a list of commands.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// command defines a CLI subcommand.
+type command struct {
+	name  string
+	usage string
+	run   func(b *bitdotio.BitDotIO, out *output, args []string) error
+}
+
+// commands lists all subcommands in the order shown by help.
+var commands = []*command{
+	{"db list", "db list", runDBList},
+	{"db create", "db create [-public] [-storage-limit BYTES] NAME", runDBCreate},
+	{"db delete", "db delete USER/DB", runDBDelete},
+	{"key create", "key create", runKeyCreate},
+	{"import", "import [-file PATH | -url URL | -sheet URL [-worksheet NAME] [-range A1:D100]] [-schema SCHEMA] [-infer-header MODE] [-dry-run] USER/DB TABLE", runImport},
+	{"export", "export [-table TABLE | -query SQL] [-schema SCHEMA] [-format FORMAT] [-file-name NAME] USER/DB", runExport},
+	{"query", "query USER/DB SQL", runQuery},
+	{"shell", "shell USER/DB", runShell},
+}
+
+// errUsage indicates that a command was invoked with invalid arguments.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	flags := flag.NewFlagSet("bitdotio", flag.ExitOnError)
+	token := flags.String("token", os.Getenv("BITDOTIO_TOKEN"), "bit.io access token (default $BITDOTIO_TOKEN)")
+	format := flags.String("output", outputTable, "output format: table, json, or csv")
+	quiet := flags.Bool("quiet", false, "print only IDs, or rows without headers")
+	flags.Usage = func() { printUsage(flags) }
+	flags.Parse(os.Args[1:])
+
+	args := flags.Args()
+	if len(args) == 0 || args[0] == "help" {
+		printUsage(flags)
+		return
+	}
+	cmd, cmdArgs := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "bitdotio: unknown command %q\n", strings.Join(args, " "))
+		printUsage(flags)
+		os.Exit(2)
+	}
+	if *token == "" {
+		fmt.Fprintln(os.Stderr, "bitdotio: an access token is required, set BITDOTIO_TOKEN or pass -token")
+		os.Exit(2)
+	}
+
+	out, err := newOutput(os.Stdout, *format, *quiet)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bitdotio: %v\n", err)
+		os.Exit(2)
+	}
+
+	b := bitdotio.NewBitDotIO(*token)
+	if err := cmd.run(b, out, cmdArgs); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: bitdotio %s\n", cmd.usage)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "bitdotio %s: %v\n", cmd.name, err)
+		os.Exit(1)
+	}
+}
+
+// findCommand matches the longest command name prefix of args.
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):]
+		}
+	}
+	return nil, nil
+}
+
+func printUsage(flags *flag.FlagSet) {
+	fmt.Fprintln(os.Stderr, "usage: bitdotio [-token TOKEN] [-output table|json|csv] [-quiet] <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nflags:")
+	flags.PrintDefaults()
+}
+
+// splitDBName splits a full database name into its username and database name.
+func splitDBName(fullDBName string) (string, string, error) {
+	username, dbName, ok := strings.Cut(fullDBName, "/")
+	if !ok || username == "" || dbName == "" {
+		return "", "", fmt.Errorf("database name must be of the form USER/DB, got %q", fullDBName)
+	}
+	return username, dbName, nil
+}
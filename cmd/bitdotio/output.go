@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats selected with the -output flag.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+)
+
+// output renders command results in the format selected by the global flags.
+type output struct {
+	w      io.Writer
+	format string
+	// quiet prints only the ID column of results that have one, or rows
+	// without a header otherwise, for use in shell pipelines.
+	quiet bool
+}
+
+// newOutput constructs an output, validating format.
+func newOutput(w io.Writer, format string, quiet bool) (*output, error) {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return &output{w: w, format: format, quiet: quiet}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q, must be table, json, or csv", format)
+}
+
+// result is a command result that can be rendered in any output format.
+type result struct {
+	// columns names the columns of rows, or is nil if the result has no header.
+	columns []string
+	rows    [][]string
+	// value is encoded for JSON output.
+	value interface{}
+	// id is the index of the column printed in quiet mode, or -1 if the result
+	// has no ID column.
+	id int
+}
+
+// print renders r.
+func (o *output) print(r *result) error {
+	if o.quiet && r.id >= 0 {
+		for _, row := range r.rows {
+			if _, err := fmt.Fprintln(o.w, row[r.id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	columns := r.columns
+	if o.quiet {
+		columns = nil
+	}
+	switch o.format {
+	case outputJSON:
+		encoder := json.NewEncoder(o.w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r.value)
+	case outputCSV:
+		w := csv.NewWriter(o.w)
+		if columns != nil {
+			w.Write(columns)
+		}
+		w.WriteAll(r.rows)
+		return w.Error()
+	default:
+		w := tabwriter.NewWriter(o.w, 0, 4, 2, ' ', 0)
+		if columns != nil {
+			rules := make([]string, len(columns))
+			for i, column := range columns {
+				rules[i] = strings.Repeat("-", len(column))
+			}
+			fmt.Fprintln(w, strings.Join(columns, "\t"))
+			fmt.Fprintln(w, strings.Join(rules, "\t"))
+		}
+		for _, row := range r.rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return w.Flush()
+	}
+}
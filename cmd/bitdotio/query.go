@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+func runQuery(b *bitdotio.BitDotIO, out *output, args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	queryResult, err := b.Query(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, len(queryResult.Data))
+	for i, row := range queryResult.Data {
+		rows[i] = make([]string, len(row))
+		for j, value := range row {
+			rows[i][j] = formatValue(value)
+		}
+	}
+	return out.print(&result{columns: queryResult.Columns, rows: rows, value: queryResult, id: -1})
+}
+
+// formatValue formats a single result value for display.
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "NULL"
+	}
+	return fmt.Sprint(value)
+}
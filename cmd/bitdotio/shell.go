@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/bitdotioinc/go-bitdotio/bitdotio"
+)
+
+// historyFileName is the name of the shell history file in the home directory.
+const historyFileName = ".bitdotio_history"
+
+// shell is an interactive SQL session against a single bit.io database.
+type shell struct {
+	dbName  string
+	pool    *pgxpool.Pool
+	output  *output
+	history []string
+	// historyFile is nil if the history file could not be opened.
+	historyFile *os.File
+}
+
+func runShell(b *bitdotio.BitDotIO, out *output, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	dbName := args[0]
+	pool, err := b.CreatePool(context.Background(), dbName)
+	if err != nil {
+		return err
+	}
+	defer b.ClosePool(dbName)
+
+	s := &shell{dbName: dbName, pool: pool, output: out}
+	s.loadHistory()
+	if s.historyFile != nil {
+		defer s.historyFile.Close()
+	}
+	fmt.Printf("Connected to %s. Terminate statements with ';', type \\q to quit or \\h for history.\n", dbName)
+	return s.run(os.Stdin, os.Stdout)
+}
+
+// run reads statements from in until EOF or \q, executing each as it is
+// terminated with a semicolon.
+func (s *shell) run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var statement strings.Builder
+	for {
+		if statement.Len() == 0 {
+			fmt.Fprintf(out, "%s=> ", s.dbName)
+		} else {
+			fmt.Fprintf(out, "%s-> ", s.dbName)
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+
+		if statement.Len() == 0 {
+			switch line {
+			case "":
+				continue
+			case `\q`:
+				return nil
+			case `\h`:
+				for i, entry := range s.history {
+					fmt.Fprintf(out, "%5d  %s\n", i+1, entry)
+				}
+				continue
+			}
+		}
+
+		if statement.Len() > 0 {
+			statement.WriteString("\n")
+		}
+		statement.WriteString(line)
+		if !strings.HasSuffix(line, ";") {
+			continue
+		}
+		sql := statement.String()
+		statement.Reset()
+		s.addHistory(sql)
+		if err := s.execute(sql, out); err != nil {
+			fmt.Fprintf(out, "ERROR: %v\n", err)
+		}
+	}
+}
+
+// execute runs a statement and renders any result rows in the selected output
+// format. The statement can be cancelled with an interrupt signal.
+func (s *shell) execute(sql string, out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rows, err := s.pool.Query(ctx, sql)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	r := &result{id: -1}
+	for _, field := range fields {
+		r.columns = append(r.columns, field.Name)
+	}
+	objects := []map[string]interface{}{}
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		cells := make([]string, len(values))
+		object := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			cells[i] = formatValue(value)
+			object[r.columns[i]] = value
+		}
+		r.rows = append(r.rows, cells)
+		objects = append(objects, object)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	r.value = objects
+
+	o := *s.output
+	o.w = out
+	if len(fields) > 0 {
+		if err := o.print(r); err != nil {
+			return err
+		}
+	}
+	if o.format != outputTable || o.quiet {
+		return nil
+	}
+	if len(fields) > 0 {
+		fmt.Fprintf(out, "(%d rows)\n", len(r.rows))
+	} else {
+		fmt.Fprintln(out, rows.CommandTag().String())
+	}
+	return nil
+}
+
+// loadHistory reads previous statements from the history file and opens it
+// for appending. History is best-effort, so errors are ignored.
+func (s *shell) loadHistory() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return
+	}
+	path := filepath.Join(home, historyFileName)
+	if data, err := os.ReadFile(path); err == nil {
+		for _, entry := range strings.Split(string(data), "\x00\n") {
+			if entry != "" {
+				s.history = append(s.history, entry)
+			}
+		}
+	}
+	s.historyFile, _ = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
+
+// addHistory records a statement in memory and in the history file. Entries
+// are separated by a NUL line terminator since statements may span lines.
+func (s *shell) addHistory(sql string) {
+	s.history = append(s.history, sql)
+	if s.historyFile != nil {
+		fmt.Fprintf(s.historyFile, "%s\x00\n", sql)
+	}
+}